@@ -6,21 +6,32 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/facebookincubator/contest/plugins/listeners/httplistener"
 )
 
-// Unauthenticated, unencrypted sample HTTP client for ConTest.
-// Requires the `httplistener` plugin for the API listener.
+// Sample HTTP client for ConTest. Requires the `httplistener` plugin for the
+// API listener. Requests can be authenticated with a bearer token (-token), an
+// HMAC signature (-hmacKey) or a client certificate (-cert and -key), and the
+// server certificate can be verified against a custom CA (-cacert).
 //
 // Usage examples:
 // Start a job with the provided job description from a JSON file
@@ -28,6 +39,24 @@ import (
 //
 // Get the status of a job whose ID is 10
 //   ./contestcli-http status 10
+//
+// List the completed jobs tagged with "nightly"
+//   ./contestcli-http -states JobStateCompleted -tags nightly list
+//
+// Follow the events of a job whose ID is 10 until it terminates
+//   ./contestcli-http watch 10
+//
+// Release the target whose ID is 42 from quarantine
+//   ./contestcli-http release 42
+//
+// Detach the target whose ID is 42 from the job whose ID is 10
+//   ./contestcli-http detach 10 42
+//
+// Start the job described in a JSON file every night at 2am
+//   ./contestcli-http -cron "0 2 * * *" schedule < start.json
+//
+// Pause the schedule whose ID is 3
+//   ./contestcli-http pauseschedule 3
 
 const (
 	defaultRequestor = "contestcli-http"
@@ -36,13 +65,36 @@ const (
 var (
 	flagAddr      = flag.String("addr", "http://localhost:8080", "ConTest server [scheme://]host:port[/basepath] to connect to")
 	flagRequestor = flag.String("r", defaultRequestor, "Identifier of the requestor of the API call")
+	flagFailed    = flag.Bool("failed", false, "Only retry the targets that failed in the original job (retry command only)")
+
+	flagUnixSocket = flag.String("unixSocket", "", "Unix socket to connect to, instead of the host and port of -addr")
+
+	flagToken   = flag.String("token", "", "Bearer token to authenticate the requests with")
+	flagHMACKey = flag.String("hmacKey", "", "HMAC key to sign the requests with, as <key ID>:<key>")
+	flagCACert  = flag.String("cacert", "", "CA certificate file to verify the server certificate with")
+	flagCert    = flag.String("cert", "", "Client certificate file to authenticate the requests with")
+	flagKey     = flag.String("key", "", "Private key file of the client certificate")
+
+	flagStates       = flag.String("states", "", "Comma-separated list of job states, e.g. JobStateCompleted (list command only)")
+	flagTags         = flag.String("tags", "", "Comma-separated list of job tags (list command only)")
+	flagAllTags      = flag.Bool("alltags", false, "Only list the jobs with all the tags, instead of any of them (list command only)")
+	flagJobRequestor = flag.String("requestedby", "", "Requestor of the jobs (list command only)")
+	flagName         = flag.String("name", "", "Job name pattern, where * matches any sequence of characters and ? a single character (list command only)")
+	flagSince        = flag.String("since", "", "Only list the jobs requested at or after the given RFC3339 time (list command only)")
+	flagUntil        = flag.String("until", "", "Only list the jobs requested at or before the given RFC3339 time (list command only)")
+	flagOffset       = flag.Uint("offset", 0, "Number of jobs to skip (list command only)")
+	flagLimit        = flag.Uint("limit", 0, "Maximum number of jobs to list, 0 means no limit (list command only)")
+
+	flagCron     = flag.String("cron", "", "Cron expression of the schedule, e.g. \"0 2 * * *\" (schedule command only)")
+	flagInterval = flag.Duration("interval", 0, "Interval between the firings of the schedule, instead of -cron (schedule command only)")
+	flagOverlap  = flag.String("overlap", "", "What to do when the schedule fires while its previous job is running: skip (default), queue or cancel-previous (schedule command only)")
 )
 
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage of contestcli-http:\n\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  contestcli-http [args] command\n\n")
-		fmt.Fprintf(flag.CommandLine.Output(), "command: start, stop, status, retry, version\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "command: start, stop, status, retry, list, watch, quarantine, release, detach, schedule, schedules, pauseschedule, resumeschedule, deleteschedule, version\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  start\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "        start a new job using the job description passed via stdin\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  stop int\n")
@@ -50,7 +102,27 @@ func main() {
 		fmt.Fprintf(flag.CommandLine.Output(), "  status int\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "        get the status of a job by job ID\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  retry int\n")
-		fmt.Fprintf(flag.CommandLine.Output(), "        retry a job by job ID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        retry a job by job ID, optionally only on the failed targets (see -failed)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  list\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        list the jobs matching the filters, from the most recent (see -states, -tags, -alltags, -requestedby, -name, -since, -until, -offset, -limit)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  watch int\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        follow the events of a job by job ID, until the job terminates\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  quarantine\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        list the targets quarantined because they failed too many of their recent runs\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  release string\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        release a target from quarantine by target ID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  detach int string\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        detach a target from a running job by job ID and target ID, and unlock it\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  schedule\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        start a new job using the job description passed via stdin each time the schedule fires (see -cron, -interval, -overlap)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  schedules\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        list the schedules\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  pauseschedule int\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        pause a schedule by schedule ID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  resumeschedule int\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        resume a paused schedule by schedule ID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  deleteschedule int\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "        delete a schedule by schedule ID\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  version\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "        request the API version to the server\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "\nargs:\n")
@@ -68,7 +140,10 @@ func run(verb string) error {
 	var (
 		params = url.Values{}
 	)
-	params.Set("requestor", *flagRequestor)
+	if !authenticated() || requestorFlagSet() {
+		// authenticated requests default to the authenticated requestor
+		params.Set("requestor", *flagRequestor)
+	}
 	switch verb {
 	case "start":
 		fmt.Fprintf(os.Stderr, "Reading from stdin...\n")
@@ -77,12 +152,60 @@ func run(verb string) error {
 			return fmt.Errorf("failed to parse job descriptor: %v", err)
 		}
 		params.Add("jobDesc", string(jobDesc))
-	case "stop", "status", "retry":
+	case "stop", "status", "retry", "watch":
 		jobID := flag.Arg(1)
 		if jobID == "" {
 			return errors.New("missing job ID")
 		}
 		params.Set("jobID", jobID)
+		if verb == "retry" && *flagFailed {
+			params.Set("failedTargetsOnly", "true")
+		}
+	case "list":
+		params.Set("states", *flagStates)
+		params.Set("tags", *flagTags)
+		params.Set("allTags", strconv.FormatBool(*flagAllTags))
+		params.Set("jobRequestor", *flagJobRequestor)
+		params.Set("name", *flagName)
+		params.Set("requestedAfter", *flagSince)
+		params.Set("requestedBefore", *flagUntil)
+		params.Set("offset", strconv.FormatUint(uint64(*flagOffset), 10))
+		params.Set("limit", strconv.FormatUint(uint64(*flagLimit), 10))
+	case "quarantine":
+		// no params to list the quarantined targets
+	case "release":
+		targetID := flag.Arg(1)
+		if targetID == "" {
+			return errors.New("missing target ID")
+		}
+		params.Set("targetID", targetID)
+	case "detach":
+		jobID, targetID := flag.Arg(1), flag.Arg(2)
+		if jobID == "" || targetID == "" {
+			return errors.New("missing job ID or target ID")
+		}
+		params.Set("jobID", jobID)
+		params.Set("targetID", targetID)
+	case "schedule":
+		fmt.Fprintf(os.Stderr, "Reading from stdin...\n")
+		jobDesc, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to parse job descriptor: %v", err)
+		}
+		params.Add("jobDesc", string(jobDesc))
+		params.Set("cron", *flagCron)
+		if *flagInterval != 0 {
+			params.Set("interval", flagInterval.String())
+		}
+		params.Set("overlapPolicy", *flagOverlap)
+	case "schedules":
+		// no params to list the schedules
+	case "pauseschedule", "resumeschedule", "deleteschedule":
+		scheduleID := flag.Arg(1)
+		if scheduleID == "" {
+			return errors.New("missing schedule ID")
+		}
+		params.Set("scheduleID", scheduleID)
 	case "version":
 		// no params for protocol version
 	default:
@@ -99,13 +222,25 @@ func run(verb string) error {
 		return fmt.Errorf("unsupported URL scheme '%s', please specify either http or https", u.Scheme)
 	}
 	u.Path += "/" + verb
+	if verb == "watch" {
+		return watch(u, params)
+	}
 	fmt.Fprintf(os.Stderr, "Requesting URL %s with requestor ID '%s'\n", u.String(), *flagRequestor)
 	fmt.Fprintf(os.Stderr, "  with params:\n")
 	for k, v := range params {
 		fmt.Fprintf(os.Stderr, "    %s: %s\n", k, v)
 	}
 	fmt.Fprintf(os.Stderr, "\n")
-	resp, err := http.PostForm(u.String(), params)
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", u.String(), strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("cannot create HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := do(client, req)
 	if err != nil {
 		return fmt.Errorf("HTTP POST failed: %v", err)
 	}
@@ -158,3 +293,154 @@ func run(verb string) error {
 	fmt.Println(string(indentedJSON))
 	return nil
 }
+
+// authenticated returns whether the requests carry credentials.
+func authenticated() bool {
+	return *flagToken != "" || *flagHMACKey != "" || *flagCert != ""
+}
+
+// requestorFlagSet returns whether the requestor was passed explicitly.
+func requestorFlagSet() bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "r" {
+			set = true
+		}
+	})
+	return set
+}
+
+// newClient returns an HTTP client configured with the TLS and socket flags.
+func newClient() (*http.Client, error) {
+	if *flagCACert == "" && *flagCert == "" && *flagUnixSocket == "" {
+		return http.DefaultClient, nil
+	}
+	transport := http.Transport{}
+	if *flagUnixSocket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", *flagUnixSocket)
+		}
+	}
+	var tlsConfig tls.Config
+	if *flagCACert != "" {
+		pem, err := ioutil.ReadFile(*flagCACert)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA certificate: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificate found in %s", *flagCACert)
+		}
+	}
+	if *flagCert != "" {
+		cert, err := tls.LoadX509KeyPair(*flagCert, *flagKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = &tlsConfig
+	return &http.Client{Transport: &transport}, nil
+}
+
+// do sends a request, authenticated with the token or signed with the HMAC key
+// passed via flags, if any.
+func do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if *flagToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*flagToken)
+	}
+	if *flagHMACKey != "" {
+		parts := strings.SplitN(*flagHMACKey, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("HMAC key must be in the form <key ID>:<key>")
+		}
+		if err := httplistener.SignRequest(req, parts[0], []byte(parts[1])); err != nil {
+			return nil, err
+		}
+	}
+	return client.Do(req)
+}
+
+// watch streams the events of a job to stdout, one JSON object per line. If
+// the connection drops, it reconnects and resumes from the last event received,
+// until the server signals the end of the stream.
+func watch(u *url.URL, params url.Values) error {
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	var lastEventID string
+	for {
+		u.RawQuery = params.Encode()
+		fmt.Fprintf(os.Stderr, "Watching URL %s with requestor ID '%s'\n", u.String(), *flagRequestor)
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return fmt.Errorf("cannot create HTTP request: %v", err)
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := do(client, req)
+		if err != nil {
+			return fmt.Errorf("HTTP GET failed: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			return fmt.Errorf("the server responded with status %s: %s", resp.Status, body)
+		}
+		ended, err := readEvents(resp.Body, &lastEventID)
+		resp.Body.Close()
+		if ended {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Connection interrupted (%v), reconnecting\n", err)
+		time.Sleep(time.Second)
+	}
+}
+
+// readEvents reads Server-Sent Events until the end of the stream, printing
+// the data of test and framework events to stdout. It returns true if the
+// server signalled that no more events will be emitted.
+func readEvents(r io.Reader, lastEventID *string) (bool, error) {
+	var (
+		scanner = bufio.NewScanner(r)
+		id      string
+		name    string
+		data    string
+	)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// end of an event
+			switch name {
+			case "end":
+				return true, nil
+			case "gap":
+				fmt.Fprintf(os.Stderr, "Some events were lost, use the status command to get the full job status\n")
+			case "test", "framework":
+				fmt.Println(data)
+			}
+			if id != "" {
+				*lastEventID = id
+			}
+			id, name, data = "", "", ""
+		case strings.HasPrefix(line, ":"):
+			// comment, e.g. keep-alive
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,447 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/facebookincubator/contest/pkg/config"
+	"github.com/facebookincubator/contest/pkg/logging"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener"
+	"github.com/facebookincubator/contest/plugins/listeners/httplistener"
+	"github.com/facebookincubator/contest/plugins/storage/rdbms"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultDBURI = "contest:contest@tcp(localhost:3306)/contest?parseTime=true"
+
+// envPrefix is the prefix of the environment variables overriding the
+// configuration. The variable of a flag is named after the flag, e.g.
+// CONTEST_DB_URI for -dbURI.
+const envPrefix = "CONTEST_"
+
+// serverConfig is the configuration of the server. It is read from a YAML or
+// JSON file, and each setting can be overridden with an environment variable
+// and with a flag, in increasing order of precedence.
+type serverConfig struct {
+	LogLevel       string           `yaml:"logLevel"`
+	Storage        storageConfig    `yaml:"storage"`
+	API            apiConfig        `yaml:"api"`
+	TargetLocker   string           `yaml:"targetLocker"`
+	Inventory      string           `yaml:"inventory"`
+	Quarantine     quarantineConfig `yaml:"quarantine"`
+	Queue          queueConfig      `yaml:"queue"`
+	HealthCheckCmd bool             `yaml:"healthCheckCmd"`
+	Timeouts       timeoutsConfig   `yaml:"timeouts"`
+	Plugins        pluginsConfig    `yaml:"plugins"`
+}
+
+type storageConfig struct {
+	DBURI                        string        `yaml:"dbURI"`
+	TestEventsFlushSize          int           `yaml:"testEventsFlushSize"`
+	TestEventsFlushInterval      time.Duration `yaml:"testEventsFlushInterval"`
+	FrameworkEventsFlushSize     int           `yaml:"frameworkEventsFlushSize"`
+	FrameworkEventsFlushInterval time.Duration `yaml:"frameworkEventsFlushInterval"`
+}
+
+type apiConfig struct {
+	Listener         string        `yaml:"listener"`
+	GRPCAddr         string        `yaml:"grpcAddr"`
+	HTTPAddr         string        `yaml:"httpAddr"`
+	HTTPBasePath     string        `yaml:"httpBasePath"`
+	HTTPMaxBodySize  int64         `yaml:"httpMaxBodySize"`
+	HTTPReadTimeout  time.Duration `yaml:"httpReadTimeout"`
+	HTTPWriteTimeout time.Duration `yaml:"httpWriteTimeout"`
+	TLSCert          string        `yaml:"tlsCert"`
+	TLSKey           string        `yaml:"tlsKey"`
+	TLSClientCA      string        `yaml:"tlsClientCA"`
+	AuthTokens       string        `yaml:"authTokens"`
+	AuthHMACKeys     string        `yaml:"authHMACKeys"`
+	AuthClientCerts  bool          `yaml:"authClientCerts"`
+	Admins           []string      `yaml:"admins"`
+}
+
+// quarantineConfig sets when targets are quarantined: when they fail at least
+// Failures of their last Runs jobs. Targets are never quarantined if Failures
+// is zero.
+type quarantineConfig struct {
+	Failures int `yaml:"failures"`
+	Runs     int `yaml:"runs"`
+}
+
+// queueConfig bounds the number of jobs running at the same time, overall and
+// per requestor, with per-requestor overrides. The other jobs are queued. 0
+// means no limit.
+type queueConfig struct {
+	MaxRunningJobs             int            `yaml:"maxRunningJobs"`
+	MaxRunningJobsPerRequestor int            `yaml:"maxRunningJobsPerRequestor"`
+	RequestorQuotas            map[string]int `yaml:"requestorQuotas"`
+}
+
+type timeoutsConfig struct {
+	TargetManager          time.Duration `yaml:"targetManager"`
+	TargetManagerRelease   time.Duration `yaml:"targetManagerRelease"`
+	TargetLock             time.Duration `yaml:"targetLock"`
+	StepInject             time.Duration `yaml:"stepInject"`
+	TestRunnerMsg          time.Duration `yaml:"testRunnerMsg"`
+	TestRunnerShutdown     time.Duration `yaml:"testRunnerShutdown"`
+	TestRunnerStepShutdown time.Duration `yaml:"testRunnerStepShutdown"`
+}
+
+// pluginsConfig enables or disables the plugins compiled in the server, by
+// kind and by name. Plugins are enabled unless they are disabled explicitly.
+type pluginsConfig struct {
+	TargetManagers map[string]bool `yaml:"targetManagers"`
+	TestFetchers   map[string]bool `yaml:"testFetchers"`
+	TestSteps      map[string]bool `yaml:"testSteps"`
+	Reporters      map[string]bool `yaml:"reporters"`
+}
+
+// enabled returns whether the plugin with the given name is enabled. Plugin
+// names are case insensitive.
+func enabled(plugins map[string]bool, name string) bool {
+	for n, enabled := range plugins {
+		if strings.EqualFold(n, name) {
+			return enabled
+		}
+	}
+	return true
+}
+
+// defaultConfig returns the configuration used when no file is passed.
+func defaultConfig() *serverConfig {
+	return &serverConfig{
+		LogLevel: "debug",
+		Storage: storageConfig{
+			DBURI:                        defaultDBURI,
+			TestEventsFlushSize:          rdbms.DefaultFlushSize,
+			TestEventsFlushInterval:      rdbms.DefaultFlushInterval,
+			FrameworkEventsFlushSize:     rdbms.DefaultFlushSize,
+			FrameworkEventsFlushInterval: rdbms.DefaultFlushInterval,
+		},
+		API: apiConfig{
+			Listener:         "http",
+			GRPCAddr:         grpclistener.DefaultAddr,
+			HTTPAddr:         httplistener.DefaultAddr,
+			HTTPMaxBodySize:  httplistener.DefaultMaxBodySize,
+			HTTPReadTimeout:  httplistener.DefaultReadTimeout,
+			HTTPWriteTimeout: httplistener.DefaultWriteTimeout,
+		},
+		TargetLocker: inmemory.Name,
+		Timeouts: timeoutsConfig{
+			TargetManager:          config.TargetManagerTimeout,
+			TargetManagerRelease:   config.TargetManagerReleaseTimeout,
+			TargetLock:             config.LockTimeout,
+			StepInject:             config.StepInjectTimeout,
+			TestRunnerMsg:          config.TestRunnerMsgTimeout,
+			TestRunnerShutdown:     config.TestRunnerShutdownTimeout,
+			TestRunnerStepShutdown: config.TestRunnerStepShutdownTimeout,
+		},
+	}
+}
+
+// commaList is a flag.Value for a comma-separated list of strings.
+type commaList struct {
+	list *[]string
+}
+
+func (l commaList) String() string {
+	if l.list == nil {
+		return ""
+	}
+	return strings.Join(*l.list, ",")
+}
+
+func (l commaList) Set(value string) error {
+	*l.list = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l.list = append(*l.list, item)
+		}
+	}
+	return nil
+}
+
+// quotaMap is a flag.Value for a comma-separated list of name=number pairs.
+type quotaMap struct {
+	quotas *map[string]int
+}
+
+func (m quotaMap) String() string {
+	if m.quotas == nil {
+		return ""
+	}
+	var pairs []string
+	for name, quota := range *m.quotas {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", name, quota))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m quotaMap) Set(value string) error {
+	quotas := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid pair '%s', expected name=number", pair)
+		}
+		quota, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return fmt.Errorf("invalid number in '%s': %v", pair, err)
+		}
+		quotas[strings.TrimSpace(parts[0])] = quota
+	}
+	*m.quotas = quotas
+	return nil
+}
+
+// registerFlags registers the flags overriding the configuration.
+func (c *serverConfig) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.LogLevel, "logLevel", c.LogLevel, "Log level: panic, fatal, error, warning, info, debug or trace")
+
+	fs.StringVar(&c.Storage.DBURI, "dbURI", c.Storage.DBURI, "Database URI")
+	fs.IntVar(&c.Storage.TestEventsFlushSize, "testEventsFlushSize", c.Storage.TestEventsFlushSize, "Number of buffered test events which triggers a flush to the database")
+	fs.DurationVar(&c.Storage.TestEventsFlushInterval, "testEventsFlushInterval", c.Storage.TestEventsFlushInterval, "Interval at which buffered test events are flushed to the database")
+	fs.IntVar(&c.Storage.FrameworkEventsFlushSize, "frameworkEventsFlushSize", c.Storage.FrameworkEventsFlushSize, "Number of buffered framework events which triggers a flush to the database")
+	fs.DurationVar(&c.Storage.FrameworkEventsFlushInterval, "frameworkEventsFlushInterval", c.Storage.FrameworkEventsFlushInterval, "Interval at which buffered framework events are flushed to the database")
+
+	fs.StringVar(&c.API.Listener, "listener", c.API.Listener, "API listener to serve: http or grpc")
+	fs.StringVar(&c.API.GRPCAddr, "grpcAddr", c.API.GRPCAddr, "Listen address of the gRPC API listener")
+	fs.StringVar(&c.API.HTTPAddr, "httpAddr", c.API.HTTPAddr, "Listen address of the HTTP API listener, either [host]:port or unix:/path/to/socket")
+	fs.StringVar(&c.API.HTTPBasePath, "httpBasePath", c.API.HTTPBasePath, "Path prefix the HTTP API is served under, e.g. /contest")
+	fs.Int64Var(&c.API.HTTPMaxBodySize, "httpMaxBodySize", c.API.HTTPMaxBodySize, "Maximum size in bytes of the HTTP request bodies")
+	fs.DurationVar(&c.API.HTTPReadTimeout, "httpReadTimeout", c.API.HTTPReadTimeout, "Maximum duration for reading an HTTP request")
+	fs.DurationVar(&c.API.HTTPWriteTimeout, "httpWriteTimeout", c.API.HTTPWriteTimeout, "Maximum duration for handling an HTTP request, except for event streams")
+	fs.StringVar(&c.API.TLSCert, "tlsCert", c.API.TLSCert, "TLS certificate file of the API listener. If set, the API is served over TLS")
+	fs.StringVar(&c.API.TLSKey, "tlsKey", c.API.TLSKey, "TLS private key file of the API listener")
+	fs.StringVar(&c.API.TLSClientCA, "tlsClientCA", c.API.TLSClientCA, "CA certificate file used to verify client certificates")
+	fs.StringVar(&c.API.AuthTokens, "authTokens", c.API.AuthTokens, "File of '<requestor> <token>' lines, to authenticate requests with bearer tokens")
+	fs.StringVar(&c.API.AuthHMACKeys, "authHMACKeys", c.API.AuthHMACKeys, "File of '<requestor> <key>' lines, to authenticate HMAC-signed requests")
+	fs.BoolVar(&c.API.AuthClientCerts, "authClientCerts", c.API.AuthClientCerts, "Authenticate requests with client certificates, whose common name is the requestor")
+	fs.Var(commaList{&c.API.Admins}, "admins", "Comma-separated list of requestors allowed to stop and retry any job, and to release quarantined targets. Other requestors can only stop and retry their own jobs when set, or when authentication is enabled")
+
+	fs.StringVar(&c.TargetLocker, "targetLocker", c.TargetLocker, "Target locker shared by all the jobs: InMemory, Noop, or DBLocker to store the locks in the database")
+	fs.StringVar(&c.Inventory, "inventory", c.Inventory, "JSON file of targets, with their attributes, to store in the target inventory at startup")
+	fs.IntVar(&c.Quarantine.Failures, "quarantineFailures", c.Quarantine.Failures, "Quarantine the targets which fail this many of their last -quarantineRuns jobs. 0 disables the quarantine")
+	fs.IntVar(&c.Quarantine.Runs, "quarantineRuns", c.Quarantine.Runs, "Number of recent jobs of a target evaluated to quarantine it")
+	fs.IntVar(&c.Queue.MaxRunningJobs, "maxRunningJobs", c.Queue.MaxRunningJobs, "Maximum number of jobs running at the same time, the other ones are queued. 0 means no limit")
+	fs.IntVar(&c.Queue.MaxRunningJobsPerRequestor, "maxRunningJobsPerRequestor", c.Queue.MaxRunningJobsPerRequestor, "Maximum number of jobs of the same requestor running at the same time, unless overridden by -requestorQuotas. 0 means no limit")
+	fs.Var(quotaMap{&c.Queue.RequestorQuotas}, "requestorQuotas", "Comma-separated list of requestor=number pairs, overriding -maxRunningJobsPerRequestor for these requestors")
+
+	fs.BoolVar(&c.HealthCheckCmd, "healthCheckCmd", c.HealthCheckCmd, "Allow the health checks of the tests to run commands on the server. The commands are chosen by whoever submits the job")
+
+	fs.DurationVar(&c.Timeouts.TargetManager, "targetManagerTimeout", c.Timeouts.TargetManager, "Maximum duration of the Acquire operation of target managers")
+	fs.DurationVar(&c.Timeouts.TargetManagerRelease, "targetManagerReleaseTimeout", c.Timeouts.TargetManagerRelease, "Maximum duration of the Release operation of target managers, which runs even if the job is cancelled")
+	fs.DurationVar(&c.Timeouts.TargetLock, "targetLockTimeout", c.Timeouts.TargetLock, "Duration of the locks on the targets of a job, which are refreshed while the job runs")
+	fs.DurationVar(&c.Timeouts.StepInject, "stepInjectTimeout", c.Timeouts.StepInject, "Maximum duration for the first step of a test to accept a target")
+	fs.DurationVar(&c.Timeouts.TestRunnerMsg, "testRunnerMsgTimeout", c.Timeouts.TestRunnerMsg, "Maximum duration for the delivery of a message between the components of the test runner")
+	fs.DurationVar(&c.Timeouts.TestRunnerShutdown, "testRunnerShutdownTimeout", c.Timeouts.TestRunnerShutdown, "Maximum duration to wait for the test steps to return after a cancellation")
+	fs.DurationVar(&c.Timeouts.TestRunnerStepShutdown, "testRunnerStepShutdownTimeout", c.Timeouts.TestRunnerStepShutdown, "Maximum duration to wait for the test steps to return after all the targets completed")
+}
+
+// envName returns the name of the environment variable overriding a flag,
+// e.g. CONTEST_AUTH_HMAC_KEYS for -authHMACKeys.
+func envName(flagName string) string {
+	var b strings.Builder
+	runes := []rune(flagName)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return envPrefix + b.String()
+}
+
+// loadConfig parses the flags and returns the configuration. The settings in
+// the configuration file passed via configFlag override the defaults, and are
+// overridden in turn by the environment variables and the flags.
+func loadConfig(fs *flag.FlagSet, args []string, configFlag string) (*serverConfig, error) {
+	cfg := defaultConfig()
+	cfg.registerFlags(fs)
+	configFile := fs.String(configFlag, "", "Configuration file, in YAML or JSON")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	// collect the overrides before loading the configuration file, which
+	// overwrites the flag variables.
+	overrides := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		overrides[f.Name] = f.Value.String()
+	})
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := overrides[f.Name]; ok {
+			return
+		}
+		if value, ok := os.LookupEnv(envName(f.Name)); ok {
+			overrides[f.Name] = value
+		}
+	})
+	if path, ok := overrides[configFlag]; ok {
+		*configFile = path
+	}
+	if *configFile != "" {
+		data, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read configuration file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %v", *configFile, err)
+		}
+	}
+	for name, value := range overrides {
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for %s (from -%s or %s): %v", value, name, name, envName(name), err)
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate checks the configuration, and reports all the invalid settings at
+// once.
+func (c *serverConfig) validate() error {
+	var errs []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+	_, err := logrus.ParseLevel(c.LogLevel)
+	check(err == nil, "logLevel: unknown level '%s'", c.LogLevel)
+
+	check(c.Storage.DBURI != "", "storage.dbURI: must not be empty")
+	check(c.Storage.TestEventsFlushSize > 0, "storage.testEventsFlushSize: must be positive")
+	check(c.Storage.TestEventsFlushInterval > 0, "storage.testEventsFlushInterval: must be positive")
+	check(c.Storage.FrameworkEventsFlushSize > 0, "storage.frameworkEventsFlushSize: must be positive")
+	check(c.Storage.FrameworkEventsFlushInterval > 0, "storage.frameworkEventsFlushInterval: must be positive")
+
+	check(c.API.Listener == "http" || c.API.Listener == "grpc", "api.listener: must be http or grpc, not '%s'", c.API.Listener)
+	check(c.API.HTTPMaxBodySize > 0, "api.httpMaxBodySize: must be positive")
+	check(c.API.HTTPReadTimeout > 0, "api.httpReadTimeout: must be positive")
+	check(c.API.HTTPWriteTimeout > 0, "api.httpWriteTimeout: must be positive")
+	check((c.API.TLSCert == "") == (c.API.TLSKey == ""), "api.tlsCert, api.tlsKey: must be set together")
+	check(!c.API.AuthClientCerts || (c.API.TLSCert != "" && c.API.TLSClientCA != ""), "api.authClientCerts: requires api.tlsCert and api.tlsClientCA")
+
+	knownLocker := false
+	for _, name := range targetLockerNames(c) {
+		knownLocker = knownLocker || strings.EqualFold(name, c.TargetLocker)
+	}
+	check(knownLocker, "targetLocker: unknown target locker '%s'", c.TargetLocker)
+
+	check(c.Quarantine.Failures >= 0, "quarantine.failures: must be non-negative")
+	check(c.Quarantine.Failures <= c.Quarantine.Runs, "quarantine.runs: must be at least quarantine.failures")
+
+	check(c.Queue.MaxRunningJobs >= 0, "queue.maxRunningJobs: must be non-negative")
+	check(c.Queue.MaxRunningJobsPerRequestor >= 0, "queue.maxRunningJobsPerRequestor: must be non-negative")
+	for requestor, quota := range c.Queue.RequestorQuotas {
+		check(quota >= 0, "queue.requestorQuotas: quota of '%s' must be non-negative", requestor)
+	}
+
+	check(c.Timeouts.TargetManager > 0, "timeouts.targetManager: must be positive")
+	check(c.Timeouts.TargetManagerRelease > 0, "timeouts.targetManagerRelease: must be positive")
+	check(c.Timeouts.TargetLock > 0, "timeouts.targetLock: must be positive")
+	check(c.Timeouts.StepInject > 0, "timeouts.stepInject: must be positive")
+	check(c.Timeouts.TestRunnerMsg > 0, "timeouts.testRunnerMsg: must be positive")
+	check(c.Timeouts.TestRunnerShutdown > 0, "timeouts.testRunnerShutdown: must be positive")
+	check(c.Timeouts.TestRunnerStepShutdown > 0, "timeouts.testRunnerStepShutdown: must be positive")
+
+	checkPlugins := func(kind string, plugins map[string]bool, names []string) {
+		for name := range plugins {
+			known := false
+			for _, n := range names {
+				known = known || strings.EqualFold(n, name)
+			}
+			check(known, "plugins.%s: unknown plugin '%s'", kind, name)
+		}
+	}
+	checkPlugins("targetManagers", c.Plugins.TargetManagers, targetManagerNames())
+	checkPlugins("testFetchers", c.Plugins.TestFetchers, testFetcherNames())
+	checkPlugins("testSteps", c.Plugins.TestSteps, testStepNames())
+	checkPlugins("reporters", c.Plugins.Reporters, reporterNames())
+
+	if len(errs) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// apply sets the global settings of the framework from the configuration.
+func (c *serverConfig) apply() {
+	level, _ := logrus.ParseLevel(c.LogLevel)
+	logging.SetLevel(level)
+	config.TargetManagerTimeout = c.Timeouts.TargetManager
+	config.TargetManagerReleaseTimeout = c.Timeouts.TargetManagerRelease
+	config.LockTimeout = c.Timeouts.TargetLock
+	config.StepInjectTimeout = c.Timeouts.StepInject
+	config.TestRunnerMsgTimeout = c.Timeouts.TestRunnerMsg
+	config.TestRunnerShutdownTimeout = c.Timeouts.TestRunnerShutdown
+	config.TestRunnerStepShutdownTimeout = c.Timeouts.TestRunnerStepShutdown
+	config.HealthCheckCmdEnabled = c.HealthCheckCmd
+}
+
+func targetManagerNames() []string {
+	var names []string
+	for _, loader := range targetManagers {
+		name, _ := loader()
+		names = append(names, name)
+	}
+	return names
+}
+
+func targetLockerNames(c *serverConfig) []string {
+	var names []string
+	for _, loader := range targetLockers(c) {
+		name, _ := loader()
+		names = append(names, name)
+	}
+	return names
+}
+
+func testFetcherNames() []string {
+	var names []string
+	for _, loader := range testFetchers {
+		name, _ := loader()
+		names = append(names, name)
+	}
+	return names
+}
+
+func testStepNames() []string {
+	var names []string
+	for _, loader := range testSteps {
+		name, _, _ := loader()
+		names = append(names, name)
+	}
+	return names
+}
+
+func reporterNames() []string {
+	var names []string
+	for _, loader := range reporters {
+		name, _ := loader()
+		names = append(names, name)
+	}
+	return names
+}
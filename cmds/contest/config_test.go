@@ -0,0 +1,104 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// load loads the configuration from the given flags, without writing the
+// usage of the flags on errors.
+func load(args ...string) (*serverConfig, error) {
+	fs := flag.NewFlagSet("contest", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return loadConfig(fs, args, "config")
+}
+
+// writeConfig writes a configuration file and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "contest")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "contest.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestEnvName(t *testing.T) {
+	for flagName, expected := range map[string]string{
+		"config":                        "CONTEST_CONFIG",
+		"logLevel":                      "CONTEST_LOG_LEVEL",
+		"dbURI":                         "CONTEST_DB_URI",
+		"authHMACKeys":                  "CONTEST_AUTH_HMAC_KEYS",
+		"tlsClientCA":                   "CONTEST_TLS_CLIENT_CA",
+		"testRunnerStepShutdownTimeout": "CONTEST_TEST_RUNNER_STEP_SHUTDOWN_TIMEOUT",
+	} {
+		require.Equal(t, expected, envName(flagName))
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := load()
+	require.NoError(t, err)
+	require.Equal(t, defaultConfig(), cfg)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+logLevel: info
+storage:
+  dbURI: file
+queue:
+  maxRunningJobs: 3
+  maxRunningJobsPerRequestor: 2
+`)
+	t.Setenv(envName("config"), path)
+	t.Setenv(envName("logLevel"), "warning")
+	t.Setenv(envName("dbURI"), "env")
+	t.Setenv(envName("maxRunningJobsPerRequestor"), "1")
+
+	cfg, err := load("-dbURI", "flag", "-maxRunningJobsPerRequestor", "0")
+	require.NoError(t, err)
+	// flags override the environment
+	require.Equal(t, "flag", cfg.Storage.DBURI)
+	require.Equal(t, 0, cfg.Queue.MaxRunningJobsPerRequestor)
+	// the environment overrides the file
+	require.Equal(t, "warning", cfg.LogLevel)
+	// the file overrides the defaults
+	require.Equal(t, 3, cfg.Queue.MaxRunningJobs)
+	require.Equal(t, defaultConfig().API, cfg.API)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	_, err := load("-config", writeConfig(t, "storage:\n  dbUri: typo\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid configuration file")
+
+	_, err = load("-config", filepath.Join(os.TempDir(), "does-not-exist.yaml"))
+	require.Error(t, err)
+
+	_, err = load("-maxRunningJobs", "many")
+	require.Error(t, err)
+
+	t.Setenv(envName("maxRunningJobs"), "many")
+	_, err = load()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "CONTEST_MAX_RUNNING_JOBS")
+	t.Setenv(envName("maxRunningJobs"), "0")
+
+	// all the invalid settings are reported
+	_, err = load("-listener", "ftp", "-quarantineFailures", "3", "-quarantineRuns", "2", "-tlsCert", "cert.pem")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "api.listener")
+	require.Contains(t, err.Error(), "quarantine.runs")
+	require.Contains(t, err.Error(), "api.tlsCert, api.tlsKey")
+}
@@ -6,12 +6,16 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/facebookincubator/contest/pkg/api"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/jobmanager"
 	"github.com/facebookincubator/contest/pkg/logging"
@@ -19,11 +23,17 @@ import (
 	"github.com/facebookincubator/contest/pkg/storage"
 	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/test"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener"
 	"github.com/facebookincubator/contest/plugins/listeners/httplistener"
 	"github.com/facebookincubator/contest/plugins/reporters/noop"
 	"github.com/facebookincubator/contest/plugins/reporters/targetsuccess"
 	"github.com/facebookincubator/contest/plugins/storage/rdbms"
+	"github.com/facebookincubator/contest/plugins/targetlocker/dblocker"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	nooplocker "github.com/facebookincubator/contest/plugins/targetlocker/noop"
 	"github.com/facebookincubator/contest/plugins/targetmanagers/csvtargetmanager"
+	"github.com/facebookincubator/contest/plugins/targetmanagers/httpinventory"
+	"github.com/facebookincubator/contest/plugins/targetmanagers/inventory"
 	"github.com/facebookincubator/contest/plugins/targetmanagers/targetlist"
 	"github.com/facebookincubator/contest/plugins/testfetchers/literal"
 	"github.com/facebookincubator/contest/plugins/testfetchers/uri"
@@ -34,18 +44,67 @@ import (
 	"github.com/facebookincubator/contest/plugins/teststeps/slowecho"
 	"github.com/facebookincubator/contest/plugins/teststeps/sshcmd"
 	"github.com/facebookincubator/contest/plugins/teststeps/terminalexpect"
-	"github.com/sirupsen/logrus"
 )
 
-const defaultDBURI = "contest:contest@tcp(localhost:3306)/contest?parseTime=true"
-
-var (
-	flagDBURI = flag.String("dbURI", defaultDBURI, "Database URI")
-)
+// authenticator returns the configured authenticator, or nil if requests are
+// not authenticated.
+func authenticator(cfg *apiConfig) (api.Authenticator, error) {
+	var authenticators api.MultiAuthenticator
+	if cfg.AuthTokens != "" {
+		a, err := api.NewTokenAuthenticator(cfg.AuthTokens)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if cfg.AuthHMACKeys != "" {
+		a, err := api.NewHMACAuthenticator(cfg.AuthHMACKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if cfg.AuthClientCerts {
+		authenticators = append(authenticators, api.CertificateAuthenticator{})
+	}
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return authenticators, nil
+}
 
 var targetManagers = []target.TargetManagerLoader{
 	csvtargetmanager.Load,
 	targetlist.Load,
+	inventory.Load,
+	httpinventory.Load,
+}
+
+// targetLockers returns the target Locker plugins. DBLocker stores the locks
+// in the database of the server.
+func targetLockers(cfg *serverConfig) []target.LockerLoader {
+	return []target.LockerLoader{
+		inmemory.Load,
+		nooplocker.Load,
+		dblocker.Loader(cfg.Storage.DBURI),
+	}
+}
+
+// importInventory stores in the target inventory the targets of a JSON file,
+// replacing the ones with the same ID.
+func importInventory(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read inventory file: %v", err)
+	}
+	var targets []*target.Target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return 0, fmt.Errorf("invalid inventory file %s: %v", path, err)
+	}
+	if err := storage.NewTargetInventory().Store(targets); err != nil {
+		return 0, err
+	}
+	return len(targets), nil
 }
 
 var testFetchers = []test.TestFetcherLoader{
@@ -81,44 +140,84 @@ var userFunctions = map[string]interface{}{
 }
 
 func main() {
-	flag.Parse()
+	cfg, err := loadConfig(flag.CommandLine, os.Args[1:], "config")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg.apply()
 	log := logging.GetLogger("contest")
-	log.Level = logrus.DebugLevel
 
 	pluginRegistry := pluginregistry.NewPluginRegistry()
 
 	// Register TargetManager plugins
 	for _, tmloader := range targetManagers {
-		if err := pluginRegistry.RegisterTargetManager(tmloader()); err != nil {
+		name, factory := tmloader()
+		if !enabled(cfg.Plugins.TargetManagers, name) {
+			continue
+		}
+		if err := pluginRegistry.RegisterTargetManager(name, factory); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Register target Locker plugins
+	for _, tlloader := range targetLockers(cfg) {
+		name, factory := tlloader()
+		if err := pluginRegistry.RegisterTargetLocker(name, factory); err != nil {
 			log.Fatal(err)
 		}
 	}
 
 	// Register TestFetcher plugins
 	for _, tfloader := range testFetchers {
-		if err := pluginRegistry.RegisterTestFetcher(tfloader()); err != nil {
+		name, factory := tfloader()
+		if !enabled(cfg.Plugins.TestFetchers, name) {
+			continue
+		}
+		if err := pluginRegistry.RegisterTestFetcher(name, factory); err != nil {
 			log.Fatal(err)
 		}
 	}
 
 	// Register TestStep plugins
 	for _, tsloader := range testSteps {
-		if err := pluginRegistry.RegisterTestStep(tsloader()); err != nil {
+		name, factory, events := tsloader()
+		if !enabled(cfg.Plugins.TestSteps, name) {
+			continue
+		}
+		if err := pluginRegistry.RegisterTestStep(name, factory, events); err != nil {
 			log.Fatal(err)
-
 		}
 	}
 
 	// Register Reporter plugins
 	for _, rfloader := range reporters {
-		if err := pluginRegistry.RegisterReporter(rfloader()); err != nil {
+		name, factory := rfloader()
+		if !enabled(cfg.Plugins.Reporters, name) {
+			continue
+		}
+		if err := pluginRegistry.RegisterReporter(name, factory); err != nil {
 			log.Fatal(err)
 		}
 	}
 
 	// storage initialization
-	log.Infof("Using database URI: %s", *flagDBURI)
-	storage.SetStorage(rdbms.New(*flagDBURI))
+	log.Infof("Using database URI: %s", cfg.Storage.DBURI)
+	storage.SetStorage(rdbms.New(cfg.Storage.DBURI,
+		rdbms.TestEventsFlushSize(cfg.Storage.TestEventsFlushSize),
+		rdbms.TestEventsFlushInterval(cfg.Storage.TestEventsFlushInterval),
+		rdbms.FrameworkEventsFlushSize(cfg.Storage.FrameworkEventsFlushSize),
+		rdbms.FrameworkEventsFlushInterval(cfg.Storage.FrameworkEventsFlushInterval),
+	))
+
+	if cfg.Inventory != "" {
+		count, err := importInventory(cfg.Inventory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Imported %d targets into the inventory from %s", count, cfg.Inventory)
+	}
 
 	// user-defined function registration
 	for name, fn := range userFunctions {
@@ -127,10 +226,80 @@ func main() {
 		}
 	}
 
+	// API listener settings, authentication and TLS
+	var (
+		httpOpts = []httplistener.Option{
+			httplistener.WithAddr(cfg.API.HTTPAddr),
+			httplistener.WithBasePath(cfg.API.HTTPBasePath),
+			httplistener.WithMaxBodySize(cfg.API.HTTPMaxBodySize),
+			httplistener.WithReadTimeout(cfg.API.HTTPReadTimeout),
+			httplistener.WithWriteTimeout(cfg.API.HTTPWriteTimeout),
+		}
+		grpcOpts []grpclistener.Option
+		jmOpts   []jobmanager.Option
+	)
+	auth, err := authenticator(&cfg.API)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if auth != nil {
+		httpOpts = append(httpOpts, httplistener.WithAuthenticator(auth))
+		grpcOpts = append(grpcOpts, grpclistener.WithAuthenticator(auth))
+	}
+	if cfg.API.TLSCert != "" {
+		tlsConfig, err := api.NewServerTLSConfig(cfg.API.TLSCert, cfg.API.TLSKey, cfg.API.TLSClientCA)
+		if err != nil {
+			log.Fatal(err)
+		}
+		httpOpts = append(httpOpts, httplistener.WithTLSConfig(tlsConfig))
+		grpcOpts = append(grpcOpts, grpclistener.WithTLSConfig(tlsConfig))
+	} else if auth != nil {
+		log.Warningf("API authentication is enabled without TLS, credentials are sent in clear text")
+	}
+	if auth != nil || len(cfg.API.Admins) > 0 {
+		// only the requestor of a job, or an admin, can stop or retry it
+		var admins []api.EventRequestor
+		for _, admin := range cfg.API.Admins {
+			admins = append(admins, api.EventRequestor(admin))
+		}
+		jmOpts = append(jmOpts, jobmanager.WithAuthorizer(api.NewOwnerAuthorizer(admins)))
+	}
+
+	// a single target locker is shared by all the jobs
+	log.Infof("Using target locker: %s", cfg.TargetLocker)
+	targetLocker, err := pluginRegistry.NewTargetLocker(cfg.TargetLocker, cfg.Timeouts.TargetLock)
+	if err != nil {
+		log.Fatal(err)
+	}
+	jmOpts = append(jmOpts, jobmanager.WithTargetLocker(targetLocker))
+	if cfg.Quarantine.Failures > 0 {
+		log.Infof("Quarantining targets which fail %d of their last %d runs", cfg.Quarantine.Failures, cfg.Quarantine.Runs)
+		jmOpts = append(jmOpts, jobmanager.WithQuarantinePolicy(jobmanager.QuarantinePolicy{
+			Failures: cfg.Quarantine.Failures,
+			Runs:     cfg.Quarantine.Runs,
+		}))
+	}
+	if cfg.Queue.MaxRunningJobs > 0 || cfg.Queue.MaxRunningJobsPerRequestor > 0 || len(cfg.Queue.RequestorQuotas) > 0 {
+		log.Infof("Queueing the jobs beyond %d running jobs, and %d per requestor (0 means no limit)", cfg.Queue.MaxRunningJobs, cfg.Queue.MaxRunningJobsPerRequestor)
+		jmOpts = append(jmOpts, jobmanager.WithQueuePolicy(jobmanager.QueuePolicy{
+			MaxRunningJobs:             cfg.Queue.MaxRunningJobs,
+			MaxRunningJobsPerRequestor: cfg.Queue.MaxRunningJobsPerRequestor,
+			RequestorQuotas:            cfg.Queue.RequestorQuotas,
+		}))
+	}
+
 	// spawn JobManager
-	listener := httplistener.HTTPListener{}
+	var listener api.Listener
+	switch cfg.API.Listener {
+	case "http":
+		listener = httplistener.New(httpOpts...)
+	case "grpc":
+		listener = grpclistener.New(cfg.API.GRPCAddr, grpcOpts...)
+	default:
+		log.Fatalf("Unknown API listener '%s'", cfg.API.Listener)
+	}
 
-	jm, err := jobmanager.New(&listener, pluginRegistry)
+	jm, err := jobmanager.New(listener, pluginRegistry, jmOpts...)
 	if err != nil {
 		log.Fatal(err)
 	}
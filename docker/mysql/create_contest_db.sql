@@ -14,7 +14,8 @@ CREATE TABLE test_events (
 	target_id VARCHAR(64) NULL,
 	payload TEXT NULL,
 	emit_time TIMESTAMP NOT NULL,
-	PRIMARY KEY (event_id)
+	PRIMARY KEY (event_id),
+	INDEX (target_id)
 );
 
 CREATE TABLE framework_events (
@@ -51,5 +52,68 @@ CREATE TABLE jobs (
 	requestor VARCHAR(32) NOT NULL,
 	request_time TIMESTAMP NOT NULL,
 	descriptor TEXT NOT NULL,
+	retry_of BIGINT(20) UNSIGNED NOT NULL DEFAULT 0,
 	PRIMARY KEY (job_id)
 );
+
+CREATE TABLE job_tags (
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	tag VARCHAR(64) NOT NULL,
+	PRIMARY KEY (job_id, tag),
+	INDEX (tag)
+);
+
+CREATE TABLE paused_jobs (
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	pause_time TIMESTAMP NOT NULL,
+	state MEDIUMTEXT NOT NULL,
+	PRIMARY KEY (job_id)
+);
+
+CREATE TABLE target_locks (
+	target_id VARCHAR(64) NOT NULL,
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	locked_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (target_id),
+	INDEX (expires_at)
+);
+
+CREATE TABLE targets (
+	target_id VARCHAR(64) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	fqdn VARCHAR(255) NOT NULL,
+	PRIMARY KEY (target_id)
+);
+
+CREATE TABLE target_attributes (
+	target_id VARCHAR(64) NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	value VARCHAR(255) NOT NULL,
+	PRIMARY KEY (target_id, name),
+	INDEX (name, value)
+);
+
+CREATE TABLE quarantined_targets (
+	target_id VARCHAR(64) NOT NULL,
+	target_name VARCHAR(64) NOT NULL,
+	reason TEXT NOT NULL,
+	quarantine_time TIMESTAMP NOT NULL,
+	release_time TIMESTAMP NULL,
+	PRIMARY KEY (target_id)
+);
+
+CREATE TABLE schedules (
+	schedule_id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+	job_name VARCHAR(32) NOT NULL,
+	requestor VARCHAR(32) NOT NULL,
+	create_time TIMESTAMP NOT NULL,
+	descriptor TEXT NOT NULL,
+	cron VARCHAR(64) NOT NULL,
+	interval_ns BIGINT(20) NOT NULL,
+	overlap_policy VARCHAR(32) NOT NULL,
+	paused TINYINT(1) NOT NULL,
+	last_fire_time TIMESTAMP NULL,
+	last_job_id BIGINT(20) UNSIGNED NOT NULL DEFAULT 0,
+	PRIMARY KEY (schedule_id)
+);
@@ -0,0 +1,7 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Links a job to the job it is a retry of. 0 means the job is not a retry.
+ALTER TABLE jobs ADD COLUMN retry_of BIGINT(20) UNSIGNED NOT NULL DEFAULT 0;
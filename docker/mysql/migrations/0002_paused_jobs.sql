@@ -0,0 +1,12 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the state of paused jobs, so that they can be resumed after a restart.
+CREATE TABLE paused_jobs (
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	pause_time TIMESTAMP NOT NULL,
+	state MEDIUMTEXT NOT NULL,
+	PRIMARY KEY (job_id)
+);
@@ -0,0 +1,20 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the tags of the jobs, one row per tag.
+CREATE TABLE job_tags (
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	tag VARCHAR(64) NOT NULL,
+	PRIMARY KEY (job_id, tag),
+	INDEX (tag)
+);
+
+-- Tags of the existing jobs are only available in their descriptors.
+INSERT IGNORE INTO job_tags (job_id, tag)
+	SELECT j.job_id, t.tag FROM jobs j,
+	JSON_TABLE(
+		CASE WHEN JSON_VALID(j.descriptor) THEN j.descriptor ELSE '{}' END,
+		'$.Tags[*]' COLUMNS (tag VARCHAR(64) PATH '$')
+	) t;
@@ -0,0 +1,14 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the locks of the targets, used by the DBLocker target locker.
+CREATE TABLE target_locks (
+	target_id VARCHAR(64) NOT NULL,
+	job_id BIGINT(20) UNSIGNED NOT NULL,
+	locked_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (target_id),
+	INDEX (expires_at)
+);
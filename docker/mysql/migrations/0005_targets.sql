@@ -0,0 +1,20 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the target inventory: the targets and their attributes.
+CREATE TABLE targets (
+	target_id VARCHAR(64) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	fqdn VARCHAR(255) NOT NULL,
+	PRIMARY KEY (target_id)
+);
+
+CREATE TABLE target_attributes (
+	target_id VARCHAR(64) NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	value VARCHAR(255) NOT NULL,
+	PRIMARY KEY (target_id, name),
+	INDEX (name, value)
+);
@@ -0,0 +1,17 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the targets quarantined because they failed too many of their recent
+-- runs. Test events are looked up by target to evaluate the recent runs.
+ALTER TABLE test_events ADD INDEX (target_id);
+
+CREATE TABLE quarantined_targets (
+	target_id VARCHAR(64) NOT NULL,
+	target_name VARCHAR(64) NOT NULL,
+	reason TEXT NOT NULL,
+	quarantine_time TIMESTAMP NOT NULL,
+	release_time TIMESTAMP NULL,
+	PRIMARY KEY (target_id)
+);
@@ -0,0 +1,21 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- Holds the schedules of recurring jobs, which start a new job each time they
+-- fire.
+CREATE TABLE schedules (
+	schedule_id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+	job_name VARCHAR(32) NOT NULL,
+	requestor VARCHAR(32) NOT NULL,
+	create_time TIMESTAMP NOT NULL,
+	descriptor TEXT NOT NULL,
+	cron VARCHAR(64) NOT NULL,
+	interval_ns BIGINT(20) NOT NULL,
+	overlap_policy VARCHAR(32) NOT NULL,
+	paused TINYINT(1) NOT NULL,
+	last_fire_time TIMESTAMP NULL,
+	last_job_id BIGINT(20) UNSIGNED NOT NULL DEFAULT 0,
+	PRIMARY KEY (schedule_id)
+);
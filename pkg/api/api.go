@@ -11,13 +11,14 @@ import (
 	"os"
 	"time"
 
+	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
 // CurrentAPIVersion is the current version of the API that the clients must be
 // able to speak in order to communicate with the server. Versioning starts at
 // 1, while 0 is to be considered an error indicator.
-const CurrentAPIVersion uint32 = 5
+const CurrentAPIVersion uint32 = 6
 
 // DefaultEventTimeout is the default time to wait for sending or receiving an
 // event on the events channel.
@@ -25,7 +26,8 @@ var DefaultEventTimeout = 3 * time.Second
 
 // The API structure implements the communication between clients and the
 // JobManager. It enables several operations like starting, stopping,
-// retrying a job, and getting a job status.
+// retrying a job, getting a job status, listing jobs, and watching the events
+// of a job.
 type API struct {
 	// The events channel is used to route API events between clients and the
 	// JobManager. It is not necessary to close it explicitly as it will be
@@ -93,7 +95,7 @@ func (a API) Version() Response {
 // reply. If the send doesn't complete within the timeout, an error is returned.
 func (a *API) SendEvent(ev *Event, timeout *time.Duration) error {
 	if ev.Msg.Requestor() == "" {
-		return errors.New("requestor cannot be empty")
+		return NewError(ErrorKindInvalid, errors.New("requestor cannot be empty"))
 	}
 	to := DefaultEventTimeout
 	if timeout != nil {
@@ -103,7 +105,7 @@ func (a *API) SendEvent(ev *Event, timeout *time.Duration) error {
 	case a.Events <- ev:
 		return nil
 	case <-time.After(to):
-		return fmt.Errorf("sending event timed out after %v", timeout)
+		return NewError(ErrorKindUnavailable, fmt.Errorf("sending event timed out after %v", to))
 	}
 }
 
@@ -125,7 +127,7 @@ func (a *API) SendReceiveEvent(ev *Event, timeout *time.Duration) (*EventRespons
 	case resp = <-ev.RespCh:
 		return resp, nil
 	case <-time.After(to):
-		return nil, fmt.Errorf("time out waiting for response after %v", timeout)
+		return nil, NewError(ErrorKindUnavailable, fmt.Errorf("time out waiting for response after %v", to))
 	}
 }
 
@@ -209,12 +211,15 @@ func (a *API) Status(requestor EventRequestor, jobID types.JobID) (Response, err
 
 // Retry will retry a job identified by its ID, using the same job
 // description. If the job is still running, an error is returned.
-func (a *API) Retry(requestor EventRequestor, jobID types.JobID) (Response, error) {
+// If failedTargetsOnly is true, the new job will only run on the targets that
+// failed in the original job, as reported by the TargetErr events in storage.
+func (a *API) Retry(requestor EventRequestor, jobID types.JobID, failedTargetsOnly bool) (Response, error) {
 	ev := &Event{
 		Type: EventTypeRetry,
 		Msg: EventRetryMsg{
-			requestor: requestor,
-			JobID:     jobID,
+			requestor:         requestor,
+			JobID:             jobID,
+			FailedTargetsOnly: failedTargetsOnly,
 		},
 		RespCh: make(chan *EventResponse, 1),
 	}
@@ -225,9 +230,254 @@ func (a *API) Retry(requestor EventRequestor, jobID types.JobID) (Response, erro
 	}
 	resp.Data = ResponseDataRetry{
 		// this is the job ID of the job to retry, not the new job ID
-		JobID: jobID,
-		// TODO this should set the new Job ID
-		// NewJobID: ...
+		JobID:    jobID,
+		NewJobID: respEv.JobID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// List returns the jobs matching the given query, from the most recent to the
+// oldest, together with their latest state.
+func (a *API) List(requestor EventRequestor, query job.ListQuery) (Response, error) {
+	ev := &Event{
+		Type: EventTypeList,
+		Msg: EventListMsg{
+			requestor: requestor,
+			Query:     query,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeList)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataList{
+		Jobs: respEv.Jobs,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// Watch subscribes to the test and framework events of a job, as they are
+// emitted. Only the events following the one whose ID is afterID are
+// delivered, which allows clients to resume watching after reconnecting, also
+// to another instance of ConTest. The caller must cancel the returned
+// subscription when done.
+func (a *API) Watch(requestor EventRequestor, jobID types.JobID, afterID uint64) (Response, error) {
+	ev := &Event{
+		Type: EventTypeWatch,
+		Msg: EventWatchMsg{
+			requestor: requestor,
+			JobID:     jobID,
+			AfterID:   afterID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeWatch)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataWatch{
+		JobID:        jobID,
+		Subscription: respEv.Subscription,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ListQuarantine returns the targets which are quarantined because they
+// failed too many of their recent runs, sorted by ID.
+func (a *API) ListQuarantine(requestor EventRequestor) (Response, error) {
+	ev := &Event{
+		Type: EventTypeListQuarantine,
+		Msg: EventListQuarantineMsg{
+			requestor: requestor,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeListQuarantine)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataListQuarantine{
+		Targets: respEv.Quarantined,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ReleaseQuarantine releases a target from quarantine by its ID, so that it
+// can be acquired by jobs again.
+func (a *API) ReleaseQuarantine(requestor EventRequestor, targetID string) (Response, error) {
+	ev := &Event{
+		Type: EventTypeReleaseQuarantine,
+		Msg: EventReleaseQuarantineMsg{
+			requestor: requestor,
+			TargetID:  targetID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeReleaseQuarantine)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	data := ResponseDataReleaseQuarantine{}
+	if len(respEv.Quarantined) > 0 {
+		data.Target = respEv.Quarantined[0]
+	}
+	resp.Data = data
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// DetachTarget detaches a target from the test that a job is running, and
+// unlocks it, without cancelling the job.
+func (a *API) DetachTarget(requestor EventRequestor, jobID types.JobID, targetID string) (Response, error) {
+	ev := &Event{
+		Type: EventTypeDetachTarget,
+		Msg: EventDetachTargetMsg{
+			requestor: requestor,
+			JobID:     jobID,
+			TargetID:  targetID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeDetachTarget)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataDetachTarget{
+		JobID:  jobID,
+		Target: respEv.Target,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// firstSchedule returns the schedule of a response about a single schedule,
+// if any
+func firstSchedule(respEv *EventResponse) *job.Schedule {
+	if len(respEv.Schedules) == 0 {
+		return nil
+	}
+	return respEv.Schedules[0]
+}
+
+// CreateSchedule creates a schedule, which starts a new job with the given
+// job descriptor each time it fires, until it is deleted. Schedules are
+// persisted, so that they survive restarts.
+func (a *API) CreateSchedule(requestor EventRequestor, schedule job.ScheduleDescriptor) (Response, error) {
+	ev := &Event{
+		Type: EventTypeCreateSchedule,
+		Msg: EventCreateScheduleMsg{
+			requestor: requestor,
+			Schedule:  schedule,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeCreateSchedule)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataCreateSchedule{
+		Schedule: firstSchedule(respEv),
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ListSchedules returns all the schedules, sorted by ID.
+func (a *API) ListSchedules(requestor EventRequestor) (Response, error) {
+	ev := &Event{
+		Type: EventTypeListSchedules,
+		Msg: EventListSchedulesMsg{
+			requestor: requestor,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeListSchedules)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataListSchedules{
+		Schedules: respEv.Schedules,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// PauseSchedule pauses a schedule by its ID, so that it does not fire until
+// it is resumed. The job it started, if any, keeps running.
+func (a *API) PauseSchedule(requestor EventRequestor, scheduleID types.ScheduleID) (Response, error) {
+	ev := &Event{
+		Type: EventTypePauseSchedule,
+		Msg: EventPauseScheduleMsg{
+			requestor:  requestor,
+			ScheduleID: scheduleID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypePauseSchedule)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataPauseSchedule{
+		Schedule: firstSchedule(respEv),
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ResumeSchedule resumes a paused schedule by its ID. The firings missed
+// while it was paused are skipped.
+func (a *API) ResumeSchedule(requestor EventRequestor, scheduleID types.ScheduleID) (Response, error) {
+	ev := &Event{
+		Type: EventTypeResumeSchedule,
+		Msg: EventResumeScheduleMsg{
+			requestor:  requestor,
+			ScheduleID: scheduleID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeResumeSchedule)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataResumeSchedule{
+		Schedule: firstSchedule(respEv),
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// DeleteSchedule deletes a schedule by its ID. The job it started, if any,
+// keeps running.
+func (a *API) DeleteSchedule(requestor EventRequestor, scheduleID types.ScheduleID) (Response, error) {
+	ev := &Event{
+		Type: EventTypeDeleteSchedule,
+		Msg: EventDeleteScheduleMsg{
+			requestor:  requestor,
+			ScheduleID: scheduleID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	resp := a.newResponse(ResponseTypeDeleteSchedule)
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataDeleteSchedule{
+		Schedule: firstSchedule(respEv),
 	}
 	resp.Err = respEv.Err
 	return resp, nil
@@ -0,0 +1,259 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package api
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request does not
+// carry the kind of credentials it verifies.
+var ErrNoCredentials = errors.New("no credentials")
+
+// DefaultMaxSignatureAge is the default maximum age of a signed request, which
+// limits the window in which a signed request can be replayed.
+const DefaultMaxSignatureAge = 5 * time.Minute
+
+// Signature is an HMAC-SHA256 signature of a request.
+type Signature struct {
+	// KeyID identifies the key used to sign the request.
+	KeyID string
+	// Timestamp is the time the request was signed at.
+	Timestamp time.Time
+	// Message is the canonical representation of the request, see
+	// SignatureMessage.
+	Message []byte
+	// MAC is the signature of Message.
+	MAC []byte
+}
+
+// SignatureMessage returns the canonical representation of a request which is
+// signed: the method, the URI, the timestamp in seconds since the epoch and
+// the SHA256 of the body, separated by newlines.
+func SignatureMessage(method, uri string, timestamp time.Time, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	return []byte(fmt.Sprintf("%s\n%s\n%d\n%s", method, uri, timestamp.Unix(), hex.EncodeToString(bodyHash[:])))
+}
+
+// Sign returns the HMAC-SHA256 of a message.
+func Sign(secret, message []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// Credentials wraps the credentials of a request, as extracted by the API
+// listener. Any of them can be missing.
+type Credentials struct {
+	// Token is a bearer token.
+	Token string
+	// Certificate is the client certificate, verified by the TLS layer.
+	Certificate *x509.Certificate
+	// Signature is the signature of the request.
+	Signature *Signature
+}
+
+// Authenticator derives the requestor of a request from its verified
+// credentials.
+type Authenticator interface {
+	// Authenticate returns the requestor the credentials belong to, or
+	// ErrNoCredentials if they do not include the kind of credentials the
+	// Authenticator verifies.
+	Authenticate(creds *Credentials) (EventRequestor, error)
+}
+
+// readSecrets reads a file with one "<requestor> <secret>" pair per line.
+// Empty lines and lines starting with '#' are ignored.
+func readSecrets(path string) (map[EventRequestor]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	secrets := make(map[EventRequestor]string)
+	scanner := bufio.NewScanner(fd)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected '<requestor> <secret>'", path, lineno)
+		}
+		secrets[EventRequestor(fields[0])] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// TokenAuthenticator authenticates requests carrying a bearer token.
+type TokenAuthenticator struct {
+	// requestors maps the SHA256 of the tokens to their requestor, so that
+	// looking up a token does not leak its value through timing.
+	requestors map[[sha256.Size]byte]EventRequestor
+}
+
+// NewTokenAuthenticator returns a TokenAuthenticator using the tokens in the
+// given file, which has one "<requestor> <token>" pair per line.
+func NewTokenAuthenticator(path string) (*TokenAuthenticator, error) {
+	tokens, err := readSecrets(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read tokens: %v", err)
+	}
+	a := TokenAuthenticator{requestors: make(map[[sha256.Size]byte]EventRequestor)}
+	for requestor, token := range tokens {
+		a.requestors[sha256.Sum256([]byte(token))] = requestor
+	}
+	return &a, nil
+}
+
+// Authenticate implements Authenticator.Authenticate.
+func (a *TokenAuthenticator) Authenticate(creds *Credentials) (EventRequestor, error) {
+	if creds.Token == "" {
+		return "", ErrNoCredentials
+	}
+	requestor, ok := a.requestors[sha256.Sum256([]byte(creds.Token))]
+	if !ok {
+		return "", NewError(ErrorKindUnauthenticated, errors.New("invalid token"))
+	}
+	return requestor, nil
+}
+
+// CertificateAuthenticator authenticates requests carrying a client
+// certificate verified by the TLS layer. The requestor is the common name of
+// the certificate.
+type CertificateAuthenticator struct{}
+
+// Authenticate implements Authenticator.Authenticate.
+func (a CertificateAuthenticator) Authenticate(creds *Credentials) (EventRequestor, error) {
+	if creds.Certificate == nil {
+		return "", ErrNoCredentials
+	}
+	if creds.Certificate.Subject.CommonName == "" {
+		return "", NewError(ErrorKindUnauthenticated, errors.New("client certificate has no common name"))
+	}
+	return EventRequestor(creds.Certificate.Subject.CommonName), nil
+}
+
+// HMACAuthenticator authenticates signed requests. The ID of the signing key
+// is the requestor.
+type HMACAuthenticator struct {
+	keys map[EventRequestor][]byte
+	// MaxAge is the maximum age of a signature. Signatures from the future
+	// are accepted within the same margin, to allow for clock skew.
+	MaxAge time.Duration
+}
+
+// NewHMACAuthenticator returns an HMACAuthenticator using the keys in the
+// given file, which has one "<requestor> <key>" pair per line.
+func NewHMACAuthenticator(path string) (*HMACAuthenticator, error) {
+	keys, err := readSecrets(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read HMAC keys: %v", err)
+	}
+	a := HMACAuthenticator{keys: make(map[EventRequestor][]byte), MaxAge: DefaultMaxSignatureAge}
+	for requestor, key := range keys {
+		a.keys[requestor] = []byte(key)
+	}
+	return &a, nil
+}
+
+// Authenticate implements Authenticator.Authenticate.
+func (a *HMACAuthenticator) Authenticate(creds *Credentials) (EventRequestor, error) {
+	sig := creds.Signature
+	if sig == nil {
+		return "", ErrNoCredentials
+	}
+	key, ok := a.keys[EventRequestor(sig.KeyID)]
+	if !ok || !hmac.Equal(sig.MAC, Sign(key, sig.Message)) {
+		return "", NewError(ErrorKindUnauthenticated, errors.New("invalid signature"))
+	}
+	if age := time.Since(sig.Timestamp); age > a.MaxAge || age < -a.MaxAge {
+		return "", NewError(ErrorKindUnauthenticated, fmt.Errorf("signature timestamp %v is out of the allowed window", sig.Timestamp))
+	}
+	return EventRequestor(sig.KeyID), nil
+}
+
+// MultiAuthenticator authenticates requests with the first of its
+// authenticators which applies to their credentials.
+type MultiAuthenticator []Authenticator
+
+// Authenticate implements Authenticator.Authenticate.
+func (m MultiAuthenticator) Authenticate(creds *Credentials) (EventRequestor, error) {
+	for _, a := range m {
+		requestor, err := a.Authenticate(creds)
+		if err != ErrNoCredentials {
+			return requestor, err
+		}
+	}
+	return "", NewError(ErrorKindUnauthenticated, errors.New("missing credentials"))
+}
+
+// CheckRequestor returns the requestor of an authenticated request. Clients
+// can still pass the requestor explicitly, but it must match the
+// authenticated one.
+func CheckRequestor(authenticated, claimed EventRequestor) (EventRequestor, error) {
+	if claimed != "" && claimed != authenticated {
+		return "", NewError(ErrorKindPermissionDenied, fmt.Errorf("requestor '%s' does not match the authenticated requestor '%s'", claimed, authenticated))
+	}
+	return authenticated, nil
+}
+
+// Authorizer decides whether a requestor can operate on an existing job, or
+// on the resources shared by all the jobs.
+type Authorizer interface {
+	// AuthorizeJob returns an error if the requestor cannot modify (e.g. stop
+	// or retry) a job requested by owner.
+	AuthorizeJob(requestor, owner EventRequestor) error
+	// AuthorizeAdmin returns an error if the requestor cannot perform
+	// operations which affect all the jobs, e.g. releasing a target from
+	// quarantine.
+	AuthorizeAdmin(requestor EventRequestor) error
+}
+
+// OwnerAuthorizer only allows the original requestor of a job and the admins
+// to operate on the job, and only the admins to perform the operations which
+// affect all the jobs.
+type OwnerAuthorizer struct {
+	admins map[EventRequestor]bool
+}
+
+// NewOwnerAuthorizer returns an OwnerAuthorizer with the given admins.
+func NewOwnerAuthorizer(admins []EventRequestor) *OwnerAuthorizer {
+	a := OwnerAuthorizer{admins: make(map[EventRequestor]bool)}
+	for _, admin := range admins {
+		a.admins[admin] = true
+	}
+	return &a
+}
+
+// AuthorizeJob implements Authorizer.AuthorizeJob.
+func (a *OwnerAuthorizer) AuthorizeJob(requestor, owner EventRequestor) error {
+	if requestor == owner || a.admins[requestor] {
+		return nil
+	}
+	return NewError(ErrorKindPermissionDenied, fmt.Errorf("requestor '%s' is not allowed to operate on jobs requested by '%s'", requestor, owner))
+}
+
+// AuthorizeAdmin implements Authorizer.AuthorizeAdmin.
+func (a *OwnerAuthorizer) AuthorizeAdmin(requestor EventRequestor) error {
+	if a.admins[requestor] {
+		return nil
+	}
+	return NewError(ErrorKindPermissionDenied, fmt.Errorf("requestor '%s' is not an admin", requestor))
+}
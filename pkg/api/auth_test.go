@@ -0,0 +1,134 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package api
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSecrets writes a secrets file and returns its path.
+func writeSecrets(t *testing.T, content string) string {
+	fd, err := ioutil.TempFile("", "contest-secrets")
+	require.NoError(t, err)
+	defer fd.Close()
+	_, err = fd.WriteString(content)
+	require.NoError(t, err)
+	return fd.Name()
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	path := writeSecrets(t, "# comment\nalice token-a\n\nbob token-b\n")
+	defer os.Remove(path)
+	a, err := NewTokenAuthenticator(path)
+	require.NoError(t, err)
+
+	requestor, err := a.Authenticate(&Credentials{Token: "token-b"})
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("bob"), requestor)
+
+	_, err = a.Authenticate(&Credentials{Token: "token-c"})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+
+	_, err = a.Authenticate(&Credentials{})
+	require.Equal(t, ErrNoCredentials, err)
+}
+
+func TestTokenAuthenticatorInvalidFile(t *testing.T) {
+	path := writeSecrets(t, "alice\n")
+	defer os.Remove(path)
+	_, err := NewTokenAuthenticator(path)
+	require.Error(t, err)
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	path := writeSecrets(t, "alice secret\n")
+	defer os.Remove(path)
+	a, err := NewHMACAuthenticator(path)
+	require.NoError(t, err)
+
+	sign := func(keyID, key string, timestamp time.Time) *Signature {
+		msg := SignatureMessage("POST", "/v1/jobs", timestamp, []byte("{}"))
+		return &Signature{KeyID: keyID, Timestamp: timestamp, Message: msg, MAC: Sign([]byte(key), msg)}
+	}
+
+	requestor, err := a.Authenticate(&Credentials{Signature: sign("alice", "secret", time.Now())})
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("alice"), requestor)
+
+	// wrong key
+	_, err = a.Authenticate(&Credentials{Signature: sign("alice", "other", time.Now())})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+	// unknown key ID
+	_, err = a.Authenticate(&Credentials{Signature: sign("bob", "secret", time.Now())})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+	// stale and future signatures
+	_, err = a.Authenticate(&Credentials{Signature: sign("alice", "secret", time.Now().Add(-time.Hour))})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+	_, err = a.Authenticate(&Credentials{Signature: sign("alice", "secret", time.Now().Add(time.Hour))})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+	// tampered message
+	sig := sign("alice", "secret", time.Now())
+	sig.Message = SignatureMessage("DELETE", "/v1/jobs/1", sig.Timestamp, nil)
+	_, err = a.Authenticate(&Credentials{Signature: sig})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+}
+
+func TestCertificateAuthenticator(t *testing.T) {
+	requestor, err := CertificateAuthenticator{}.Authenticate(&Credentials{
+		Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("alice"), requestor)
+
+	_, err = CertificateAuthenticator{}.Authenticate(&Credentials{Certificate: &x509.Certificate{}})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+}
+
+func TestMultiAuthenticator(t *testing.T) {
+	path := writeSecrets(t, "alice token-a\n")
+	defer os.Remove(path)
+	tokens, err := NewTokenAuthenticator(path)
+	require.NoError(t, err)
+	a := MultiAuthenticator{tokens, CertificateAuthenticator{}}
+
+	requestor, err := a.Authenticate(&Credentials{Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}}})
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("bob"), requestor)
+
+	// an invalid token is not overridden by the other credentials
+	_, err = a.Authenticate(&Credentials{Token: "invalid", Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}}})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+
+	_, err = a.Authenticate(&Credentials{})
+	require.Equal(t, ErrorKindUnauthenticated, ErrorKindOf(err))
+}
+
+func TestCheckRequestor(t *testing.T) {
+	requestor, err := CheckRequestor("alice", "")
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("alice"), requestor)
+	requestor, err = CheckRequestor("alice", "alice")
+	require.NoError(t, err)
+	require.Equal(t, EventRequestor("alice"), requestor)
+	_, err = CheckRequestor("alice", "bob")
+	require.Equal(t, ErrorKindPermissionDenied, ErrorKindOf(err))
+}
+
+func TestOwnerAuthorizer(t *testing.T) {
+	a := NewOwnerAuthorizer([]EventRequestor{"admin"})
+	require.NoError(t, a.AuthorizeJob("alice", "alice"))
+	require.NoError(t, a.AuthorizeJob("admin", "alice"))
+	require.Equal(t, ErrorKindPermissionDenied, ErrorKindOf(a.AuthorizeJob("bob", "alice")))
+	require.NoError(t, a.AuthorizeAdmin("admin"))
+	require.Equal(t, ErrorKindPermissionDenied, ErrorKindOf(a.AuthorizeAdmin("alice")))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package api
+
+import (
+	"errors"
+)
+
+// ErrorKind classifies the errors returned by the API, so that listeners can
+// map them to the status codes of their protocol.
+type ErrorKind int
+
+// The various kinds of API errors. Errors which are not classified are
+// internal errors.
+const (
+	ErrorKindInternal ErrorKind = iota
+	// ErrorKindNotFound means that the job does not exist.
+	ErrorKindNotFound
+	// ErrorKindConflict means that the request conflicts with the state of
+	// the job, e.g. stopping a job which is not running.
+	ErrorKindConflict
+	// ErrorKindInvalid means that the request is invalid, e.g. it carries a
+	// malformed job descriptor.
+	ErrorKindInvalid
+	// ErrorKindUnavailable means that the request cannot be served, e.g.
+	// because the server is shutting down.
+	ErrorKindUnavailable
+	// ErrorKindUnauthenticated means that the credentials of the request are
+	// missing or invalid.
+	ErrorKindUnauthenticated
+	// ErrorKindPermissionDenied means that the requestor is not allowed to
+	// perform the request.
+	ErrorKindPermissionDenied
+)
+
+// Error is an error which is classified by kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// NewError returns an error of the given kind, wrapping err.
+func NewError(kind ErrorKind, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// Error returns the message of the wrapped error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf returns the kind of an error, which can be wrapped in other
+// errors. Errors which are not classified are internal errors.
+func ErrorKindOf(err error) ErrorKind {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind
+	}
+	return ErrorKindInternal
+}
@@ -7,6 +7,8 @@ package api
 
 import (
 	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
@@ -27,11 +29,21 @@ func (e EventType) String() string {
 }
 
 var eventTypeNames = map[EventType]string{
-	EventTypeStart:  "event_type_start",
-	EventTypeStatus: "event_type_status",
-	EventTypeStop:   "event_type_stop",
-	EventTypeRetry:  "event_type_retry",
-	EventTypeError:  "event_type_error",
+	EventTypeStart:             "event_type_start",
+	EventTypeStatus:            "event_type_status",
+	EventTypeStop:              "event_type_stop",
+	EventTypeRetry:             "event_type_retry",
+	EventTypeList:              "event_type_list",
+	EventTypeWatch:             "event_type_watch",
+	EventTypeError:             "event_type_error",
+	EventTypeListQuarantine:    "event_type_list_quarantine",
+	EventTypeReleaseQuarantine: "event_type_release_quarantine",
+	EventTypeDetachTarget:      "event_type_detach_target",
+	EventTypeCreateSchedule:    "event_type_create_schedule",
+	EventTypeListSchedules:     "event_type_list_schedules",
+	EventTypePauseSchedule:     "event_type_pause_schedule",
+	EventTypeResumeSchedule:    "event_type_resume_schedule",
+	EventTypeDeleteSchedule:    "event_type_delete_schedule",
 }
 
 // list of existing API event types.
@@ -41,6 +53,16 @@ const (
 	EventTypeStop
 	EventTypeRetry
 	EventTypeError
+	EventTypeList
+	EventTypeWatch
+	EventTypeListQuarantine
+	EventTypeReleaseQuarantine
+	EventTypeDetachTarget
+	EventTypeCreateSchedule
+	EventTypeListSchedules
+	EventTypePauseSchedule
+	EventTypeResumeSchedule
+	EventTypeDeleteSchedule
 )
 
 // Event represents an event that the API can generate. This is used by the API
@@ -95,15 +117,127 @@ func (e EventStopMsg) Requestor() EventRequestor { return e.requestor }
 type EventRetryMsg struct {
 	requestor EventRequestor
 	JobID     types.JobID
+	// FailedTargetsOnly restricts the retried job to the targets that failed
+	// in the original job.
+	FailedTargetsOnly bool
 }
 
 // Requestor returns the requestor of the API call as reported by the client.
 func (e EventRetryMsg) Requestor() EventRequestor { return e.requestor }
 
+// EventListMsg contains the arguments for an event of type List.
+type EventListMsg struct {
+	requestor EventRequestor
+	Query     job.ListQuery
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventListMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventWatchMsg contains the arguments for an event of type Watch.
+type EventWatchMsg struct {
+	requestor EventRequestor
+	JobID     types.JobID
+	// AfterID is the ID of the last event received by the client, if it is
+	// reconnecting. Only the events after it are streamed.
+	AfterID uint64
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventWatchMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventListQuarantineMsg contains the arguments for an event of type
+// ListQuarantine.
+type EventListQuarantineMsg struct {
+	requestor EventRequestor
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventListQuarantineMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventReleaseQuarantineMsg contains the arguments for an event of type
+// ReleaseQuarantine.
+type EventReleaseQuarantineMsg struct {
+	requestor EventRequestor
+	TargetID  string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventReleaseQuarantineMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventDetachTargetMsg contains the arguments for an event of type
+// DetachTarget.
+type EventDetachTargetMsg struct {
+	requestor EventRequestor
+	JobID     types.JobID
+	TargetID  string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventDetachTargetMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventCreateScheduleMsg contains the arguments for an event of type
+// CreateSchedule.
+type EventCreateScheduleMsg struct {
+	requestor EventRequestor
+	Schedule  job.ScheduleDescriptor
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventCreateScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventListSchedulesMsg contains the arguments for an event of type
+// ListSchedules.
+type EventListSchedulesMsg struct {
+	requestor EventRequestor
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventListSchedulesMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventPauseScheduleMsg contains the arguments for an event of type
+// PauseSchedule.
+type EventPauseScheduleMsg struct {
+	requestor  EventRequestor
+	ScheduleID types.ScheduleID
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventPauseScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventResumeScheduleMsg contains the arguments for an event of type
+// ResumeSchedule.
+type EventResumeScheduleMsg struct {
+	requestor  EventRequestor
+	ScheduleID types.ScheduleID
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventResumeScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventDeleteScheduleMsg contains the arguments for an event of type
+// DeleteSchedule.
+type EventDeleteScheduleMsg struct {
+	requestor  EventRequestor
+	ScheduleID types.ScheduleID
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventDeleteScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
 // EventResponse is a response to an EventMsg.
 type EventResponse struct {
 	Requestor EventRequestor
 	JobID     types.JobID
 	Err       error
 	Status    *job.Status
+	Jobs      []job.Summary
+	// Subscription delivers the events of a watched job
+	Subscription *storage.Subscription
+	// Quarantined are the quarantined targets, or the released one
+	Quarantined []*target.QuarantinedTarget
+	// Target is the target detached from a job
+	Target *target.Target
+	// Schedules are the listed schedules, or the one operated on
+	Schedules []*job.Schedule
 }
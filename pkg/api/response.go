@@ -7,6 +7,8 @@ package api
 
 import (
 	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
@@ -20,15 +22,35 @@ const (
 	ResponseTypeStatus
 	ResponseTypeRetry
 	ResponseTypeVersion
+	ResponseTypeList
+	ResponseTypeWatch
+	ResponseTypeListQuarantine
+	ResponseTypeReleaseQuarantine
+	ResponseTypeDetachTarget
+	ResponseTypeCreateSchedule
+	ResponseTypeListSchedules
+	ResponseTypePauseSchedule
+	ResponseTypeResumeSchedule
+	ResponseTypeDeleteSchedule
 )
 
 // ResponseTypeToName maps response types to their names.
 var ResponseTypeToName = map[ResponseType]string{
-	ResponseTypeStart:   "ResponseTypeStart",
-	ResponseTypeStop:    "ResponseTypeStop",
-	ResponseTypeStatus:  "ResponseTypeStatus",
-	ResponseTypeRetry:   "ResponseTypeRetry",
-	ResponseTypeVersion: "ResponseTypeVersion",
+	ResponseTypeStart:             "ResponseTypeStart",
+	ResponseTypeStop:              "ResponseTypeStop",
+	ResponseTypeStatus:            "ResponseTypeStatus",
+	ResponseTypeRetry:             "ResponseTypeRetry",
+	ResponseTypeVersion:           "ResponseTypeVersion",
+	ResponseTypeList:              "ResponseTypeList",
+	ResponseTypeWatch:             "ResponseTypeWatch",
+	ResponseTypeListQuarantine:    "ResponseTypeListQuarantine",
+	ResponseTypeReleaseQuarantine: "ResponseTypeReleaseQuarantine",
+	ResponseTypeDetachTarget:      "ResponseTypeDetachTarget",
+	ResponseTypeCreateSchedule:    "ResponseTypeCreateSchedule",
+	ResponseTypeListSchedules:     "ResponseTypeListSchedules",
+	ResponseTypePauseSchedule:     "ResponseTypePauseSchedule",
+	ResponseTypeResumeSchedule:    "ResponseTypeResumeSchedule",
+	ResponseTypeDeleteSchedule:    "ResponseTypeDeleteSchedule",
 }
 
 // Response is the type returned to any API request.
@@ -93,3 +115,111 @@ type ResponseDataVersion struct {
 func (r ResponseDataVersion) Type() ResponseType {
 	return ResponseTypeVersion
 }
+
+// ResponseDataList is the response type for a List request.
+type ResponseDataList struct {
+	Jobs []job.Summary
+}
+
+// Type returns the response type.
+func (r ResponseDataList) Type() ResponseType {
+	return ResponseTypeList
+}
+
+// ResponseDataWatch is the response type for a Watch request. The events are
+// delivered through the subscription, which is not serialized.
+type ResponseDataWatch struct {
+	JobID        types.JobID
+	Subscription *storage.Subscription `json:"-"`
+}
+
+// Type returns the response type.
+func (r ResponseDataWatch) Type() ResponseType {
+	return ResponseTypeWatch
+}
+
+// ResponseDataListQuarantine is the response type for a ListQuarantine
+// request.
+type ResponseDataListQuarantine struct {
+	Targets []*target.QuarantinedTarget
+}
+
+// Type returns the response type.
+func (r ResponseDataListQuarantine) Type() ResponseType {
+	return ResponseTypeListQuarantine
+}
+
+// ResponseDataReleaseQuarantine is the response type for a ReleaseQuarantine
+// request.
+type ResponseDataReleaseQuarantine struct {
+	Target *target.QuarantinedTarget
+}
+
+// Type returns the response type.
+func (r ResponseDataReleaseQuarantine) Type() ResponseType {
+	return ResponseTypeReleaseQuarantine
+}
+
+// ResponseDataDetachTarget is the response type for a DetachTarget request.
+type ResponseDataDetachTarget struct {
+	JobID  types.JobID
+	Target *target.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataDetachTarget) Type() ResponseType {
+	return ResponseTypeDetachTarget
+}
+
+// ResponseDataCreateSchedule is the response type for a CreateSchedule
+// request.
+type ResponseDataCreateSchedule struct {
+	Schedule *job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataCreateSchedule) Type() ResponseType {
+	return ResponseTypeCreateSchedule
+}
+
+// ResponseDataListSchedules is the response type for a ListSchedules request.
+type ResponseDataListSchedules struct {
+	Schedules []*job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataListSchedules) Type() ResponseType {
+	return ResponseTypeListSchedules
+}
+
+// ResponseDataPauseSchedule is the response type for a PauseSchedule request.
+type ResponseDataPauseSchedule struct {
+	Schedule *job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataPauseSchedule) Type() ResponseType {
+	return ResponseTypePauseSchedule
+}
+
+// ResponseDataResumeSchedule is the response type for a ResumeSchedule
+// request.
+type ResponseDataResumeSchedule struct {
+	Schedule *job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataResumeSchedule) Type() ResponseType {
+	return ResponseTypeResumeSchedule
+}
+
+// ResponseDataDeleteSchedule is the response type for a DeleteSchedule
+// request.
+type ResponseDataDeleteSchedule struct {
+	Schedule *job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataDeleteSchedule) Type() ResponseType {
+	return ResponseTypeDeleteSchedule
+}
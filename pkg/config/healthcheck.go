@@ -0,0 +1,12 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package config
+
+// HealthCheckCmdEnabled allows the health checks of the tests to run commands
+// on the ConTest server. The commands are chosen by whoever submits the job,
+// so they are rejected unless the server enables them explicitly, and only TCP
+// health checks are allowed.
+var HealthCheckCmdEnabled = false
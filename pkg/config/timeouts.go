@@ -8,9 +8,14 @@ package config
 import "time"
 
 // TargetManagerTimeout represents the maximum time that JobManager should wait
-// for the execution of Acquire and Release functions from the chosen TargetManager
+// for the execution of Acquire functions from the chosen TargetManager
 var TargetManagerTimeout = 5 * time.Minute
 
+// TargetManagerReleaseTimeout represents the maximum time that JobManager
+// should wait for the execution of Release functions from the chosen
+// TargetManager. Release is not interrupted by the cancellation of the job.
+var TargetManagerReleaseTimeout = 5 * time.Minute
+
 // StepInjectTimeout represents the maximum time that TestRunner will wait for
 // the first TestStep of the pipeline to accept a Target
 var StepInjectTimeout = 30 * time.Second
@@ -40,3 +45,7 @@ var TestRunnerShutdownTimeout = 30 * time.Second
 // is reset every time a step returns. The timeout should be handled so that it
 // doesn't reset when a TestStep returns.
 var TestRunnerStepShutdownTimeout = 5 * time.Second
+
+// LockTimeout represents the duration of the locks that JobRunner takes on the
+// targets of a job. Locks are refreshed periodically while the job runs.
+var LockTimeout = 10 * time.Second
@@ -49,6 +49,7 @@ type Query struct {
 	event.Query
 	TestName      string
 	TestStepLabel string
+	TargetID      string
 }
 
 // QueryField defines a function type used to set fields on a Query object
@@ -104,6 +105,13 @@ func QueryTestStepLabel(testStepLabel string) QueryField {
 	}
 }
 
+// QueryTargetID sets the TargetID field of the Query object
+func QueryTargetID(targetID string) QueryField {
+	return func(eq *Query) {
+		eq.TargetID = targetID
+	}
+}
+
 // Emitter defines the interface that emitter objects must implement
 type Emitter interface {
 	Emit(event Data) error
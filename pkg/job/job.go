@@ -6,6 +6,7 @@
 package job
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/test"
@@ -17,14 +18,98 @@ import (
 // JobDescriptor models the JSON encoded blob which is given as input to the
 // job creation request. A JobDescriptor embeds a list of TestDescriptor.
 type JobDescriptor struct {
-	JobName         string
-	Tags            []string
-	Runs            uint
-	RunInterval     xjson.Duration
+	JobName     string
+	Tags        []string
+	Runs        uint
+	RunInterval xjson.Duration
+	// ParallelTests runs the tests of each run concurrently, at most
+	// MaxParallelTests at a time if set, instead of one after the other.
+	ParallelTests    bool
+	MaxParallelTests uint
+	// FailurePolicy defines whether the job goes on with its next runs when
+	// a run fails. MaxConsecutiveFailures is only used, and required, by
+	// FailurePolicyAbortAfterConsecutiveFailures.
+	FailurePolicy          FailurePolicy
+	MaxConsecutiveFailures uint
+	// Priority orders the job among the queued ones, when the JobManager
+	// limits the number of running jobs.
+	Priority        Priority
 	TestDescriptors []*test.TestDescriptor
 	Reporting       Reporting
 }
 
+// Priority is the priority class of a job. Queued jobs with a higher priority
+// start first, and jobs with the same priority start in submission order.
+type Priority string
+
+// The priority classes of a job
+const (
+	PriorityHigh Priority = "high"
+	// PriorityNormal is the default.
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// Rank returns a number which is greater for higher priorities. The empty
+// priority stands for PriorityNormal.
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityHigh:
+		return 1
+	case PriorityLow:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Validate checks that the priority is known. The empty priority stands for
+// PriorityNormal.
+func (p Priority) Validate() error {
+	switch p {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	default:
+		return fmt.Errorf("unknown priority '%s'", p)
+	}
+}
+
+// FailurePolicy defines what a job does when one of its runs fails, i.e. a
+// test of the run fails to acquire or release its targets, or to run.
+type FailurePolicy string
+
+// The failure policies of a job
+const (
+	// FailurePolicyStopOnFirstFailure fails the job as soon as a run fails.
+	// It is the default.
+	FailurePolicyStopOnFirstFailure FailurePolicy = "stop-on-first-failure"
+	// FailurePolicyContinue skips the remaining tests of a failed run, and
+	// goes on with the next runs.
+	FailurePolicyContinue FailurePolicy = "continue"
+	// FailurePolicyAbortAfterConsecutiveFailures goes on with the next runs
+	// like FailurePolicyContinue, unless the last MaxConsecutiveFailures runs
+	// failed, in which case the job fails.
+	FailurePolicyAbortAfterConsecutiveFailures FailurePolicy = "abort-after-consecutive-failures"
+)
+
+// Validate checks that the failure policy is known. The empty policy stands
+// for FailurePolicyStopOnFirstFailure.
+func (p FailurePolicy) Validate(maxConsecutiveFailures uint) error {
+	switch p {
+	case "", FailurePolicyStopOnFirstFailure, FailurePolicyContinue:
+		if maxConsecutiveFailures > 0 {
+			return fmt.Errorf("MaxConsecutiveFailures requires the %s failure policy", FailurePolicyAbortAfterConsecutiveFailures)
+		}
+	case FailurePolicyAbortAfterConsecutiveFailures:
+		if maxConsecutiveFailures == 0 {
+			return fmt.Errorf("the %s failure policy requires MaxConsecutiveFailures", p)
+		}
+	default:
+		return fmt.Errorf("unknown failure policy '%s'", p)
+	}
+	return nil
+}
+
 // Job is used to run a type of test job on a given set of targets.
 type Job struct {
 	ID   types.JobID
@@ -54,6 +139,17 @@ type Job struct {
 	// unlimited, are specified.
 	RunInterval time.Duration
 	Tests       []*test.Test
+	// ParallelTests runs the tests of each run concurrently instead of one
+	// after the other, at most MaxParallelTests at a time, unless it is 0.
+	// The tests must use disjoint targets.
+	ParallelTests    bool
+	MaxParallelTests uint
+	// FailurePolicy and MaxConsecutiveFailures define whether the job goes
+	// on with its next runs when a run fails, see FailurePolicy.
+	FailurePolicy          FailurePolicy
+	MaxConsecutiveFailures uint
+	// Priority orders the job among the queued ones, see Priority.
+	Priority Priority
 	// RunReporterBundles and FinalReporterBundles wrap the reporter instances
 	// chosen for the Job and its associated parameters, which have already
 	// gone through validation
@@ -80,3 +176,13 @@ func (j *Job) IsCancelled() bool {
 		return false
 	}
 }
+
+// IsPaused returns whether the job has been paused
+func (j *Job) IsPaused() bool {
+	select {
+	case _, ok := <-j.PauseCh:
+		return !ok
+	default:
+		return false
+	}
+}
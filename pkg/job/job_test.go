@@ -0,0 +1,33 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailurePolicyValidate(t *testing.T) {
+	require.NoError(t, FailurePolicy("").Validate(0))
+	require.NoError(t, FailurePolicyStopOnFirstFailure.Validate(0))
+	require.NoError(t, FailurePolicyContinue.Validate(0))
+	require.NoError(t, FailurePolicyAbortAfterConsecutiveFailures.Validate(3))
+
+	require.Error(t, FailurePolicy("retry").Validate(0))
+	require.Error(t, FailurePolicyContinue.Validate(3))
+	require.Error(t, FailurePolicyAbortAfterConsecutiveFailures.Validate(0))
+}
+
+func TestPriority(t *testing.T) {
+	require.NoError(t, Priority("").Validate())
+	require.NoError(t, PriorityHigh.Validate())
+	require.Error(t, Priority("urgent").Validate())
+
+	require.Greater(t, PriorityHigh.Rank(), PriorityNormal.Rank())
+	require.Greater(t, PriorityNormal.Rank(), PriorityLow.Rank())
+	require.Equal(t, PriorityNormal.Rank(), Priority("").Rank())
+}
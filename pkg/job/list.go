@@ -0,0 +1,57 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package job
+
+import (
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// ListQuery wraps the criteria used to list jobs. Criteria left to their zero
+// value are ignored, so an empty ListQuery matches all the jobs.
+type ListQuery struct {
+	// States restricts the list to jobs whose latest state is one of the
+	// given ones.
+	States []event.Name
+	// Tags restricts the list to jobs tagged with any of the given tags, or
+	// with all of them if MatchAllTags is true.
+	Tags         []string
+	MatchAllTags bool
+	// Requestor restricts the list to jobs requested by the given requestor.
+	Requestor string
+	// NamePattern restricts the list to jobs whose name matches the given
+	// pattern, where `*` matches any sequence of characters and `?` matches
+	// a single character.
+	NamePattern string
+	// RequestedAfter and RequestedBefore restrict the list to jobs requested
+	// within the given time range.
+	RequestedAfter  time.Time
+	RequestedBefore time.Time
+	// Offset and Limit are used for pagination. Jobs are listed from the most
+	// recent to the oldest. A Limit of 0 means no limit.
+	Offset uint
+	Limit  uint
+}
+
+// Summary contains the information about a job which is returned when listing
+// jobs.
+type Summary struct {
+	JobID       types.JobID
+	JobName     string
+	Requestor   string
+	RequestTime time.Time
+	Tags        []string
+	// State is the latest state event emitted for the job, or an empty string
+	// if no state event was emitted yet.
+	State event.Name
+}
+
+// Lister is an interface implemented by objects that list jobs
+type Lister interface {
+	List(query *ListQuery) ([]Summary, error)
+}
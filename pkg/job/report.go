@@ -40,6 +40,9 @@ func (r *Report) ToJSON() ([]byte, error) {
 // ReportEmitter is an interface implemented by objects that implement report emission logic
 type ReportEmitter interface {
 	Emit(jobReport *JobReport) error
+	// EmitRunReports emits the reports of a single run as soon as it
+	// completes. Run numbers start at 1.
+	EmitRunReports(jobID types.JobID, runNumber uint, reports []*Report) error
 }
 
 // ReportFetcher is an interface implemented by objects that implement report fetching logic
@@ -18,6 +18,12 @@ type Request struct {
 	Requestor     string
 	RequestTime   time.Time
 	JobDescriptor string
+	// Tags are the tags of the job, as specified in the JobDescriptor. Storage
+	// engines return them sorted and without duplicates.
+	Tags []string
+	// RetryOf is the ID of the job that this request is retrying, if any. A
+	// value of 0 means that the request is not a retry.
+	RetryOf types.JobID
 }
 
 // RequestEmitter is an interface implemented by creator objects that
@@ -30,6 +36,7 @@ type RequestEmitter interface {
 // job requests objects
 type RequestFetcher interface {
 	Fetch(id types.JobID) (*Request, error)
+	FetchByTag(tag string) ([]*Request, error)
 }
 
 // RequestEmitterFetcher is an interface implemented by objects that implement both
@@ -0,0 +1,120 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package job
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/lib/cron"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/insomniacslk/xjson"
+)
+
+// OverlapPolicy defines what a schedule does when it fires while the job it
+// started the previous time is still running.
+type OverlapPolicy string
+
+// The overlap policies of a schedule
+const (
+	// OverlapPolicySkip does not start a new job. It is the default.
+	OverlapPolicySkip OverlapPolicy = "skip"
+	// OverlapPolicyQueue starts the new job as soon as the previous one
+	// terminates. Firings happening while a job is already queued are merged
+	// into it.
+	OverlapPolicyQueue OverlapPolicy = "queue"
+	// OverlapPolicyCancelPrevious cancels the previous job, and starts the new
+	// one right away.
+	OverlapPolicyCancelPrevious OverlapPolicy = "cancel-previous"
+)
+
+// Validate checks that the overlap policy is known. The empty policy stands
+// for OverlapPolicySkip.
+func (p OverlapPolicy) Validate() error {
+	switch p {
+	case "", OverlapPolicySkip, OverlapPolicyQueue, OverlapPolicyCancelPrevious:
+		return nil
+	default:
+		return fmt.Errorf("unknown overlap policy '%s'", p)
+	}
+}
+
+// ScheduleDescriptor describes a recurring job: the job descriptor is started
+// as a new job each time the schedule fires, either according to a cron
+// expression (see the cron package) or at a fixed interval. Exactly one of
+// Cron and Interval must be set.
+type ScheduleDescriptor struct {
+	JobDescriptor string
+	Cron          string
+	Interval      xjson.Duration
+	OverlapPolicy OverlapPolicy
+}
+
+// Validate checks the schedule, but not its job descriptor.
+func (d ScheduleDescriptor) Validate() error {
+	switch {
+	case d.Cron == "" && d.Interval == 0:
+		return errors.New("either a cron expression or an interval is required")
+	case d.Cron != "" && d.Interval != 0:
+		return errors.New("a cron expression and an interval are mutually exclusive")
+	case d.Interval < 0:
+		return errors.New("interval must be positive")
+	}
+	if d.Cron != "" {
+		if _, err := cron.Parse(d.Cron); err != nil {
+			return err
+		}
+	}
+	return d.OverlapPolicy.Validate()
+}
+
+// Schedule is a persisted ScheduleDescriptor, together with the state of its
+// firings.
+type Schedule struct {
+	ID            types.ScheduleID
+	JobName       string
+	Requestor     string
+	CreateTime    time.Time
+	JobDescriptor string
+	Cron          string
+	Interval      xjson.Duration
+	OverlapPolicy OverlapPolicy
+	// Paused schedules do not fire until they are resumed.
+	Paused bool
+	// LastFireTime and LastJobID are the time the schedule last started a
+	// job, and the ID of that job. They are zero if it never did.
+	LastFireTime time.Time
+	LastJobID    types.JobID
+	// NextFireTime is when the schedule fires next. It is not stored, and it
+	// is zero for paused schedules.
+	NextFireTime time.Time
+	// Queued is whether a firing waits for the previous job to terminate,
+	// see OverlapPolicyQueue. It is not stored.
+	Queued bool
+}
+
+// Next returns the first firing time of the schedule strictly after t.
+// Schedules with an interval fire at their creation time plus any multiple of
+// the interval. The zero time is returned if the schedule never fires again.
+func (s *Schedule) Next(t time.Time) (time.Time, error) {
+	if s.Cron != "" {
+		e, err := cron.Parse(s.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return e.Next(t), nil
+	}
+	interval := time.Duration(s.Interval)
+	if interval <= 0 {
+		return time.Time{}, fmt.Errorf("invalid interval %s", interval)
+	}
+	if t.Before(s.CreateTime) {
+		return s.CreateTime, nil
+	}
+	return s.CreateTime.Add((t.Sub(s.CreateTime)/interval + 1) * interval), nil
+}
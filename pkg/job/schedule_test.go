@@ -0,0 +1,39 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/xjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleDescriptorValidate(t *testing.T) {
+	require.NoError(t, ScheduleDescriptor{Cron: "@daily"}.Validate())
+	require.NoError(t, ScheduleDescriptor{Interval: xjson.Duration(time.Hour), OverlapPolicy: OverlapPolicyQueue}.Validate())
+
+	require.Error(t, ScheduleDescriptor{}.Validate())
+	require.Error(t, ScheduleDescriptor{Cron: "@daily", Interval: xjson.Duration(time.Hour)}.Validate())
+	require.Error(t, ScheduleDescriptor{Interval: xjson.Duration(-time.Hour)}.Validate())
+	require.Error(t, ScheduleDescriptor{Cron: "every day"}.Validate())
+	require.Error(t, ScheduleDescriptor{Cron: "@daily", OverlapPolicy: "wait"}.Validate())
+}
+
+func TestScheduleNextInterval(t *testing.T) {
+	created := time.Date(2020, time.January, 1, 10, 0, 0, 0, time.UTC)
+	s := Schedule{CreateTime: created, Interval: xjson.Duration(time.Hour)}
+
+	next, err := s.Next(created)
+	require.NoError(t, err)
+	require.Equal(t, created.Add(time.Hour), next)
+
+	// missed firings are skipped
+	next, err = s.Next(created.Add(150 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, created.Add(3*time.Hour), next)
+}
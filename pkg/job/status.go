@@ -53,6 +53,11 @@ type Status struct {
 	// `Finished` is false.
 	EndTime time.Time
 
+	// QueuePosition is the position of the job in the queue of the jobs
+	// waiting to start, starting from 1, if its state is JobStateQueued. It
+	// is 0 otherwise.
+	QueuePosition uint
+
 	// TestStatus represents a list of status objects for each test in the job
 	TestStatus []TestStatus
 
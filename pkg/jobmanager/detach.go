@@ -0,0 +1,54 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/runner"
+)
+
+func (jm *JobManager) detachTarget(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventDetachTargetMsg)
+	errResponse := func(err error) *api.EventResponse {
+		log.Errorf("Cannot detach target %s from job %d: %v", msg.TargetID, msg.JobID, err)
+		return &api.EventResponse{
+			JobID:     msg.JobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not detach target %s: %w", msg.TargetID, err),
+		}
+	}
+	request, err := jm.fetchJobRequest(msg.JobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if err := jm.authorizeJob(ev.Msg.Requestor(), request); err != nil {
+		return errResponse(err)
+	}
+	state, err := jm.lastJobState(msg.JobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if eventNameIn(state, JobFinalStates) {
+		return errResponse(api.NewError(api.ErrorKindConflict, fmt.Errorf("job %d is not running, its state is '%s'", msg.JobID, state)))
+	}
+	detached, err := jm.jobRunner.DetachTarget(msg.JobID, msg.TargetID)
+	if errors.Is(err, runner.ErrTargetNotInJob) {
+		return errResponse(api.NewError(api.ErrorKindNotFound, err))
+	}
+	if err != nil {
+		// the job is not running a test on this instance of ConTest
+		return errResponse(api.NewError(api.ErrorKindConflict, err))
+	}
+	log.Infof("Target %s detached from job %d by '%s'", msg.TargetID, msg.JobID, ev.Msg.Requestor())
+	return &api.EventResponse{
+		JobID:     msg.JobID,
+		Requestor: ev.Msg.Requestor(),
+		Target:    detached,
+	}
+}
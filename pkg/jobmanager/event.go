@@ -14,6 +14,10 @@ type errorPayload struct {
 	Err string
 }
 
+// EventJobQueued indicates that a Job waits for the JobManager to start it,
+// because too many jobs are running
+var EventJobQueued = event.Name("JobStateQueued")
+
 // EventJobStarted indicates that a Job is beginning execution
 var EventJobStarted = event.Name("JobStateStarted")
 
@@ -32,3 +36,18 @@ var EventJobCancelled = event.Name("JobStateCancelled")
 
 // EventJobCancellationFailed indicates that the cancellation was not completed correctly
 var EventJobCancellationFailed = event.Name("JobStateCancelled")
+
+// EventJobPaused indicates that a Job has been paused, and its state has been
+// persisted so that it can be resumed
+var EventJobPaused = event.Name("JobStatePaused")
+
+// EventJobResumed indicates that a paused Job has been resumed
+var EventJobResumed = event.Name("JobStateResumed")
+
+// EventJobWaitingForTargets indicates that a Job is waiting for targets locked
+// by other jobs
+var EventJobWaitingForTargets = event.Name("JobStateWaitingForTargets")
+
+// EventJobTargetsAcquired indicates that a Job waiting for targets acquired
+// them, and runs again
+var EventJobTargetsAcquired = event.Name("JobStateTargetsAcquired")
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/config"
 	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
 	"github.com/facebookincubator/contest/pkg/event/testevent"
@@ -23,8 +24,10 @@ import (
 	"github.com/facebookincubator/contest/pkg/pluginregistry"
 	"github.com/facebookincubator/contest/pkg/runner"
 	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/test"
 	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
 )
 
 var log = logging.GetLogger("pkg/jobmanager")
@@ -48,13 +51,72 @@ type JobManager struct {
 	jobsWg sync.WaitGroup
 
 	jobRequestManager  job.RequestEmitterFetcher
+	jobLister          job.Lister
 	jobReportManager   job.ReportEmitterFetcher
 	frameworkEvManager frameworkevent.EmitterFetcher
 	testEvManager      testevent.Fetcher
 
+	jobPauseStateManager storage.JobPauseStateEmitterFetcher
+
 	apiListener    api.Listener
 	apiCancel      chan struct{}
 	pluginRegistry *pluginregistry.PluginRegistry
+
+	// authorizer decides who can stop and retry a job. If nil, anybody can.
+	authorizer api.Authorizer
+
+	// targetLocker is the Locker shared by all the jobs of this JobManager.
+	targetLocker target.Locker
+
+	// quarantinePolicy decides when targets are quarantined. If zero, targets
+	// are never quarantined.
+	quarantinePolicy QuarantinePolicy
+	targetQuarantine storage.TargetQuarantine
+
+	// schedules are the schedules of recurring jobs. They are only accessed
+	// by the goroutine running Start.
+	schedules       map[types.ScheduleID]*job.Schedule
+	scheduleManager storage.ScheduleEmitterFetcher
+
+	// queuePolicy bounds the number of running jobs. If zero, jobs are never
+	// queued.
+	queuePolicy QueuePolicy
+	queueMu     sync.Mutex
+	queue       jobQueue
+}
+
+// Option is an optional setting of the JobManager.
+type Option func(jm *JobManager)
+
+// WithAuthorizer sets the Authorizer deciding who can stop and retry a job.
+func WithAuthorizer(a api.Authorizer) Option {
+	return func(jm *JobManager) {
+		jm.authorizer = a
+	}
+}
+
+// WithTargetLocker sets the Locker used to lock the targets of all the jobs.
+// If not set, an in-memory locker is used.
+func WithTargetLocker(tl target.Locker) Option {
+	return func(jm *JobManager) {
+		jm.targetLocker = tl
+	}
+}
+
+// WithQuarantinePolicy enables the quarantine of the targets which fail too
+// many of their recent runs, see QuarantinePolicy.
+func WithQuarantinePolicy(p QuarantinePolicy) Option {
+	return func(jm *JobManager) {
+		jm.quarantinePolicy = p
+	}
+}
+
+// WithQueuePolicy bounds the number of jobs running at the same time, see
+// QueuePolicy.
+func WithQueuePolicy(p QueuePolicy) Option {
+	return func(jm *JobManager) {
+		jm.queuePolicy = p
+	}
 }
 
 // NewJob creates a new Job object
@@ -77,6 +139,15 @@ func NewJob(pr *pluginregistry.PluginRegistry, jobDescriptor string) (*job.Job,
 	if jd.RunInterval < 0 {
 		return nil, errors.New("run interval must be non-negative")
 	}
+	if jd.MaxParallelTests > 0 && !jd.ParallelTests {
+		return nil, errors.New("MaxParallelTests requires ParallelTests")
+	}
+	if err := jd.FailurePolicy.Validate(jd.MaxConsecutiveFailures); err != nil {
+		return nil, err
+	}
+	if err := jd.Priority.Validate(); err != nil {
+		return nil, err
+	}
 
 	if len(jd.Reporting.RunReporters) == 0 && len(jd.Reporting.FinalReporters) == 0 {
 		return nil, errors.New("at least one run reporter or one final reporter must be specified in a job")
@@ -158,11 +229,17 @@ func NewJob(pr *pluginregistry.PluginRegistry, jobDescriptor string) (*job.Job,
 			}
 			stepBundles = append(stepBundles, *tsb)
 		}
+		if td.HealthCheck != nil {
+			if err := td.HealthCheck.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid health check for test %s: %v", name, err)
+			}
+		}
 		test := test.Test{
 			Name:                name,
 			TargetManagerBundle: tmb,
 			TestFetcherBundle:   tfb,
 			TestStepsBundles:    stepBundles,
+			HealthCheck:         td.HealthCheck,
 		}
 		tests = append(tests, &test)
 	}
@@ -170,14 +247,19 @@ func NewJob(pr *pluginregistry.PluginRegistry, jobDescriptor string) (*job.Job,
 	// Create a Job object from the above managers and parameters. The Job ID assigned
 	// is 0, and gets actually set by the JobManager after calling the persistence layer
 	job := job.Job{
-		ID:                   types.JobID(0),
-		Name:                 jd.JobName,
-		Tags:                 jd.Tags,
-		Runs:                 jd.Runs,
-		RunInterval:          time.Duration(jd.RunInterval),
-		Tests:                tests,
-		RunReporterBundles:   runReporterBundles,
-		FinalReporterBundles: finalReporterBundles,
+		ID:                     types.JobID(0),
+		Name:                   jd.JobName,
+		Tags:                   jd.Tags,
+		Runs:                   jd.Runs,
+		RunInterval:            time.Duration(jd.RunInterval),
+		Tests:                  tests,
+		ParallelTests:          jd.ParallelTests,
+		MaxParallelTests:       jd.MaxParallelTests,
+		FailurePolicy:          jd.FailurePolicy,
+		MaxConsecutiveFailures: jd.MaxConsecutiveFailures,
+		Priority:               jd.Priority,
+		RunReporterBundles:     runReporterBundles,
+		FinalReporterBundles:   finalReporterBundles,
 	}
 
 	job.Done = make(chan struct{})
@@ -189,7 +271,7 @@ func NewJob(pr *pluginregistry.PluginRegistry, jobDescriptor string) (*job.Job,
 }
 
 // New initializes and returns a new JobManager with the given API listener.
-func New(l api.Listener, pr *pluginregistry.PluginRegistry) (*JobManager, error) {
+func New(l api.Listener, pr *pluginregistry.PluginRegistry, opts ...Option) (*JobManager, error) {
 	if pr == nil {
 		return nil, errors.New("plugin registry cannot be nil")
 	}
@@ -204,12 +286,33 @@ func New(l api.Listener, pr *pluginregistry.PluginRegistry) (*JobManager, error)
 		pluginRegistry:     pr,
 		jobs:               make(map[types.JobID]*job.Job),
 		jobRequestManager:  jobRequestManager,
+		jobLister:          storage.NewJobLister(JobStateEvents),
 		jobReportManager:   jobReportManager,
 		frameworkEvManager: frameworkEvManager,
 		testEvManager:      testEvManager,
 		apiCancel:          make(chan struct{}),
+
+		jobPauseStateManager: storage.NewJobPauseStateEmitterFetcher(),
+		targetQuarantine:     storage.NewTargetQuarantine(),
+
+		schedules:       make(map[types.ScheduleID]*job.Schedule),
+		scheduleManager: storage.NewScheduleEmitterFetcher(),
+
+		queue: newJobQueue(),
+	}
+	for _, opt := range opts {
+		opt(&jm)
 	}
-	jm.jobRunner = runner.NewJobRunner()
+	if err := jm.quarantinePolicy.validate(); err != nil {
+		return nil, err
+	}
+	if err := jm.queuePolicy.validate(); err != nil {
+		return nil, err
+	}
+	if jm.targetLocker == nil {
+		jm.targetLocker = inmemory.New(config.LockTimeout)
+	}
+	jm.jobRunner = runner.NewJobRunner(jobLocker{Locker: jm.targetLocker, jm: &jm})
 	return &jm, nil
 }
 
@@ -225,6 +328,26 @@ func (jm *JobManager) handleEvent(ev *api.Event) {
 		resp = jm.stop(ev)
 	case api.EventTypeRetry:
 		resp = jm.retry(ev)
+	case api.EventTypeList:
+		resp = jm.list(ev)
+	case api.EventTypeWatch:
+		resp = jm.watch(ev)
+	case api.EventTypeListQuarantine:
+		resp = jm.listQuarantine(ev)
+	case api.EventTypeReleaseQuarantine:
+		resp = jm.releaseQuarantine(ev)
+	case api.EventTypeDetachTarget:
+		resp = jm.detachTarget(ev)
+	case api.EventTypeCreateSchedule:
+		resp = jm.createSchedule(ev)
+	case api.EventTypeListSchedules:
+		resp = jm.listSchedules(ev)
+	case api.EventTypePauseSchedule:
+		resp = jm.pauseSchedule(ev, ev.Msg.(api.EventPauseScheduleMsg).ScheduleID, true)
+	case api.EventTypeResumeSchedule:
+		resp = jm.pauseSchedule(ev, ev.Msg.(api.EventResumeScheduleMsg).ScheduleID, false)
+	case api.EventTypeDeleteSchedule:
+		resp = jm.deleteSchedule(ev)
 	default:
 		resp = &api.EventResponse{
 			Requestor: ev.Msg.Requestor(),
@@ -248,8 +371,22 @@ func (jm *JobManager) handleEvent(ev *api.Event) {
 
 // Start is responsible for starting the API listener and responding to incoming
 // events. It also responds to cancellation requests coming from SIGINT/SIGTERM
-// signals, propagating the signals downwards to all jobs.
+// signals, propagating the signals downwards to all jobs, and starts the jobs
+// of the schedules when they are due. Jobs which were paused by a previous
+// instance are resumed, and the ones it queued are queued again, before
+// serving the API.
 func (jm *JobManager) Start(sigs chan os.Signal) error {
+	if err := jm.resumePausedJobs(); err != nil {
+		log.Errorf("Could not resume paused jobs: %v", err)
+	}
+	if err := jm.requeueJobs(); err != nil {
+		log.Errorf("Could not queue jobs again: %v", err)
+	}
+	if err := jm.loadSchedules(); err != nil {
+		log.Errorf("Could not load schedules: %v", err)
+	}
+	scheduleTicker := time.NewTicker(scheduleCheckInterval)
+	defer scheduleTicker.Stop()
 	a := api.New()
 	errCh := make(chan error, 1)
 	go func() {
@@ -266,6 +403,9 @@ loop:
 			log.Printf("Handling event %+v", ev)
 			// send the response, and wait for the given timeout
 			jm.handleEvent(ev)
+		// start the jobs of the schedules which are due
+		case now := <-scheduleTicker.C:
+			jm.fireSchedules(now)
 		// check for errors or premature termination from the listener.
 		case err := <-errCh:
 			log.Info("JobManager: API listener failed, triggering a cancellation of all jobs")
@@ -313,14 +453,27 @@ func (jm *JobManager) CancelJob(jobID types.JobID) error {
 	return nil
 }
 
+// trackedJobs returns a copy of the jobs tracked by the JobManager, which can
+// be iterated while the jobs terminate and queued jobs start.
+func (jm *JobManager) trackedJobs() map[types.JobID]*job.Job {
+	jm.jobsMu.Lock()
+	defer jm.jobsMu.Unlock()
+	jobs := make(map[types.JobID]*job.Job, len(jm.jobs))
+	for jobID, j := range jm.jobs {
+		jobs[jobID] = j
+	}
+	return jobs
+}
+
 // CancelAll sends a cancellation request to the API listener and to every running
 // job.
 func (jm *JobManager) CancelAll() {
 	// TODO This doesn't see the right thing to do, if the listener fails we should
 	// pause, not cancel.
 	log.Info("JobManager: cancelling all jobs")
+	jm.stopQueue()
 	close(jm.apiCancel)
-	for jobID, job := range jm.jobs {
+	for jobID, job := range jm.trackedJobs() {
 		log.Debugf("JobManager: cancelling job with ID %v", jobID)
 		job.Cancel()
 	}
@@ -330,13 +483,36 @@ func (jm *JobManager) CancelAll() {
 // API listener.
 func (jm *JobManager) Pause() {
 	log.Info("JobManager: requested pausing")
+	jm.stopQueue()
 	close(jm.apiCancel)
-	for jobID, job := range jm.jobs {
+	for jobID, job := range jm.trackedJobs() {
 		log.Debugf("JobManager: pausing job with ID %v", jobID)
 		job.Pause()
 	}
 }
 
+// fetchJobRequest fetches the request of a job. If the job does not exist, the
+// error is classified accordingly for the API.
+func (jm *JobManager) fetchJobRequest(jobID types.JobID) (*job.Request, error) {
+	request, err := jm.jobRequestManager.Fetch(jobID)
+	if err != nil {
+		if errors.Is(err, storage.ErrJobNotFound) {
+			return nil, api.NewError(api.ErrorKindNotFound, fmt.Errorf("unknown job ID: %d", jobID))
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// authorizeJob checks whether a requestor can operate on the job with the
+// given request.
+func (jm *JobManager) authorizeJob(requestor api.EventRequestor, request *job.Request) error {
+	if jm.authorizer == nil {
+		return nil
+	}
+	return jm.authorizer.AuthorizeJob(requestor, api.EventRequestor(request.Requestor))
+}
+
 func (jm *JobManager) emitErrEvent(jobID types.JobID, eventName event.Name, err error) error {
 	var (
 		rawPayload json.RawMessage
@@ -0,0 +1,49 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event"
+)
+
+func eventNameIn(name event.Name, names []event.Name) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (jm *JobManager) list(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventListMsg)
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       err,
+		}
+	}
+	for _, state := range msg.Query.States {
+		if !eventNameIn(state, JobStateEvents) {
+			return errResponse(api.NewError(api.ErrorKindInvalid, fmt.Errorf("invalid job state '%s'", state)))
+		}
+	}
+	if !msg.Query.RequestedAfter.IsZero() && !msg.Query.RequestedBefore.IsZero() &&
+		msg.Query.RequestedBefore.Before(msg.Query.RequestedAfter) {
+		return errResponse(api.NewError(api.ErrorKindInvalid, fmt.Errorf("invalid time range, %v is before %v", msg.Query.RequestedBefore, msg.Query.RequestedAfter)))
+	}
+	jobs, err := jm.jobLister.List(&msg.Query)
+	if err != nil {
+		return errResponse(err)
+	}
+	return &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Jobs:      jobs,
+	}
+}
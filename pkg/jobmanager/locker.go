@@ -0,0 +1,49 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"errors"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// jobLocker wraps the target locker shared by the jobs, to skip the
+// quarantined targets, and to track with state events the jobs which wait for
+// targets locked by other jobs.
+type jobLocker struct {
+	target.Locker
+	jm *JobManager
+}
+
+// LockWait implements target.BlockingLocker. Quarantined targets are removed
+// from the request first. It then tries to lock the targets without waiting,
+// and only emits EventJobWaitingForTargets if it has to wait.
+func (l jobLocker) LockWait(jobID types.JobID, cancel <-chan struct{}, req target.LockRequest) ([]*target.Target, error) {
+	req, err := l.jm.skipQuarantined(jobID, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Deadline.IsZero() {
+		return target.LockWait(l.Locker, jobID, cancel, req)
+	}
+	locked, err := target.LockWait(l.Locker, jobID, cancel, target.LockRequest{
+		Targets: req.Targets,
+		Min:     req.Min,
+		Max:     req.Max,
+	})
+	if !errors.Is(err, target.ErrNotEnoughTargets) {
+		return locked, err
+	}
+	log.Infof("Job %d is waiting for targets until %s", jobID, req.Deadline)
+	_ = l.jm.emitEvent(jobID, EventJobWaitingForTargets)
+	locked, err = target.LockWait(l.Locker, jobID, cancel, req)
+	if err == nil {
+		_ = l.jm.emitEvent(jobID, EventJobTargetsAcquired)
+	}
+	return locked, err
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/runner"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// pauseJob persists the state of a paused job and emits the corresponding
+// state event. If the state cannot be persisted, the job is marked as failed
+// as it will not be possible to resume it.
+func (jm *JobManager) pauseJob(j *job.Job, state *runner.JobPauseState) {
+	if err := jm.jobPauseStateManager.Emit(j.ID, state); err != nil {
+		log.Errorf("Job %d was paused but its state could not be persisted: %v", j.ID, err)
+		_ = jm.emitErrEvent(j.ID, EventJobFailed, fmt.Errorf("could not persist pause state: %v", err))
+		return
+	}
+	log.Infof("Job %d paused during run #%d, test #%d", j.ID, state.Run+1, state.TestIndex)
+	_ = jm.emitEvent(j.ID, EventJobPaused)
+}
+
+// resumeJob rebuilds a paused job from its original request, and resumes it
+// from the given state.
+func (jm *JobManager) resumeJob(jobID types.JobID, rawState json.RawMessage) error {
+	var state runner.JobPauseState
+	if err := json.Unmarshal(rawState, &state); err != nil {
+		return fmt.Errorf("could not deserialize pause state: %v", err)
+	}
+	request, err := jm.jobRequestManager.Fetch(jobID)
+	if err != nil {
+		return err
+	}
+	j, err := NewJob(jm.pluginRegistry, request.JobDescriptor)
+	if err != nil {
+		return fmt.Errorf("could not rebuild job: %v", err)
+	}
+	if state.TestIndex < 0 || state.TestIndex >= len(j.Tests) {
+		return fmt.Errorf("invalid test index %d in pause state, job has %d tests", state.TestIndex, len(j.Tests))
+	}
+	j.ID = jobID
+	if err := jm.emitEvent(jobID, EventJobResumed); err != nil {
+		return err
+	}
+	// The state is consumed by the resumed job. If the job is paused again, a
+	// new state is persisted.
+	if err := jm.jobPauseStateManager.Delete(jobID); err != nil {
+		log.Warningf("Could not delete pause state of resumed job %d: %v", jobID, err)
+	}
+	jm.runJob(j, request.Requestor, &state)
+	return nil
+}
+
+// resumePausedJobs resumes all the jobs that were paused by a previous
+// instance of ConTest, e.g. because of a restart or an upgrade.
+func (jm *JobManager) resumePausedJobs() error {
+	states, err := jm.jobPauseStateManager.FetchAll()
+	if err != nil {
+		return err
+	}
+	for jobID, rawState := range states {
+		lastState, err := jm.lastJobState(jobID)
+		if err != nil {
+			return err
+		}
+		if lastState != EventJobPaused {
+			// the job has reached a different state after pausing (e.g. it
+			// was resumed already), the pause state is stale.
+			log.Warningf("Discarding stale pause state of job %d, whose last state is '%s'", jobID, lastState)
+			if err := jm.jobPauseStateManager.Delete(jobID); err != nil {
+				log.Warningf("Could not delete stale pause state of job %d: %v", jobID, err)
+			}
+			continue
+		}
+		log.Infof("Resuming paused job %d", jobID)
+		if err := jm.resumeJob(jobID, rawState); err != nil {
+			log.Errorf("Could not resume job %d: %v", jobID, err)
+			_ = jm.emitErrEvent(jobID, EventJobFailed, fmt.Errorf("could not resume job: %v", err))
+			if err := jm.jobPauseStateManager.Delete(jobID); err != nil {
+				log.Warningf("Could not delete pause state of job %d: %v", jobID, err)
+			}
+		}
+	}
+	return nil
+}
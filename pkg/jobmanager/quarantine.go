@@ -0,0 +1,211 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// QuarantinePolicy decides when a target is quarantined: when it failed, i.e.
+// it had a TargetErr or a TargetUnhealthy event, in at least Failures of the
+// last Runs jobs it ran in. Only the jobs which ran after the target was last
+// released count. Targets without an ID are never quarantined.
+type QuarantinePolicy struct {
+	Failures int
+	Runs     int
+}
+
+// failureEvents are the test events signaling that a target failed a run
+var failureEvents = []event.Name{target.EventTargetErr, target.EventTargetUnhealthy}
+
+// enabled returns whether targets are quarantined at all
+func (p QuarantinePolicy) enabled() bool {
+	return p.Failures > 0
+}
+
+func (p QuarantinePolicy) validate() error {
+	if p.Failures < 0 || p.Runs < 0 {
+		return fmt.Errorf("invalid quarantine policy %+v: values must be non-negative", p)
+	}
+	if p.Failures > p.Runs {
+		return fmt.Errorf("invalid quarantine policy %+v: failures cannot exceed runs", p)
+	}
+	return nil
+}
+
+// updateQuarantine quarantines the targets which failed in a job, if they
+// failed too many of their recent runs according to the QuarantinePolicy.
+func (jm *JobManager) updateQuarantine(jobID types.JobID) {
+	if !jm.quarantinePolicy.enabled() {
+		return
+	}
+	events, err := jm.testEvManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventNames(failureEvents),
+		},
+	)
+	if err != nil {
+		log.Warningf("Could not fetch failed targets of job %d to update the quarantine: %v", jobID, err)
+		return
+	}
+	failed := make(map[string]*target.Target)
+	for _, ev := range events {
+		if ev.Data != nil && ev.Data.Target != nil && ev.Data.Target.ID != "" {
+			failed[ev.Data.Target.ID] = ev.Data.Target
+		}
+	}
+	for _, t := range failed {
+		reason, err := jm.quarantineReason(t)
+		if err != nil {
+			log.Warningf("Could not evaluate the recent runs of target %s: %v", t, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		if err := jm.targetQuarantine.Quarantine(t, reason); err != nil {
+			log.Warningf("%v", err)
+			continue
+		}
+		log.Warningf("Target %s quarantined: %s", t, reason)
+	}
+}
+
+// quarantineReason returns why a target must be quarantined, or an empty
+// string if it must not.
+func (jm *JobManager) quarantineReason(t *target.Target) (string, error) {
+	record, err := jm.targetQuarantine.Get(t.ID)
+	if err != nil {
+		return "", err
+	}
+	fields := []testevent.QueryField{
+		testevent.QueryTargetID(t.ID),
+		testevent.QueryEventNames(append([]event.Name{target.EventTargetIn}, failureEvents...)),
+	}
+	if record != nil {
+		if !record.Released() {
+			// already quarantined
+			return "", nil
+		}
+		fields = append(fields, testevent.QueryEmittedStartTime(record.ReleaseTime))
+	}
+	events, err := jm.testEvManager.Fetch(fields)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch test events: %v", err)
+	}
+	failedJobs := make(map[types.JobID]bool)
+	for _, ev := range events {
+		if ev.Header == nil || ev.Data == nil {
+			continue
+		}
+		failedJobs[ev.Header.JobID] = failedJobs[ev.Header.JobID] || ev.Data.EventName != target.EventTargetIn
+	}
+	jobIDs := make([]types.JobID, 0, len(failedJobs))
+	for jobID := range failedJobs {
+		jobIDs = append(jobIDs, jobID)
+	}
+	// most recent jobs first
+	sort.Slice(jobIDs, func(i, j int) bool { return jobIDs[i] > jobIDs[j] })
+	if len(jobIDs) > jm.quarantinePolicy.Runs {
+		jobIDs = jobIDs[:jm.quarantinePolicy.Runs]
+	}
+	failures := 0
+	for _, jobID := range jobIDs {
+		if failedJobs[jobID] {
+			failures++
+		}
+	}
+	if failures < jm.quarantinePolicy.Failures {
+		return "", nil
+	}
+	return fmt.Sprintf("failed in %d of its last %d runs, most recently in job %d", failures, len(jobIDs), jobIDs[0]), nil
+}
+
+// skipQuarantined removes the quarantined targets from a lock request. It
+// fails if the request requires all its targets, and some are quarantined.
+func (jm *JobManager) skipQuarantined(jobID types.JobID, req target.LockRequest) (target.LockRequest, error) {
+	if !jm.quarantinePolicy.enabled() {
+		return req, nil
+	}
+	quarantined, err := jm.targetQuarantine.List()
+	if err != nil {
+		return req, err
+	}
+	if len(quarantined) == 0 {
+		return req, nil
+	}
+	ids := make(map[string]bool)
+	for _, qt := range quarantined {
+		ids[qt.ID] = true
+	}
+	var (
+		available []*target.Target
+		skipped   []string
+	)
+	for _, t := range req.Targets {
+		if ids[t.ID] {
+			skipped = append(skipped, t.String())
+			continue
+		}
+		available = append(available, t)
+	}
+	if len(skipped) == 0 {
+		return req, nil
+	}
+	if req.Min == 0 {
+		return req, fmt.Errorf("%d of the requested targets are quarantined: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+	log.Infof("Job %d: skipping %d quarantined target(s): %s", jobID, len(skipped), strings.Join(skipped, ", "))
+	req.Targets = available
+	return req, nil
+}
+
+func (jm *JobManager) listQuarantine(ev *api.Event) *api.EventResponse {
+	quarantined, err := jm.targetQuarantine.List()
+	return &api.EventResponse{
+		Requestor:   ev.Msg.Requestor(),
+		Quarantined: quarantined,
+		Err:         err,
+	}
+}
+
+func (jm *JobManager) releaseQuarantine(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventReleaseQuarantineMsg)
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not release target %s: %w", msg.TargetID, err),
+		}
+	}
+	if jm.authorizer != nil {
+		if err := jm.authorizer.AuthorizeAdmin(ev.Msg.Requestor()); err != nil {
+			return errResponse(err)
+		}
+	}
+	released, err := jm.targetQuarantine.Release(msg.TargetID)
+	if errors.Is(err, storage.ErrTargetNotQuarantined) {
+		return errResponse(api.NewError(api.ErrorKindNotFound, err))
+	}
+	if err != nil {
+		return errResponse(err)
+	}
+	log.Infof("Target %s released from quarantine by '%s'", msg.TargetID, ev.Msg.Requestor())
+	return &api.EventResponse{
+		Requestor:   ev.Msg.Requestor(),
+		Quarantined: []*target.QuarantinedTarget{released},
+	}
+}
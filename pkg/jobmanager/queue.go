@@ -0,0 +1,249 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// QueuePolicy bounds the number of jobs running at the same time. The jobs
+// which cannot start right away are queued, in the JobStateQueued state,
+// and start by priority, then in submission order, as running jobs
+// terminate. Limits left to 0 mean no limit.
+type QueuePolicy struct {
+	// MaxRunningJobs is the maximum number of jobs running at the same time.
+	MaxRunningJobs int
+	// MaxRunningJobsPerRequestor is the maximum number of jobs of the same
+	// requestor running at the same time, unless RequestorQuotas overrides
+	// it for the requestor.
+	MaxRunningJobsPerRequestor int
+	RequestorQuotas            map[string]int
+}
+
+func (p QueuePolicy) validate() error {
+	if p.MaxRunningJobs < 0 || p.MaxRunningJobsPerRequestor < 0 {
+		return fmt.Errorf("invalid queue policy %+v: values must be non-negative", p)
+	}
+	for requestor, quota := range p.RequestorQuotas {
+		if quota < 0 {
+			return fmt.Errorf("invalid queue policy %+v: quota of '%s' must be non-negative", p, requestor)
+		}
+	}
+	return nil
+}
+
+// quota returns the maximum number of running jobs of a requestor, or 0 if
+// there is no limit.
+func (p QueuePolicy) quota(requestor string) int {
+	if quota, ok := p.RequestorQuotas[requestor]; ok {
+		return quota
+	}
+	return p.MaxRunningJobsPerRequestor
+}
+
+// queuedJob is a job waiting to start
+type queuedJob struct {
+	job       *job.Job
+	requestor string
+	// seq orders the jobs with the same priority by submission
+	seq uint64
+}
+
+// jobQueue holds the queued jobs, and accounts for the running ones. It is
+// protected by queueMu.
+type jobQueue struct {
+	jobs []*queuedJob
+	seq  uint64
+	// running maps the running jobs to their requestor, and
+	// runningPerRequestor counts them by requestor.
+	running             map[types.JobID]string
+	runningPerRequestor map[string]int
+	// stopped is set once the JobManager is shutting down, after which no
+	// queued job is started.
+	stopped bool
+}
+
+func newJobQueue() jobQueue {
+	return jobQueue{
+		running:             make(map[types.JobID]string),
+		runningPerRequestor: make(map[string]int),
+	}
+}
+
+// canRunLocked returns whether a job of the requestor can start without
+// exceeding the queue policy.
+func (jm *JobManager) canRunLocked(requestor string) bool {
+	if jm.queue.stopped {
+		return false
+	}
+	if max := jm.queuePolicy.MaxRunningJobs; max > 0 && len(jm.queue.running) >= max {
+		return false
+	}
+	if quota := jm.queuePolicy.quota(requestor); quota > 0 && jm.queue.runningPerRequestor[requestor] >= quota {
+		return false
+	}
+	return true
+}
+
+// admit starts a new job, or queues it if too many jobs are running. Queued
+// jobs are registered with the JobManager, so that their status can be
+// requested and they can be cancelled.
+func (jm *JobManager) admit(j *job.Job, requestor string) error {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	// the queued jobs are started as soon as they can, so none of them could
+	// start in place of the new job.
+	if jm.canRunLocked(requestor) {
+		if err := jm.emitEvent(j.ID, EventJobStarted); err != nil {
+			return err
+		}
+		jm.runJobLocked(j, requestor, nil)
+		return nil
+	}
+	if err := jm.emitEvent(j.ID, EventJobQueued); err != nil {
+		return err
+	}
+	jm.enqueueLocked(j, requestor)
+	log.Infof("Job %d of '%s' queued at position %d", j.ID, requestor, jm.queuePositionLocked(j.ID))
+	return nil
+}
+
+// enqueueLocked adds a job to the queue, keeping it sorted
+func (jm *JobManager) enqueueLocked(j *job.Job, requestor string) {
+	jm.queue.seq++
+	jm.queue.jobs = append(jm.queue.jobs, &queuedJob{job: j, requestor: requestor, seq: jm.queue.seq})
+	sort.SliceStable(jm.queue.jobs, func(i, k int) bool {
+		a, b := jm.queue.jobs[i], jm.queue.jobs[k]
+		if a.job.Priority.Rank() != b.job.Priority.Rank() {
+			return a.job.Priority.Rank() > b.job.Priority.Rank()
+		}
+		return a.seq < b.seq
+	})
+	jm.jobsMu.Lock()
+	jm.jobs[j.ID] = j
+	jm.jobsMu.Unlock()
+}
+
+// dispatchLocked starts the queued jobs which can run. Jobs whose requestor
+// reached its quota do not hold back the jobs of other requestors.
+func (jm *JobManager) dispatchLocked() {
+	var waiting []*queuedJob
+	for _, qj := range jm.queue.jobs {
+		if !jm.canRunLocked(qj.requestor) {
+			waiting = append(waiting, qj)
+			continue
+		}
+		log.Infof("Starting queued job %d of '%s'", qj.job.ID, qj.requestor)
+		if err := jm.emitEvent(qj.job.ID, EventJobStarted); err != nil {
+			log.Errorf("Could not start queued job %d: %v", qj.job.ID, err)
+			continue
+		}
+		jm.runJobLocked(qj.job, qj.requestor, nil)
+	}
+	jm.queue.jobs = waiting
+}
+
+// jobTerminated releases the slot of a job which does not run anymore, and
+// starts the queued jobs which can take it.
+func (jm *JobManager) jobTerminated(jobID types.JobID) {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	requestor, ok := jm.queue.running[jobID]
+	if !ok {
+		return
+	}
+	delete(jm.queue.running, jobID)
+	jm.queue.runningPerRequestor[requestor]--
+	if jm.queue.runningPerRequestor[requestor] == 0 {
+		delete(jm.queue.runningPerRequestor, requestor)
+	}
+	jm.dispatchLocked()
+}
+
+// cancelQueuedJob cancels a job if it is queued, and returns whether it was.
+// Queued jobs are cancelled right away, as they did not start.
+func (jm *JobManager) cancelQueuedJob(jobID types.JobID) bool {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	for i, qj := range jm.queue.jobs {
+		if qj.job.ID != jobID {
+			continue
+		}
+		jm.queue.jobs = append(jm.queue.jobs[:i], jm.queue.jobs[i+1:]...)
+		jm.jobsMu.Lock()
+		delete(jm.jobs, jobID)
+		jm.jobsMu.Unlock()
+		log.Infof("Queued job %d cancelled", jobID)
+		_ = jm.emitEvent(jobID, EventJobCancelled)
+		storage.GetEventBroker().Close(jobID)
+		return true
+	}
+	return false
+}
+
+// queuePosition returns the position of a job in the queue, starting from 1,
+// or 0 if the job is not queued.
+func (jm *JobManager) queuePosition(jobID types.JobID) uint {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	return jm.queuePositionLocked(jobID)
+}
+
+func (jm *JobManager) queuePositionLocked(jobID types.JobID) uint {
+	for i, qj := range jm.queue.jobs {
+		if qj.job.ID == jobID {
+			return uint(i + 1)
+		}
+	}
+	return 0
+}
+
+// stopQueue prevents the queued jobs from starting, once the JobManager is
+// shutting down. They stay queued in the storage, and are queued again by
+// the next instance of ConTest.
+func (jm *JobManager) stopQueue() {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	jm.queue.stopped = true
+}
+
+// requeueJobs queues again the jobs which were queued by a previous instance
+// of ConTest, in submission order, and starts the ones which can run.
+func (jm *JobManager) requeueJobs() error {
+	summaries, err := jm.jobLister.List(&job.ListQuery{States: []event.Name{EventJobQueued}})
+	if err != nil {
+		return err
+	}
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	// jobs are listed from the most recent
+	for i := len(summaries) - 1; i >= 0; i-- {
+		jobID := summaries[i].JobID
+		request, err := jm.jobRequestManager.Fetch(jobID)
+		if err != nil {
+			return err
+		}
+		j, err := NewJob(jm.pluginRegistry, request.JobDescriptor)
+		if err != nil {
+			log.Errorf("Could not queue job %d again: %v", jobID, err)
+			_ = jm.emitErrEvent(jobID, EventJobFailed, fmt.Errorf("could not rebuild job: %v", err))
+			continue
+		}
+		j.ID = jobID
+		jm.enqueueLocked(j, request.Requestor)
+	}
+	if len(summaries) > 0 {
+		log.Infof("Queued %d job(s) again", len(summaries))
+	}
+	jm.dispatchLocked()
+	return nil
+}
@@ -6,13 +6,185 @@
 package jobmanager
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/test"
+	"github.com/facebookincubator/contest/pkg/types"
 )
 
+// targetKey identifies a target across jobs. Only name and ID are used, as
+// these are the only fields that are guaranteed to be persisted with test
+// events by every storage engine.
+type targetKey struct {
+	Name string
+	ID   string
+}
+
+// failedTargetsManager wraps the TargetManager of a test being retried, so
+// that only the targets which failed in the original job are handed over to
+// the TestRunner. Targets that are acquired but did not fail are unlocked
+// right away.
+type failedTargetsManager struct {
+	target.TargetManager
+	failed map[targetKey]bool
+}
+
+// Acquire acquires the targets via the wrapped TargetManager and filters out
+// the ones that did not fail in the original job.
+func (tm *failedTargetsManager) Acquire(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl target.Locker) ([]*target.Target, error) {
+	targets, err := tm.TargetManager.Acquire(jobID, cancel, parameters, tl)
+	if err != nil {
+		return nil, err
+	}
+	var selected, discarded []*target.Target
+	acquired := make(map[targetKey]bool)
+	for _, t := range targets {
+		key := targetKey{Name: t.Name, ID: t.ID}
+		if tm.failed[key] {
+			selected = append(selected, t)
+			acquired[key] = true
+		} else {
+			discarded = append(discarded, t)
+		}
+	}
+	var missing []string
+	for key := range tm.failed {
+		if !acquired[key] {
+			missing = append(missing, fmt.Sprintf("%s (ID: %s)", key.Name, key.ID))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		log.Warningf("%d failed target(s) out of %d could not be acquired for retry, running on the remaining ones. Missing targets: %s",
+			len(missing), len(tm.failed), strings.Join(missing, ", "))
+	}
+	if len(discarded) > 0 {
+		if err := tl.Unlock(jobID, discarded); err != nil {
+			log.Warningf("Failed to unlock %d target(s) not selected for retry: %v", len(discarded), err)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("none of the %d failed target(s) could be acquired", len(tm.failed))
+	}
+	return selected, nil
+}
+
+// failedTargets returns the targets which failed in a job, grouped by test
+// name.
+func (jm *JobManager) failedTargets(jobID types.JobID) (map[string]map[targetKey]bool, error) {
+	events, err := jm.testEvManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(target.EventTargetErr),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch failed targets for job %d: %v", jobID, err)
+	}
+	failed := make(map[string]map[targetKey]bool)
+	for _, ev := range events {
+		if ev.Header == nil || ev.Data == nil || ev.Data.Target == nil {
+			continue
+		}
+		if _, ok := failed[ev.Header.TestName]; !ok {
+			failed[ev.Header.TestName] = make(map[targetKey]bool)
+		}
+		failed[ev.Header.TestName][targetKey{Name: ev.Data.Target.Name, ID: ev.Data.Target.ID}] = true
+	}
+	return failed, nil
+}
+
+// restrictToFailedTargets modifies the tests of a job so that only the tests
+// with failed targets are run again, and only on those targets.
+func (jm *JobManager) restrictToFailedTargets(j *job.Job, originalJobID types.JobID) error {
+	// Failures are associated to tests by name, as test events do not carry
+	// the index of the test within the job.
+	names := make(map[string]bool)
+	for _, t := range j.Tests {
+		if names[t.Name] {
+			return fmt.Errorf("cannot tell failed targets apart, job has multiple tests named '%s'", t.Name)
+		}
+		names[t.Name] = true
+	}
+	failed, err := jm.failedTargets(originalJobID)
+	if err != nil {
+		return err
+	}
+	tests := make([]*test.Test, 0, len(j.Tests))
+	for _, t := range j.Tests {
+		failedInTest, ok := failed[t.Name]
+		if !ok {
+			log.Infof("Test '%s' of job %d has no failed targets, skipping it", t.Name, originalJobID)
+			continue
+		}
+		bundle := *t.TargetManagerBundle
+		bundle.TargetManager = &failedTargetsManager{
+			TargetManager: bundle.TargetManager,
+			failed:        failedInTest,
+		}
+		t.TargetManagerBundle = &bundle
+		tests = append(tests, t)
+	}
+	if len(tests) == 0 {
+		return api.NewError(api.ErrorKindConflict, fmt.Errorf("job %d has no failed targets to retry", originalJobID))
+	}
+	j.Tests = tests
+	return nil
+}
+
 func (jm *JobManager) retry(ev *api.Event) *api.EventResponse {
-	return &api.EventResponse{
-		Requestor: ev.Msg.Requestor(),
-		Err:       fmt.Errorf("Not implemented"),
+	msg := ev.Msg.(api.EventRetryMsg)
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			JobID:     msg.JobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not retry job %d: %w", msg.JobID, err),
+		}
+	}
+
+	originalRequest, err := jm.fetchJobRequest(msg.JobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if err := jm.authorizeJob(ev.Msg.Requestor(), originalRequest); err != nil {
+		return errResponse(err)
+	}
+	state, err := jm.lastJobState(msg.JobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if jobRunning(state) {
+		return errResponse(api.NewError(api.ErrorKindConflict, errors.New("job is still running")))
+	}
+
+	j, err := NewJob(jm.pluginRegistry, originalRequest.JobDescriptor)
+	if err != nil {
+		return errResponse(api.NewError(api.ErrorKindInvalid, err))
+	}
+	if msg.FailedTargetsOnly {
+		if err := jm.restrictToFailedTargets(j, msg.JobID); err != nil {
+			return errResponse(err)
+		}
+	}
+	request := job.Request{
+		JobName:       j.Name,
+		Requestor:     string(ev.Msg.Requestor()),
+		RequestTime:   time.Now(),
+		JobDescriptor: originalRequest.JobDescriptor,
+		Tags:          j.Tags,
+		RetryOf:       msg.JobID,
+	}
+	if err := jm.launch(j, &request); err != nil {
+		return errResponse(err)
 	}
+	log.Infof("Job %d started as a retry of job %d", j.ID, msg.JobID)
+	return jm.startedResponse(j, ev.Msg.Requestor())
 }
@@ -0,0 +1,304 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// scheduleCheckInterval is the interval at which the JobManager checks
+// whether schedules are due.
+var scheduleCheckInterval = time.Second
+
+// EventScheduleFired indicates that a job was started by a schedule
+var EventScheduleFired = event.Name("ScheduleFired")
+
+// schedulePayload represents the payload carried by a ScheduleFired event
+type schedulePayload struct {
+	ScheduleID types.ScheduleID
+}
+
+// loadSchedules loads the schedules persisted by a previous instance of
+// ConTest. Firings missed in the meantime are skipped.
+func (jm *JobManager) loadSchedules() error {
+	schedules, err := jm.scheduleManager.FetchAll()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, s := range schedules {
+		if err := jm.updateNextFireTime(s, now); err != nil {
+			log.Errorf("Not loading schedule %d: %v", s.ID, err)
+			continue
+		}
+		jm.schedules[s.ID] = s
+	}
+	log.Infof("Loaded %d schedule(s)", len(jm.schedules))
+	return nil
+}
+
+// updateNextFireTime computes when a schedule fires next, after t
+func (jm *JobManager) updateNextFireTime(s *job.Schedule, t time.Time) error {
+	if s.Paused {
+		s.NextFireTime = time.Time{}
+		return nil
+	}
+	next, err := s.Next(t)
+	if err != nil {
+		return err
+	}
+	s.NextFireTime = next
+	return nil
+}
+
+// sortedSchedules returns the schedules sorted by ID
+func (jm *JobManager) sortedSchedules() []*job.Schedule {
+	schedules := make([]*job.Schedule, 0, len(jm.schedules))
+	for _, s := range jm.schedules {
+		schedules = append(schedules, s)
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+	return schedules
+}
+
+// fireSchedules starts the jobs of the schedules which are due, and of the
+// ones with a queued firing whose previous job terminated.
+func (jm *JobManager) fireSchedules(now time.Time) {
+	for _, s := range jm.sortedSchedules() {
+		if s.Paused {
+			continue
+		}
+		due := !s.NextFireTime.IsZero() && !now.Before(s.NextFireTime)
+		if !due && !s.Queued {
+			continue
+		}
+		if due {
+			if err := jm.updateNextFireTime(s, now); err != nil {
+				log.Errorf("Could not compute the next firing of schedule %d: %v", s.ID, err)
+			}
+		}
+		jm.fireSchedule(s, now)
+	}
+}
+
+// fireSchedule starts a new job for a schedule, unless its previous job is
+// still running, in which case the overlap policy of the schedule applies.
+func (jm *JobManager) fireSchedule(s *job.Schedule, now time.Time) {
+	running, err := jm.scheduledJobRunning(s)
+	if err != nil {
+		log.Errorf("Not firing schedule %d: %v", s.ID, err)
+		return
+	}
+	if running {
+		switch s.OverlapPolicy {
+		case job.OverlapPolicyQueue:
+			if !s.Queued {
+				log.Infof("Job %d of schedule %d is still running, queueing the next one", s.LastJobID, s.ID)
+				s.Queued = true
+			}
+			return
+		case job.OverlapPolicyCancelPrevious:
+			log.Infof("Job %d of schedule %d is still running, cancelling it", s.LastJobID, s.ID)
+			if jm.cancelQueuedJob(s.LastJobID) {
+				break
+			}
+			if err := jm.CancelJob(s.LastJobID); err != nil {
+				log.Warningf("Could not cancel job %d of schedule %d: %v", s.LastJobID, s.ID, err)
+			} else {
+				_ = jm.emitEvent(s.LastJobID, EventJobCancelling)
+			}
+		default:
+			log.Infof("Job %d of schedule %d is still running, skipping this firing", s.LastJobID, s.ID)
+			return
+		}
+	}
+	s.Queued = false
+
+	j, err := NewJob(jm.pluginRegistry, s.JobDescriptor)
+	if err != nil {
+		log.Errorf("Could not create the job of schedule %d: %v", s.ID, err)
+		return
+	}
+	request := job.Request{
+		JobName:       j.Name,
+		Requestor:     s.Requestor,
+		RequestTime:   now,
+		JobDescriptor: s.JobDescriptor,
+		Tags:          j.Tags,
+	}
+	if err := jm.launch(j, &request); err != nil {
+		log.Errorf("Could not start the job of schedule %d: %v", s.ID, err)
+		return
+	}
+	log.Infof("Schedule %d started job %d", s.ID, j.ID)
+	s.LastFireTime = now
+	s.LastJobID = j.ID
+	if _, err := jm.scheduleManager.Emit(s); err != nil {
+		log.Warningf("Could not persist the last firing of schedule %d: %v", s.ID, err)
+	}
+	jm.emitScheduleFired(j.ID, s.ID)
+}
+
+// scheduledJobRunning returns whether the job last started by a schedule has
+// not terminated yet
+func (jm *JobManager) scheduledJobRunning(s *job.Schedule) (bool, error) {
+	if s.LastJobID == 0 {
+		return false, nil
+	}
+	state, err := jm.lastJobState(s.LastJobID)
+	if err != nil {
+		return false, err
+	}
+	return jobRunning(state), nil
+}
+
+func (jm *JobManager) emitScheduleFired(jobID types.JobID, scheduleID types.ScheduleID) {
+	payload, err := json.Marshal(schedulePayload{ScheduleID: scheduleID})
+	if err != nil {
+		log.Warningf("Could not serialize payload for event %s: %v", EventScheduleFired, err)
+		return
+	}
+	rawPayload := json.RawMessage(payload)
+	ev := frameworkevent.Event{
+		JobID:     jobID,
+		EventName: EventScheduleFired,
+		Payload:   &rawPayload,
+		EmitTime:  time.Now(),
+	}
+	if err := jm.frameworkEvManager.Emit(ev); err != nil {
+		log.Warningf("Could not emit event %s for job %d: %v", EventScheduleFired, jobID, err)
+	}
+}
+
+// scheduleResponse returns the response to an API event about a schedule.
+// The schedule is copied, as it is modified by the JobManager afterwards.
+func scheduleResponse(requestor api.EventRequestor, schedules ...*job.Schedule) *api.EventResponse {
+	resp := api.EventResponse{Requestor: requestor}
+	for _, s := range schedules {
+		s := *s
+		resp.Schedules = append(resp.Schedules, &s)
+	}
+	return &resp
+}
+
+// schedule looks up a schedule by ID, and checks whether the requestor can
+// operate on it, i.e. on the jobs it starts.
+func (jm *JobManager) schedule(requestor api.EventRequestor, scheduleID types.ScheduleID) (*job.Schedule, error) {
+	s, ok := jm.schedules[scheduleID]
+	if !ok {
+		return nil, api.NewError(api.ErrorKindNotFound, fmt.Errorf("unknown schedule ID: %d", scheduleID))
+	}
+	if jm.authorizer != nil {
+		if err := jm.authorizer.AuthorizeJob(requestor, api.EventRequestor(s.Requestor)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (jm *JobManager) createSchedule(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventCreateScheduleMsg)
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not create schedule: %w", err),
+		}
+	}
+	if err := msg.Schedule.Validate(); err != nil {
+		return errResponse(api.NewError(api.ErrorKindInvalid, err))
+	}
+	j, err := NewJob(jm.pluginRegistry, msg.Schedule.JobDescriptor)
+	if err != nil {
+		return errResponse(api.NewError(api.ErrorKindInvalid, err))
+	}
+	s := job.Schedule{
+		JobName:       j.Name,
+		Requestor:     string(ev.Msg.Requestor()),
+		CreateTime:    time.Now(),
+		JobDescriptor: msg.Schedule.JobDescriptor,
+		Cron:          msg.Schedule.Cron,
+		Interval:      msg.Schedule.Interval,
+		OverlapPolicy: msg.Schedule.OverlapPolicy,
+	}
+	if s.OverlapPolicy == "" {
+		s.OverlapPolicy = job.OverlapPolicySkip
+	}
+	if err := jm.updateNextFireTime(&s, s.CreateTime); err != nil {
+		return errResponse(api.NewError(api.ErrorKindInvalid, err))
+	}
+	if s.ID, err = jm.scheduleManager.Emit(&s); err != nil {
+		return errResponse(err)
+	}
+	jm.schedules[s.ID] = &s
+	log.Infof("Schedule %d created by '%s', next firing at %s", s.ID, s.Requestor, s.NextFireTime)
+	return scheduleResponse(ev.Msg.Requestor(), &s)
+}
+
+func (jm *JobManager) listSchedules(ev *api.Event) *api.EventResponse {
+	return scheduleResponse(ev.Msg.Requestor(), jm.sortedSchedules()...)
+}
+
+// pauseSchedule pauses or resumes a schedule. Pausing a schedule drops its
+// queued firing, if any, but does not affect its running job.
+func (jm *JobManager) pauseSchedule(ev *api.Event, scheduleID types.ScheduleID, pause bool) *api.EventResponse {
+	action := "pause"
+	if !pause {
+		action = "resume"
+	}
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not %s schedule %d: %w", action, scheduleID, err),
+		}
+	}
+	s, err := jm.schedule(ev.Msg.Requestor(), scheduleID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if s.Paused == pause {
+		return errResponse(api.NewError(api.ErrorKindConflict, fmt.Errorf("schedule is already %sd", action)))
+	}
+	updated := *s
+	updated.Paused = pause
+	updated.Queued = false
+	if err := jm.updateNextFireTime(&updated, time.Now()); err != nil {
+		return errResponse(err)
+	}
+	if _, err := jm.scheduleManager.Emit(&updated); err != nil {
+		return errResponse(err)
+	}
+	*s = updated
+	log.Infof("Schedule %d %sd by '%s'", scheduleID, action, ev.Msg.Requestor())
+	return scheduleResponse(ev.Msg.Requestor(), s)
+}
+
+// deleteSchedule deletes a schedule. Its running job, if any, is not
+// affected.
+func (jm *JobManager) deleteSchedule(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventDeleteScheduleMsg)
+	s, err := jm.schedule(ev.Msg.Requestor(), msg.ScheduleID)
+	if err == nil {
+		err = jm.scheduleManager.Delete(msg.ScheduleID)
+	}
+	if err != nil {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not delete schedule %d: %w", msg.ScheduleID, err),
+		}
+	}
+	delete(jm.schedules, msg.ScheduleID)
+	log.Infof("Schedule %d deleted by '%s'", msg.ScheduleID, ev.Msg.Requestor())
+	return scheduleResponse(ev.Msg.Requestor(), s)
+}
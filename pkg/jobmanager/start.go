@@ -6,19 +6,25 @@
 package jobmanager
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/api"
 	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/runner"
+	"github.com/facebookincubator/contest/pkg/storage"
 )
 
 func (jm *JobManager) start(ev *api.Event) *api.EventResponse {
 	msg := ev.Msg.(api.EventStartMsg)
 	j, err := NewJob(jm.pluginRegistry, msg.JobDescriptor)
 	if err != nil {
-		return &api.EventResponse{Err: err}
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       api.NewError(api.ErrorKindInvalid, err),
+		}
 	}
 	// The job descriptor has been validated correctly, now use the JobRequestEmitter
 	// interface to obtain a JobRequest object with a valid id
@@ -27,32 +33,96 @@ func (jm *JobManager) start(ev *api.Event) *api.EventResponse {
 		Requestor:     string(ev.Msg.Requestor()),
 		RequestTime:   time.Now(),
 		JobDescriptor: msg.JobDescriptor,
+		Tags:          j.Tags,
 	}
-	jobID, err := jm.jobRequestManager.Emit(&request)
-	if err != nil {
-		return &api.EventResponse{
-			Requestor: ev.Msg.Requestor(),
-			Err:       fmt.Errorf("could not create job request: %v", err)}
-	}
-	j.ID = jobID
-	if err := jm.emitEvent(j.ID, EventJobStarted); err != nil {
+	if err := jm.launch(j, &request); err != nil {
 		return &api.EventResponse{
 			Requestor: ev.Msg.Requestor(),
 			Err:       err,
 		}
 	}
+	return jm.startedResponse(j, ev.Msg.Requestor())
+}
+
+// startedResponse returns the response to an API event which started a job,
+// or queued it.
+func (jm *JobManager) startedResponse(j *job.Job, requestor api.EventRequestor) *api.EventResponse {
+	status := job.Status{
+		Name:      j.Name,
+		State:     string(EventJobStarted),
+		StartTime: time.Now(),
+	}
+	if position := jm.queuePosition(j.ID); position > 0 {
+		status.State = string(EventJobQueued)
+		status.StartTime = time.Time{}
+		status.QueuePosition = position
+	}
+	return &api.EventResponse{
+		JobID:     j.ID,
+		Requestor: requestor,
+		Err:       nil,
+		Status:    &status,
+	}
+}
+
+// launch persists the job request, assigns the resulting job ID to the job
+// and runs the job asynchronously, unless it has to be queued.
+func (jm *JobManager) launch(j *job.Job, request *job.Request) error {
+	jobID, err := jm.jobRequestManager.Emit(request)
+	if err != nil {
+		return fmt.Errorf("could not create job request: %v", err)
+	}
+	j.ID = jobID
+	return jm.admit(j, request.Requestor)
+}
+
+// runJob runs a job asynchronously, or resumes it if a pause state is given.
+// The job counts as running for the QueuePolicy, but it is not subject to it.
+func (jm *JobManager) runJob(j *job.Job, requestor string, pauseState *runner.JobPauseState) {
+	jm.queueMu.Lock()
+	defer jm.queueMu.Unlock()
+	jm.runJobLocked(j, requestor, pauseState)
+}
+
+// runJobLocked runs a job asynchronously, or resumes it if a pause state is
+// given, and emits the events that track its final state. If the job is
+// paused, its state is persisted so that it can be resumed later.
+func (jm *JobManager) runJobLocked(j *job.Job, requestor string, pauseState *runner.JobPauseState) {
+	jobID := j.ID
+	jm.jobsMu.Lock()
+	jm.jobs[jobID] = j
+	jm.jobsMu.Unlock()
+	jm.queue.running[jobID] = requestor
+	jm.queue.runningPerRequestor[requestor]++
 
 	jm.jobsWg.Add(1)
 	go func() {
 		defer jm.jobsWg.Done()
-
-		jm.jobsMu.Lock()
-		jm.jobs[j.ID] = j
-		jm.jobsMu.Unlock()
+		// the slot of the job is released once its final state is emitted
+		defer jm.jobTerminated(jobID)
+		// no more events are streamed for the job once it has terminated
+		defer storage.GetEventBroker().Close(jobID)
 
 		start := time.Now()
-		runReports, finalReports, err := jm.jobRunner.Run(j)
+		var (
+			finalReports []*job.Report
+			err          error
+		)
+		if pauseState != nil {
+			_, finalReports, err = jm.jobRunner.Resume(j, pauseState)
+		} else {
+			_, finalReports, err = jm.jobRunner.Run(j)
+		}
 		duration := time.Since(start)
+		// If the Job was paused, persist its state so that it can be resumed
+		// once ConTest starts again
+		var errPaused *runner.ErrJobPaused
+		if errors.As(err, &errPaused) {
+			jm.pauseJob(j, errPaused.State)
+			return
+		}
+		// the test events of the job are complete, evaluate its targets
+		jm.updateQuarantine(jobID)
 		// If the Job was cancelled, the error returned by JobRunner indicates whether
 		// the cancellatioon has been successful or failed
 		if j.IsCancelled() {
@@ -86,9 +156,10 @@ func (jm *JobManager) start(ev *api.Event) *api.EventResponse {
 			_ = jm.emitEvent(jobID, eventToEmit)
 		}
 		if err == nil {
+			// the run reports were stored by the JobRunner as each run
+			// completed
 			jobReport := job.JobReport{
 				JobID:        j.ID,
-				RunReports:   runReports,
 				FinalReports: finalReports,
 			}
 			err := jm.jobReportManager.Emit(&jobReport)
@@ -97,15 +168,4 @@ func (jm *JobManager) start(ev *api.Event) *api.EventResponse {
 			}
 		}
 	}()
-
-	return &api.EventResponse{
-		JobID:     j.ID,
-		Requestor: ev.Msg.Requestor(),
-		Err:       nil,
-		Status: &job.Status{
-			Name:      j.Name,
-			State:     string(EventJobStarted),
-			StartTime: time.Now(),
-		},
-	}
 }
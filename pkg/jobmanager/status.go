@@ -21,12 +21,26 @@ import (
 
 // JobStateEvents gather all event names which track the state of a job
 var JobStateEvents = []event.Name{
+	EventJobQueued,
 	EventJobStarted,
 	EventJobCompleted,
 	EventJobFailed,
 	EventJobCancelling,
 	EventJobCancelled,
 	EventJobCancellationFailed,
+	EventJobPaused,
+	EventJobResumed,
+	EventJobWaitingForTargets,
+	EventJobTargetsAcquired,
+}
+
+// JobFinalStates gather all event names which track a state after which a job
+// does not run anymore
+var JobFinalStates = []event.Name{
+	EventJobCompleted,
+	EventJobFailed,
+	EventJobCancelled,
+	EventJobCancellationFailed,
 }
 
 // TargetRoutingEvents gather all event names which track the flow of targets
@@ -38,6 +52,30 @@ var TargetRoutingEvents = []event.Name{
 	target.EventTargetInErr,
 }
 
+// lastJobState returns the name of the last state event emitted for a job. An
+// empty name is returned if no state event was ever emitted.
+func (jm *JobManager) lastJobState(jobID types.JobID) (event.Name, error) {
+	jobEvents, err := jm.frameworkEvManager.Fetch(
+		[]frameworkevent.QueryField{
+			frameworkevent.QueryJobID(jobID),
+			frameworkevent.QueryEventNames(JobStateEvents),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch events associated to job state: %v", err)
+	}
+	if len(jobEvents) == 0 {
+		return "", nil
+	}
+	return jobEvents[len(jobEvents)-1].EventName, nil
+}
+
+// jobRunning returns whether a job in the given state, as returned by
+// lastJobState, has not terminated yet. Queued jobs are running too.
+func jobRunning(state event.Name) bool {
+	return state != "" && !eventNameIn(state, JobFinalStates)
+}
+
 // buildTargetStatus populates a TestStepStatus object with TestStepStatus information
 func (jm *JobManager) buildTargetStatus(jobID types.JobID, currentTestStepStatus *job.TestStepStatus) error {
 
@@ -61,7 +99,7 @@ func (jm *JobManager) buildTargetStatus(jobID types.JobID, currentTestStepStatus
 		// TargetStatus associated yet, append it
 		var currentTargetStatus *job.TargetStatus
 		for index, candidateTargetStatus := range currentTestStepStatus.TargetStatus {
-			if *candidateTargetStatus.Target == *testEvent.Data.Target {
+			if candidateTargetStatus.Target.Key() == testEvent.Data.Target.Key() {
 				currentTargetStatus = &currentTestStepStatus.TargetStatus[index]
 				break
 			}
@@ -162,6 +200,14 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 	msg := ev.Msg.(api.EventStatusMsg)
 	jobID := msg.JobID
 
+	if _, err := jm.fetchJobRequest(jobID); err != nil {
+		return &api.EventResponse{
+			JobID:     jobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       err,
+		}
+	}
+
 	jobReport, err := jm.jobReportManager.Fetch(jobID)
 	if err != nil {
 		return &api.EventResponse{
@@ -189,7 +235,9 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 			Err:       fmt.Errorf("could not fetch events associated to job state: %v", err),
 		}
 	}
+	jm.jobsMu.Lock()
 	currentJob, ok := jm.jobs[jobID]
+	jm.jobsMu.Unlock()
 	// BUG If we return an error via the API, the HTTP listener simply returns a nil response?
 	if !ok {
 		return &api.EventResponse{
@@ -201,6 +249,7 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 
 	// Lookup job starting time and job termination time (i.e. when the job report was built)
 	var (
+		state      event.Name
 		startTime  time.Time
 		reportTime time.Time
 	)
@@ -208,14 +257,19 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 		if ev.EventName == EventJobStarted {
 			startTime = ev.EmitTime
 		}
+		state = ev.EventName
 	}
 	jobStatus := job.Status{
 		Name:       currentJob.Name,
+		State:      string(state),
 		StartTime:  startTime,
 		EndTime:    reportTime,
 		JobReport:  jobReport,
 		TestStatus: make([]job.TestStatus, 0, len(currentJob.Tests)),
 	}
+	if state == EventJobQueued {
+		jobStatus.QueuePosition = jm.queuePosition(jobID)
+	}
 
 	if err := jm.buildTestStatus(jobID, currentJob.Tests, &jobStatus); err != nil {
 		return &api.EventResponse{
@@ -16,15 +16,47 @@ import (
 func (jm *JobManager) stop(ev *api.Event) *api.EventResponse {
 	msg := ev.Msg.(api.EventStopMsg)
 	jobID := msg.JobID
+	errResponse := func(err error) *api.EventResponse {
+		log.Errorf("Cannot stop job: %v", err)
+		return &api.EventResponse{
+			JobID:     jobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       fmt.Errorf("could not stop job: %w", err),
+		}
+	}
+	request, err := jm.fetchJobRequest(jobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if err := jm.authorizeJob(ev.Msg.Requestor(), request); err != nil {
+		return errResponse(err)
+	}
+	state, err := jm.lastJobState(jobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	if state == EventJobCancelling || eventNameIn(state, JobFinalStates) {
+		return errResponse(api.NewError(api.ErrorKindConflict, fmt.Errorf("job %d is not running, its state is '%s'", jobID, state)))
+	}
+	if jm.cancelQueuedJob(jobID) {
+		return &api.EventResponse{
+			JobID:     jobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       nil,
+			Status: &job.Status{
+				Name:  request.JobName,
+				State: string(EventJobCancelled),
+			},
+		}
+	}
 	// CancelJob is asynchronous, it closes the Job's cancellation signal which
 	// is propagated all the way down to the TestRunner. TestRunner  will wait
 	// TestRunnerShutdownTimeout before flagging the test as timed out. JobRunner
-	// will attempt to call Release on TargetManager and will wait up to
-	// TargetManagerTimeout for Release to return.
-	err := jm.CancelJob(jobID)
-	if err != nil {
-		log.Errorf("Cannot stop job: %v", err)
-		return &api.EventResponse{Err: fmt.Errorf("could not stop job: %v", err)}
+	// then calls Release on TargetManager and waits up to
+	// TargetManagerReleaseTimeout for Release to return.
+	if err := jm.CancelJob(jobID); err != nil {
+		// the job exists, but it is not run by this instance of ConTest
+		return errResponse(api.NewError(api.ErrorKindConflict, err))
 	}
 	_ = jm.emitEvent(jobID, EventJobCancelling)
 	return &api.EventResponse{
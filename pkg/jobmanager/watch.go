@@ -0,0 +1,50 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/storage"
+)
+
+func (jm *JobManager) watch(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventWatchMsg)
+	errResponse := func(err error) *api.EventResponse {
+		return &api.EventResponse{
+			JobID:     msg.JobID,
+			Requestor: ev.Msg.Requestor(),
+			Err:       err,
+		}
+	}
+	if _, err := jm.fetchJobRequest(msg.JobID); err != nil {
+		return errResponse(err)
+	}
+	state, err := jm.lastJobState(msg.JobID)
+	if err != nil {
+		return errResponse(err)
+	}
+	jm.jobsMu.Lock()
+	_, running := jm.jobs[msg.JobID]
+	jm.jobsMu.Unlock()
+	// Stopped jobs are not tracked anymore while they are being cancelled,
+	// but they still emit events.
+	running = running || state == EventJobCancelling
+	broker := storage.GetEventBroker()
+	if !running || eventNameIn(state, JobFinalStates) {
+		// No more events will be published for the job, e.g. because it was
+		// run by a previous instance of ConTest.
+		broker.Close(msg.JobID)
+	}
+	sub, err := broker.Subscribe(msg.JobID, msg.AfterID)
+	if err != nil {
+		return errResponse(err)
+	}
+	return &api.EventResponse{
+		JobID:        msg.JobID,
+		Requestor:    ev.Msg.Requestor(),
+		Subscription: sub,
+	}
+}
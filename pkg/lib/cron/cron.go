@@ -0,0 +1,210 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package cron implements cron expressions, which describe recurring points in
+// time. An expression has five space-separated fields: minute (0-59), hour
+// (0-23), day of month (1-31), month (1-12) and day of week (0-6, where 0 and
+// 7 are Sunday). Each field is a comma-separated list of:
+// * "*", any value
+// * a single value, e.g. "5"
+// * a range, e.g. "1-5"
+// each optionally followed by a step, e.g. "*/15" or "0-30/10". Months and
+// days of week can also be given by their three-letter English names, e.g.
+// "jan" or "mon-fri". As in the classic cron, when both the day of month and
+// the day of week are restricted, a day matches if either of them does.
+// The shortcuts @yearly (or @annually), @monthly, @weekly, @daily (or
+// @midnight) and @hourly are accepted too.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bounds describes the values allowed in a field of an expression
+type bounds struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minutes = bounds{name: "minute", min: 0, max: 59}
+	hours   = bounds{name: "hour", min: 0, max: 23}
+	days    = bounds{name: "day of month", min: 1, max: 31}
+	months  = bounds{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	weekdays = bounds{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxLookahead bounds the search for the next matching time, so that
+// expressions which never match, e.g. "0 0 30 2 *", do not loop forever.
+const maxLookahead = 5
+
+// Expression is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type Expression struct {
+	expr                         string
+	minute, hour, day, month, wd uint64
+	// anyDay and anyWeekday record whether the day fields are unrestricted,
+	// which decides how they are combined.
+	anyDay, anyWeekday bool
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Expression, error) {
+	spec := strings.TrimSpace(expr)
+	if shortcut, ok := shortcuts[strings.ToLower(spec)]; ok {
+		spec = shortcut
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields, got %d", expr, len(fields))
+	}
+	e := Expression{expr: expr}
+	var err error
+	for idx, f := range []struct {
+		bounds
+		set *uint64
+	}{
+		{minutes, &e.minute},
+		{hours, &e.hour},
+		{days, &e.day},
+		{months, &e.month},
+		{weekdays, &e.wd},
+	} {
+		if *f.set, err = parseField(fields[idx], f.bounds); err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %v", expr, err)
+		}
+	}
+	// 7 is Sunday too
+	if e.wd&(1<<7) != 0 {
+		e.wd = e.wd&^(1<<7) | 1
+	}
+	e.anyDay = fields[2] == "*"
+	e.anyWeekday = fields[4] == "*"
+	return &e, nil
+}
+
+// parseField parses a field of an expression into the bit set of the values
+// it matches.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, uint(1)
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			rng = item[:idx]
+			s, err := strconv.ParseUint(item[idx+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step in %s field '%s'", b.name, item)
+			}
+			step = uint(s)
+		}
+		var first, last uint
+		switch {
+		case rng == "*":
+			first, last = b.min, b.max
+		case strings.Contains(rng, "-"):
+			parts := strings.SplitN(rng, "-", 2)
+			var err error
+			if first, err = parseValue(parts[0], b); err != nil {
+				return 0, err
+			}
+			if last, err = parseValue(parts[1], b); err != nil {
+				return 0, err
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range in %s field '%s'", b.name, item)
+			}
+		default:
+			value, err := parseValue(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			first, last = value, value
+			if step > 1 {
+				// "5/15" stands for "5-max/15"
+				last = b.max
+			}
+		}
+		for value := first; value <= last; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number or a name within the bounds of a field
+func parseValue(s string, b bounds) (uint, error) {
+	if value, ok := b.names[strings.ToLower(s)]; ok {
+		return value, nil
+	}
+	value, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || uint(value) < b.min || uint(value) > b.max {
+		return 0, fmt.Errorf("invalid %s '%s', must be between %d and %d", b.name, s, b.min, b.max)
+	}
+	return uint(value), nil
+}
+
+// matchesDay tells whether the day of t matches the day fields
+func (e *Expression) matchesDay(t time.Time) bool {
+	day := e.day&(1<<uint(t.Day())) != 0
+	weekday := e.wd&(1<<uint(t.Weekday())) != 0
+	switch {
+	case e.anyDay && e.anyWeekday:
+		return true
+	case e.anyDay:
+		return weekday
+	case e.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// Next returns the first time matching the expression strictly after t, in the
+// location of t. It returns the zero time if nothing matches in the next five
+// years.
+func (e *Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(maxLookahead, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case e.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case e.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case e.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// String returns the expression as it was parsed
+func (e *Expression) String() string {
+	return e.expr
+}
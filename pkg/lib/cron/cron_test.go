@@ -0,0 +1,66 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@sometimes",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Friday, 2020-01-31 10:17
+	now := time.Date(2020, time.January, 31, 10, 17, 30, 0, time.UTC)
+	cases := map[string]time.Time{
+		"* * * * *":         time.Date(2020, time.January, 31, 10, 18, 0, 0, time.UTC),
+		"*/15 * * * *":      time.Date(2020, time.January, 31, 10, 30, 0, 0, time.UTC),
+		"17 * * * *":        time.Date(2020, time.January, 31, 11, 17, 0, 0, time.UTC),
+		"5/20 10 * * *":     time.Date(2020, time.January, 31, 10, 25, 0, 0, time.UTC),
+		"0 2 * * *":         time.Date(2020, time.February, 1, 2, 0, 0, 0, time.UTC),
+		"@daily":            time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		"@hourly":           time.Date(2020, time.January, 31, 11, 0, 0, 0, time.UTC),
+		"@monthly":          time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		"@yearly":           time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 * * mon-fri":   time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":         time.Date(2020, time.February, 2, 0, 0, 0, 0, time.UTC),
+		"0 0 29 feb *":      time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"30 8 1,15 * *":     time.Date(2020, time.February, 1, 8, 30, 0, 0, time.UTC),
+		"0 0 13 * fri":      time.Date(2020, time.February, 7, 0, 0, 0, 0, time.UTC),
+		"0 12 31 jan-mar *": time.Date(2020, time.January, 31, 12, 0, 0, 0, time.UTC),
+	}
+	for expr, want := range cases {
+		e, err := Parse(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, want, e.Next(now), expr)
+	}
+}
+
+func TestNextNeverMatches(t *testing.T) {
+	e, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, e.Next(time.Now()).IsZero())
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package selector implements label selector expressions, which match sets of
+// key/value attributes. An expression is a list of requirements joined by
+// "&&", all of which must be satisfied. The supported requirements are:
+// * Equal, "key=value" or "key==value"
+// * Not equal, "key!=value", also satisfied if the key is missing
+// * Set membership, "key in (value1,value2)"
+// * Set exclusion, "key notin (value1,value2)", also satisfied if the key is
+//   missing
+// * Existence, "key", and non-existence, "!key"
+// For example: "sku=T6 && rack in (A1,A2) && !quarantined".
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operator is the operator of a requirement
+type Operator string
+
+// requirement operators.
+const (
+	OpEqual        Operator = "="
+	OpNotEqual     Operator = "!="
+	OpIn           Operator = "in"
+	OpNotIn        Operator = "notin"
+	OpExists       Operator = "exists"
+	OpDoesNotExist Operator = "!"
+)
+
+const token = `[A-Za-z0-9_./:-]+`
+
+var (
+	existsRe  = regexp.MustCompile(`^(!?)\s*(` + token + `)$`)
+	compareRe = regexp.MustCompile(`^(` + token + `)\s*(==|=|!=)\s*(` + token + `)$`)
+	setRe     = regexp.MustCompile(`^(` + token + `)\s+(in|notin)\s*\((.*)\)$`)
+	valueRe   = regexp.MustCompile(`^` + token + `$`)
+)
+
+// Requirement is a single requirement on the value of an attribute
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Matches tells whether the attributes satisfy the requirement
+func (r Requirement) Matches(attrs map[string]string) bool {
+	value, ok := attrs[r.Key]
+	switch r.Operator {
+	case OpExists:
+		return ok
+	case OpDoesNotExist:
+		return !ok
+	case OpEqual, OpIn:
+		return ok && r.has(value)
+	case OpNotEqual, OpNotIn:
+		return !ok || !r.has(value)
+	}
+	return false
+}
+
+func (r Requirement) has(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case OpExists:
+		return r.Key
+	case OpDoesNotExist:
+		return "!" + r.Key
+	case OpIn, OpNotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	}
+	return fmt.Sprintf("%s%s%s", r.Key, r.Operator, r.Values[0])
+}
+
+// Selector is a parsed selector expression. The empty selector matches any
+// set of attributes.
+type Selector []Requirement
+
+// Matches tells whether the attributes satisfy all the requirements of the
+// selector
+func (s Selector) Matches(attrs map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	reqs := make([]string, 0, len(s))
+	for _, r := range s {
+		reqs = append(reqs, r.String())
+	}
+	return strings.Join(reqs, " && ")
+}
+
+// Parse parses a selector expression. An empty expression returns the empty
+// selector.
+func Parse(expr string) (Selector, error) {
+	if strings.TrimSpace(expr) == "" {
+		return Selector{}, nil
+	}
+	var sel Selector
+	for _, term := range strings.Split(expr, "&&") {
+		r, err := parseRequirement(strings.TrimSpace(term))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector '%s': %v", expr, err)
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	if term == "" {
+		return Requirement{}, errors.New("empty requirement")
+	}
+	if m := setRe.FindStringSubmatch(term); m != nil {
+		r := Requirement{Key: m[1], Operator: Operator(m[2])}
+		for _, v := range strings.Split(m[3], ",") {
+			v = strings.TrimSpace(v)
+			if !valueRe.MatchString(v) {
+				return Requirement{}, fmt.Errorf("invalid value '%s' in requirement '%s'", v, term)
+			}
+			r.Values = append(r.Values, v)
+		}
+		return r, nil
+	}
+	if m := compareRe.FindStringSubmatch(term); m != nil {
+		op := OpEqual
+		if m[2] == "!=" {
+			op = OpNotEqual
+		}
+		return Requirement{Key: m[1], Operator: op, Values: []string{m[3]}}, nil
+	}
+	if m := existsRe.FindStringSubmatch(term); m != nil {
+		if m[1] == "!" {
+			return Requirement{Key: m[2], Operator: OpDoesNotExist}, nil
+		}
+		return Requirement{Key: m[2], Operator: OpExists}, nil
+	}
+	return Requirement{}, fmt.Errorf("invalid requirement '%s'", term)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	attrs := map[string]string{
+		"sku":      "T6",
+		"rack":     "A2",
+		"firmware": "1.2.3",
+	}
+	exprs := map[string]bool{
+		"":                                 true,
+		"sku=T6":                           true,
+		"sku == T6":                        true,
+		"sku=T7":                           false,
+		"sku!=T7":                          true,
+		"serial!=ttyS0":                    true,
+		"rack in (A1,A2)":                  true,
+		"rack in (A1, A3)":                 false,
+		"rack notin (A1, A3)":              true,
+		"serial notin (ttyS0)":             true,
+		"firmware":                         true,
+		"!firmware":                        false,
+		"!quarantined":                     true,
+		"sku=T6 && rack in (A1,A2)":        true,
+		"sku=T6 && rack in (A1,A3)":        false,
+		"sku=T6&&firmware=1.2.3&&!serial":  true,
+		"  sku = T6  &&  rack notin (A2) ": false,
+	}
+	for expr, want := range exprs {
+		sel, err := Parse(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, want, sel.Matches(attrs), expr)
+	}
+}
+
+func TestSelectorParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"sku=",
+		"=T6",
+		"sku=T6 &&",
+		"sku=T6 || sku=T7",
+		"rack in (A1,)",
+		"rack in A1",
+		"sku=T 6",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	sel, err := Parse("sku==T6&&rack in (A1, A2)&& ! quarantined")
+	require.NoError(t, err)
+	require.Equal(t, "sku=T6 && rack in (A1,A2) && !quarantined", sel.String())
+}
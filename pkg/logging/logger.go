@@ -21,6 +21,11 @@ func GetLogger(prefix string) *logrus.Entry {
 	return log.WithField("prefix", prefix)
 }
 
+// SetLevel sets the level of all the loggers.
+func SetLevel(level logrus.Level) {
+	log.SetLevel(level)
+}
+
 // Disable sends all logging output to the bit bucket.
 func Disable() {
 	log.SetOutput(ioutil.Discard)
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/job"
@@ -40,6 +41,9 @@ type PluginRegistry struct {
 
 	// Reporters collects a mapping of Plugin Name <-> Reporter constructor
 	Reporters map[string]job.ReporterFactory
+
+	// TargetLockers collects a mapping of Plugin Name <-> Locker constructor
+	TargetLockers map[string]target.LockerFactory
 }
 
 // NewPluginRegistry constructs a new empty plugin registry
@@ -50,6 +54,7 @@ func NewPluginRegistry() *PluginRegistry {
 	pr.TestSteps = make(map[string]test.TestStepFactory)
 	pr.TestStepsEvents = make(map[string]map[event.Name]bool)
 	pr.Reporters = make(map[string]job.ReporterFactory)
+	pr.TargetLockers = make(map[string]target.LockerFactory)
 	return &pr
 }
 
@@ -116,6 +121,19 @@ func (r *PluginRegistry) RegisterReporter(pluginName string, rf job.ReporterFact
 	return nil
 }
 
+// RegisterTargetLocker registers a target Locker within the registry
+func (r *PluginRegistry) RegisterTargetLocker(pluginName string, lf target.LockerFactory) error {
+	pluginName = strings.ToLower(pluginName)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	log.Infof("Registering target locker %s", pluginName)
+	if _, found := r.TargetLockers[pluginName]; found {
+		return fmt.Errorf("TargetLocker %s already registered", pluginName)
+	}
+	r.TargetLockers[pluginName] = lf
+	return nil
+}
+
 // NewTargetManager returns a new instance of TargetManager from its
 // corresponding name
 func (r *PluginRegistry) NewTargetManager(pluginName string) (target.TargetManager, error) {
@@ -201,3 +219,17 @@ func (r *PluginRegistry) NewReporter(pluginName string) (job.Reporter, error) {
 	reporter := reporterFactory()
 	return reporter, nil
 }
+
+// NewTargetLocker returns a new instance of a target Locker from its
+// corresponding name, whose locks last for the given timeout. Lockers are
+// meant to be shared by all the jobs, so that they see each other's locks.
+func (r *PluginRegistry) NewTargetLocker(pluginName string, timeout time.Duration) (target.Locker, error) {
+	pluginName = strings.ToLower(pluginName)
+	r.lock.RLock()
+	lockerFactory, found := r.TargetLockers[pluginName]
+	r.lock.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("TargetLocker %s is not registered", pluginName)
+	}
+	return lockerFactory(timeout), nil
+}
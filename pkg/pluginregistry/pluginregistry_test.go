@@ -7,11 +7,13 @@ package pluginregistry
 
 import (
 	"testing"
+	"time"
 
 	"github.com/facebookincubator/contest/pkg/cerrors"
 	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/test"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
 
 	"github.com/stretchr/testify/require"
 )
@@ -62,3 +64,16 @@ func TestRegisterTestStepDoesNotValidate(t *testing.T) {
 	err := pr.RegisterTestStep("AStep", NewAStep, []event.Name{event.Name("Event which does not validate")})
 	require.Error(t, err)
 }
+
+func TestRegisterTargetLocker(t *testing.T) {
+	pr := NewPluginRegistry()
+	require.NoError(t, pr.RegisterTargetLocker(inmemory.Load()))
+	require.Error(t, pr.RegisterTargetLocker("inmemory", inmemory.New))
+
+	tl, err := pr.NewTargetLocker("INMEMORY", time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, tl)
+
+	_, err = pr.NewTargetLocker("Unknown", time.Second)
+	require.Error(t, err)
+}
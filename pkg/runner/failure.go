@@ -0,0 +1,80 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+)
+
+// EventRunFailed indicates that a run of a job failed. Depending on the
+// failure policy of the job, the next runs may still take place.
+var EventRunFailed = event.Name("RunFailed")
+
+// runFailedPayload represents the payload carried by EventRunFailed
+type runFailedPayload struct {
+	Run                 uint
+	ConsecutiveFailures uint
+	Err                 string
+}
+
+// runFailed records the failure of a run of a job, the consecutiveFailures-th
+// in a row, and returns an error if the job must stop according to its
+// failure policy.
+func runFailed(j *job.Job, run uint, consecutiveFailures uint, runErr error) error {
+	jobLog.Errorf("Run #%d of job %d failed (%d consecutive failures): %v", run+1, j.ID, consecutiveFailures, runErr)
+	emitFrameworkEvent(j, EventRunFailed, runFailedPayload{Run: run + 1, ConsecutiveFailures: consecutiveFailures, Err: runErr.Error()})
+	switch j.FailurePolicy {
+	case job.FailurePolicyContinue:
+		return nil
+	case job.FailurePolicyAbortAfterConsecutiveFailures:
+		if consecutiveFailures < j.MaxConsecutiveFailures {
+			return nil
+		}
+		return fmt.Errorf("aborting after %d consecutive failed runs: %w", consecutiveFailures, runErr)
+	default:
+		return runErr
+	}
+}
+
+// failedRunReports returns the run reports of a run whose test failed without
+// a result, which are unsuccessful and carry the error of the test.
+func failedRunReports(j *job.Job, runErr error) []*job.Report {
+	reports := make([]*job.Report, 0, len(j.RunReporterBundles))
+	for range j.RunReporterBundles {
+		reports = append(reports, &job.Report{
+			Success:    false,
+			ReportTime: time.Now(),
+			Data:       fmt.Sprintf("run failed: %v", runErr),
+		})
+	}
+	return reports
+}
+
+// emitFrameworkEvent emits a framework event for a job, with the given
+// payload serialized to JSON. Failures are only logged.
+func emitFrameworkEvent(j *job.Job, eventName event.Name, payload interface{}) {
+	ev := frameworkevent.Event{
+		JobID:     j.ID,
+		EventName: eventName,
+		EmitTime:  time.Now(),
+	}
+	if payloadJSON, err := json.Marshal(payload); err != nil {
+		jobLog.Warningf("Could not serialize payload for event %s: %v", eventName, err)
+	} else {
+		rawPayload := json.RawMessage(payloadJSON)
+		ev.Payload = &rawPayload
+	}
+	if err := storage.NewFrameworkEventEmitter().Emit(ev); err != nil {
+		jobLog.Warningf("Could not emit event %s for job %d: %v", eventName, j.ID, err)
+	}
+}
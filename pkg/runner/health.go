@@ -0,0 +1,65 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/test"
+)
+
+// checkHealth runs the health check of a test on the acquired targets, in
+// parallel, and returns the healthy ones, and the unhealthy ones with their
+// error. The unhealthy targets are unlocked, so that other jobs can acquire
+// them, and an EventTargetUnhealthy event is emitted for each of them.
+func checkHealth(j *job.Job, t *test.Test, tl target.Locker, targets []*target.Target) ([]*target.Target, map[*target.Target]error, error) {
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for idx, tgt := range targets {
+		wg.Add(1)
+		go func(idx int, tgt *target.Target) {
+			defer wg.Done()
+			errs[idx] = t.HealthCheck.Check(j.CancelCh, tgt)
+		}(idx, tgt)
+	}
+	wg.Wait()
+
+	ev := storage.NewTestEventEmitter(testevent.Header{JobID: j.ID, TestName: t.Name})
+	var healthy, unhealthy []*target.Target
+	unhealthyErrs := make(map[*target.Target]error)
+	for idx, tgt := range targets {
+		if errs[idx] == nil {
+			healthy = append(healthy, tgt)
+			continue
+		}
+		jobLog.Warningf("Target %s failed the health check of test '%s': %v", tgt, t.Name, errs[idx])
+		unhealthy = append(unhealthy, tgt)
+		unhealthyErrs[tgt] = errs[idx]
+		payload, err := json.Marshal(map[string]string{"error": errs[idx].Error()})
+		if err != nil {
+			jobLog.Warningf("Could not serialize the health check error of target %s: %v", tgt, err)
+		}
+		rawPayload := json.RawMessage(payload)
+		if err := ev.Emit(testevent.Data{EventName: target.EventTargetUnhealthy, Target: tgt, Payload: &rawPayload}); err != nil {
+			jobLog.Warningf("Could not emit %s event for target %s: %v", target.EventTargetUnhealthy, tgt, err)
+		}
+	}
+	if len(unhealthy) > 0 {
+		if err := tl.Unlock(j.ID, unhealthy); err != nil {
+			jobLog.Warningf("Failed to unlock %d unhealthy target(s): %v", len(unhealthy), err)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, unhealthyErrs, fmt.Errorf("none of the %d target(s) of test '%s' passed the health check", len(targets), t.Name)
+	}
+	return healthy, unhealthyErrs, nil
+}
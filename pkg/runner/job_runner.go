@@ -7,38 +7,145 @@ package runner
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/config"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/logging"
 	"github.com/facebookincubator/contest/pkg/storage"
 	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/test"
 	"github.com/facebookincubator/contest/pkg/types"
-	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
 )
 
 var jobLog = logging.GetLogger("pkg/runner")
 
 // JobRunner implements logic to run, cancel and stop Jobs
 type JobRunner struct {
-	// targetMap keeps the association between JobID and list of targets.
-	// This might be requested from clients using the JobRunner instance
-	targetMap map[types.JobID][]*target.Target
-	// targetLock protects the access to targetMap
+	// targetMap keeps the association between JobID and the targets of each
+	// of its tests holding targets, by test index. This might be requested
+	// from clients using the JobRunner instance. Targets joining or detached
+	// from a running test are added or removed.
+	targetMap map[types.JobID]map[int][]*target.Target
+	// testRunners holds the TestRunner of the tests each job is running, by
+	// test index
+	testRunners map[types.JobID]map[int]*TestRunner
+	// lockedTargets holds the IDs of the targets locked by each test of a
+	// job, from the time they are locked, see testLocker
+	lockedTargets map[types.JobID]map[int]map[string]bool
+	// targetLock protects the access to targetMap, testRunners and
+	// lockedTargets
 	targetLock *sync.RWMutex
+	// targetLocker is shared by all the jobs run by this JobRunner, so that
+	// concurrent jobs cannot acquire the same targets
+	targetLocker target.Locker
 }
 
-// GetTargets returns a list of acquired targets for JobID
+// GetTargets returns a list of acquired targets for JobID, across the tests
+// it is running
 func (jr *JobRunner) GetTargets(jobID types.JobID) []*target.Target {
 	jr.targetLock.RLock()
 	defer jr.targetLock.RUnlock()
-	if _, ok := jr.targetMap[jobID]; !ok {
-		return nil
+	indexes := make([]int, 0, len(jr.targetMap[jobID]))
+	for idx := range jr.targetMap[jobID] {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	var targets []*target.Target
+	for _, idx := range indexes {
+		targets = append(targets, jr.targetMap[jobID][idx]...)
+	}
+	return targets
+}
+
+// testTargets returns the targets of a test of a job
+func (jr *JobRunner) testTargets(jobID types.JobID, testIndex int) []*target.Target {
+	jr.targetLock.RLock()
+	defer jr.targetLock.RUnlock()
+	return jr.targetMap[jobID][testIndex]
+}
+
+// setTestTargets associates the targets acquired by a test with its job, for
+// later retrieval. Since the locks are held by the job, tests running in
+// parallel must use disjoint targets: if other tests of the job hold some of
+// the targets, it returns them and the targets are not associated.
+func (jr *JobRunner) setTestTargets(jobID types.JobID, testIndex int, targets []*target.Target) []*target.Target {
+	jr.targetLock.Lock()
+	defer jr.targetLock.Unlock()
+	held := jr.heldByOtherTestsLocked(jobID, testIndex)
+	var shared []*target.Target
+	for _, t := range targets {
+		if held[t.ID] {
+			shared = append(shared, t)
+		}
+	}
+	if len(shared) > 0 {
+		return shared
+	}
+	if jr.targetMap[jobID] == nil {
+		jr.targetMap[jobID] = make(map[int][]*target.Target)
+	}
+	jr.targetMap[jobID][testIndex] = targets
+	return nil
+}
+
+// heldByOtherTestsLocked returns the IDs of the targets held by the tests of
+// a job other than the given one, whether they are running on them or still
+// acquiring them. It must be called with targetLock held.
+func (jr *JobRunner) heldByOtherTestsLocked(jobID types.JobID, testIndex int) map[string]bool {
+	held := make(map[string]bool)
+	for idx, others := range jr.targetMap[jobID] {
+		if idx == testIndex {
+			continue
+		}
+		for _, t := range others {
+			held[t.ID] = true
+		}
+	}
+	for idx, ids := range jr.lockedTargets[jobID] {
+		if idx == testIndex {
+			continue
+		}
+		for id := range ids {
+			held[id] = true
+		}
+	}
+	return held
+}
+
+// clearTestTargets dissociates the targets of a test from its job
+func (jr *JobRunner) clearTestTargets(jobID types.JobID, testIndex int) {
+	jr.targetLock.Lock()
+	defer jr.targetLock.Unlock()
+	delete(jr.targetMap[jobID], testIndex)
+	if len(jr.targetMap[jobID]) == 0 {
+		delete(jr.targetMap, jobID)
+	}
+	delete(jr.lockedTargets[jobID], testIndex)
+	if len(jr.lockedTargets[jobID]) == 0 {
+		delete(jr.lockedTargets, jobID)
+	}
+}
+
+// setTestRunner registers the TestRunner of a running test of a job, or
+// unregisters it if nil
+func (jr *JobRunner) setTestRunner(jobID types.JobID, testIndex int, tr *TestRunner) {
+	jr.targetLock.Lock()
+	defer jr.targetLock.Unlock()
+	if tr != nil {
+		if jr.testRunners[jobID] == nil {
+			jr.testRunners[jobID] = make(map[int]*TestRunner)
+		}
+		jr.testRunners[jobID][testIndex] = tr
+		return
+	}
+	delete(jr.testRunners[jobID], testIndex)
+	if len(jr.testRunners[jobID]) == 0 {
+		delete(jr.testRunners, jobID)
 	}
-	return jr.targetMap[jobID]
 }
 
 // Run implements the main job running logic. It holds a registry of all running
@@ -49,13 +156,31 @@ func (jr *JobRunner) GetTargets(jobID types.JobID) []*target.Target {
 // * [][]job.Report: all the run reports, grouped by run, sorted from first to
 //                   last
 // * []job.Report:   all the final reports
-// * error:          an error, if any
+// * error:          an error, if any. If the job is paused, the error is of
+//                   type *ErrJobPaused and carries the state required to
+//                   resume the job.
 func (jr *JobRunner) Run(j *job.Job) ([][]*job.Report, []*job.Report, error) {
+	return jr.run(j, nil)
+}
+
+// Resume resumes a job which was previously paused, from the state returned
+// by Run or Resume at the time of pausing. The returned values have the same
+// meaning as for Run.
+func (jr *JobRunner) Resume(j *job.Job, state *JobPauseState) ([][]*job.Report, []*job.Report, error) {
+	if state == nil {
+		return nil, nil, fmt.Errorf("cannot resume job %d without a pause state", j.ID)
+	}
+	return jr.run(j, state)
+}
+
+func (jr *JobRunner) run(j *job.Job, resumeState *JobPauseState) ([][]*job.Report, []*job.Report, error) {
 	var (
-		err                    error
-		runReport, finalReport *job.Report
-		run                    uint
-		testResults            []*test.TestResult
+		err         error
+		finalReport *job.Report
+		run         uint
+		testResults []*test.TestResult
+		// consecutiveFailures is the number of runs which failed in a row
+		consecutiveFailures uint
 	)
 
 	if j.Runs == 0 {
@@ -63,163 +188,145 @@ func (jr *JobRunner) Run(j *job.Job) ([][]*job.Report, []*job.Report, error) {
 	} else {
 		jobLog.Infof("Running job '%s' %d times", j.Name, j.Runs)
 	}
-	// TODO make this configurable
-	lockTimeout := 10 * time.Second
-	tl := inmemory.New(lockTimeout)
 	ev := storage.NewTestEventFetcher()
 	var (
 		allRunsReports [][]*job.Report
 		thisRunReports []*job.Report
 	)
+	if resumeState != nil {
+		jobLog.Infof("Resuming job '%s' (id %v) from run #%d, test #%d", j.Name, j.ID, resumeState.Run+1, resumeState.TestIndex)
+		run = resumeState.Run
+		allRunsReports = resumeState.RunReports
+		testResults = resumeState.TestResults
+		consecutiveFailures = resumeState.ConsecutiveFailures
+	}
+	// paused returns the error signaling that the job has been paused at the
+	// given test of the current run.
+	paused := func(testIndex int, targets []TargetState) *ErrJobPaused {
+		jobLog.Infof("Job %d paused during run #%d, test #%d", j.ID, run+1, testIndex)
+		return &ErrJobPaused{State: &JobPauseState{
+			Run:         run,
+			TestIndex:   testIndex,
+			Targets:     targets,
+			RunReports:  allRunsReports,
+			TestResults: testResults,
+			// the current run did not fail, or the job would not go on
+			ConsecutiveFailures: consecutiveFailures,
+		}}
+	}
+runs:
 	for {
 		if j.Runs != 0 && run == j.Runs {
 			break
 		}
-		for idx, t := range j.Tests {
-			if j.IsCancelled() {
-				jobLog.Debugf("Cancellation requested, skipping test #%d of run #%d", idx, run+1)
-				break
-			}
-			jobLog.Infof("Run #%d: fetching targets for test '%s'", run+1, t.Name)
-			bundle := t.TargetManagerBundle
+		// runErr is the error of the first failed test of the run, if any
+		var runErr error
+		thisRunReports = nil
+		if j.ParallelTests {
 			var (
-				targets   []*target.Target
-				targetsCh = make(chan []*target.Target, 1)
-				errCh     = make(chan error, 1)
+				completed map[int]*test.TestResult
+				running   map[int][]TargetState
 			)
-			go func() {
-				// the Acquire semantic is synchronous, so that the implementation
-				// is simpler on the user's side. We run it in a goroutine in
-				// order to use a timeout for target acquisition.
-				targets, err := bundle.TargetManager.Acquire(j.ID, j.CancelCh, bundle.AcquireParameters, tl)
-				if err != nil {
-					errCh <- err
-					targetsCh <- nil
-					return
-				}
-				if allAreLocked, _, notLocked := tl.CheckLocks(j.ID, targets); !allAreLocked {
-					errCh <- fmt.Errorf("Could not lock %d targets out of %d are not locked: %v", len(notLocked), len(targets), notLocked)
-					targetsCh <- nil
-				}
-				errCh <- nil
-				targetsCh <- targets
-			}()
-			// wait for targets up to a certain amount of time
-			select {
-			case err := <-errCh:
-				targets = <-targetsCh
-				if err != nil {
-					jobLog.Warningf("Run #%d: cannot fetch targets for test '%s': %v", run+1, t.Name, err)
-					return nil, nil, err
-				}
-				// Associate the targets with the job for later retrievel
-				jr.targetLock.Lock()
-				jr.targetMap[j.ID] = targets
-				jr.targetLock.Unlock()
-
-			case <-time.After(config.TargetManagerTimeout):
-				return nil, nil, fmt.Errorf("target manager acquire timed out after %s", config.TargetManagerTimeout)
-			case <-j.CancelCh:
+			if resumeState != nil {
+				completed, running = resumeState.CompletedTests, resumeState.RunningTests
+				resumeState = nil
+			}
+			outcomes := jr.runTestsParallel(j, run, completed, running)
+			if j.IsCancelled() {
 				jobLog.Infof("cancellation requested for job ID %v", j.ID)
 				return nil, nil, nil
 			}
-
-			// refresh the target locks periodically, by extending their
-			// expiration time. If the job is cancelled, the locks are released.
-			// If the job is paused (e.g. because we are migrating the ConTest
-			// instance or upgrading it), the locks are not released, because we
-			// may want to resume once the new ConTest instance starts.
-			done := make(chan struct{})
-			go func(j *job.Job, tl target.Locker, targets []*target.Target, lockTimeout time.Duration) {
-				for {
-					select {
-					case <-j.CancelCh:
-						// unlock targets
-						if err := tl.Unlock(j.ID, targets); err != nil {
-							log.Warningf("Failed to unlock targets (%v) for job ID %d: %v", targets, j.ID, err)
-						}
-						return
-					case <-j.PauseCh:
-						// do not unlock targets, we can resume later, or let
-						// them expire
-						log.Debugf("Received pause request, NOT releasing targets so the job can be resumed")
-						return
-					case <-done:
-						if err := tl.Unlock(j.ID, targets); err != nil {
-							log.Warningf("Failed to unlock %d target(s) (%v): %v", len(targets), targets, err)
-						}
-						log.Infof("Unlocked %d target(s) for job ID %d", len(targets), j.ID)
-						return
-					case <-time.After(lockTimeout):
-						// refresh the locks before the timeout expires
-						if err := tl.RefreshLocks(j.ID, targets); err != nil {
-							log.Warningf("Failed to refresh %d locks for job ID %d: %v", len(targets), j.ID, err)
-						}
-					}
+			completed = make(map[int]*test.TestResult, len(outcomes))
+			pauseTargets := make(map[int][]TargetState)
+			firstIncomplete := -1
+			for idx, outcome := range outcomes {
+				if outcome.err != nil && runErr == nil {
+					runErr = outcome.err
 				}
-			}(j, tl, targets, lockTimeout)
-
-			// Run the job
-			jobLog.Infof("Run #%d: running test #%d for job '%s' (job ID: %d) on %d targets", run+1, idx, j.Name, j.ID, len(targets))
-			runner := NewTestRunner()
-			testResult, runErr := runner.Run(j.CancelCh, j.PauseCh, t, targets, j.ID)
-			if testResult != nil {
-				testResults = append(testResults, testResult)
-			}
-			// Job is done, release all the targets
-			go func() {
-				// the Release semantic is synchronous, so that the implementation
-				// is simpler on the user's side. We run it in a goroutine in
-				// order to use a timeout for target acquisition. If Release fails, whether
-				// due to an error or for a timeout, the whole Job is considered failed
-				errCh <- bundle.TargetManager.Release(j.ID, j.CancelCh, bundle.ReleaseParameters)
-				// signal that we are done to the goroutine that refreshes the
-				// locks.
-				done <- struct{}{}
-			}()
-			select {
-			case err := <-errCh:
-				if err != nil {
-					errRelease := fmt.Sprintf("Failed to release targets: %v", err)
-					jobLog.Errorf(errRelease)
-					return nil, nil, fmt.Errorf(errRelease)
+				if outcome.paused && len(outcome.pauseTargets) > 0 {
+					pauseTargets[idx] = outcome.pauseTargets
+				}
+				if outcome.result != nil {
+					completed[idx] = outcome.result
+				} else if outcome.err == nil && firstIncomplete < 0 {
+					firstIncomplete = idx
 				}
-			case <-time.After(config.TargetManagerTimeout):
-				return nil, nil, fmt.Errorf("target manager release timed out after %s", config.TargetManagerTimeout)
-			case <-j.CancelCh:
-				jobLog.Infof("cancellation requested for job ID %v", j.ID)
-				return nil, nil, nil
 			}
-			// return the Run error only after releasing the targets, and only
-			// if we are not running indefinitely.
-			// TODO do the next runs even if one fails. We are interested in the
-			// signal from all of them. Or not? Should this go behind a flag?
-			if runErr != nil {
-				return nil, nil, runErr
+			if runErr == nil && firstIncomplete >= 0 {
+				if !j.IsPaused() {
+					return nil, nil, fmt.Errorf("test #%d of run #%d did not produce any result", firstIncomplete, run+1)
+				}
+				pauseErr := paused(firstIncomplete, nil)
+				pauseErr.State.CompletedTests = completed
+				pauseErr.State.RunningTests = pauseTargets
+				return nil, nil, pauseErr
 			}
-			if len(testResults) == 0 {
-				jobLog.Warningf("Skipping reporting phase because test did not produce any result")
-				return nil, nil, fmt.Errorf("Report skipped because test did not produce any result")
+			// report in the order of the tests, as if they ran sequentially:
+			// the reports of a failed run are those of its first failed test
+			reportedFailure := false
+			for _, outcome := range outcomes {
+				if outcome.result == nil && outcome.err == nil {
+					// the test did not start because another one failed
+					continue
+				}
+				if outcome.result != nil {
+					testResults = append(testResults, outcome.result)
+				}
+				if !reportedFailure {
+					thisRunReports = jr.runReports(j, run, outcome, ev)
+					reportedFailure = outcome.err != nil
+				}
 			}
-			thisRunReports = make([]*job.Report, 0)
-			for _, bundle := range j.RunReporterBundles {
-				runReport, err = bundle.Reporter.RunReport(j.CancelCh, bundle.Parameters, run+1, testResult, ev)
-				if err != nil {
-					jobLog.Warningf("Run reporter failed while calculating test results, proceeding anyway: %v", err)
-				} else {
-					if runReport.Success {
-						jobLog.Printf("Run #%d of job %d considered successful", run+1, j.ID)
-					} else {
-						jobLog.Errorf("Run #%d of job %d considered failed", run+1, j.ID)
+		} else {
+			for idx, t := range j.Tests {
+				var resumeTargets []TargetState
+				if resumeState != nil {
+					// skip the tests that were completed before pausing
+					if idx < resumeState.TestIndex {
+						continue
 					}
+					resumeTargets = resumeState.Targets
+					resumeState = nil
+				}
+				if j.IsCancelled() {
+					jobLog.Debugf("Cancellation requested, skipping test #%d of run #%d", idx, run+1)
+					break
+				}
+				outcome := jr.runTest(j, run, idx, t, resumeTargets)
+				if outcome.paused {
+					return nil, nil, paused(idx, outcome.pauseTargets)
+				}
+				if outcome.result == nil && outcome.err == nil {
+					// the job was cancelled
+					return nil, nil, nil
+				}
+				if outcome.result != nil {
+					testResults = append(testResults, outcome.result)
 				}
-				// TODO run report must be sent to the storage layer as soon as it's
-				//      ready, not at the end of the runs. This requires a change in
-				//      how we store and expose reports, because this will require
-				//      one DB entry per run report rather than one for all of them.
-				thisRunReports = append(thisRunReports, runReport)
+				thisRunReports = jr.runReports(j, run, outcome, ev)
+				if outcome.err != nil {
+					// the run failed, skip its remaining tests
+					runErr = outcome.err
+					break
+				}
+			}
+		}
+		// store the reports of the run right away, so that they are visible
+		// while the job runs, and survive a crash, unless the run was
+		// interrupted by a cancellation
+		if !j.IsCancelled() {
+			if err := storage.NewJobReportEmitter().EmitRunReports(j.ID, run+1, thisRunReports); err != nil {
+				jobLog.Warningf("Could not store the reports of run #%d of job %d: %v", run+1, j.ID, err)
 			}
 		}
+		if runErr != nil {
+			consecutiveFailures++
+			if err := runFailed(j, run, consecutiveFailures, runErr); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			consecutiveFailures = 0
+		}
 		allRunsReports = append(allRunsReports, thisRunReports)
 		if j.IsCancelled() {
 			jobLog.Debugf("Cancellation requested, skipping run #%d", run+1)
@@ -228,7 +335,15 @@ func (jr *JobRunner) Run(j *job.Job) ([][]*job.Report, []*job.Report, error) {
 		// don't sleep on the last run
 		if j.Runs == 0 || (j.Runs > 1 && run < j.Runs-1) {
 			jobLog.Infof("Sleeping %s before the next run...", j.RunInterval)
-			time.Sleep(j.RunInterval)
+			select {
+			case <-time.After(j.RunInterval):
+			case <-j.CancelCh:
+				jobLog.Debugf("Cancellation requested, skipping run #%d", run+2)
+				break runs
+			case <-j.PauseCh:
+				run++
+				return nil, nil, paused(0, nil)
+			}
 		}
 		run++
 	}
@@ -256,10 +371,340 @@ func (jr *JobRunner) Run(j *job.Job) ([][]*job.Report, []*job.Report, error) {
 	return allRunsReports, finalReports, nil
 }
 
-// NewJobRunner returns a new JobRunner, which holds an empty registry of jobs
-func NewJobRunner() *JobRunner {
-	jr := JobRunner{}
-	jr.targetMap = make(map[types.JobID][]*target.Target)
+// runReports calculates the run reports of a test of a job. If the test
+// failed, the reports are unsuccessful.
+func (jr *JobRunner) runReports(j *job.Job, run uint, outcome testOutcome, ev testevent.Fetcher) []*job.Report {
+	if outcome.result == nil {
+		return failedRunReports(j, outcome.err)
+	}
+	reports := make([]*job.Report, 0)
+	for _, bundle := range j.RunReporterBundles {
+		runReport, err := bundle.Reporter.RunReport(j.CancelCh, bundle.Parameters, run+1, outcome.result, ev)
+		if err != nil {
+			jobLog.Warningf("Run reporter failed while calculating test results, proceeding anyway: %v", err)
+		} else {
+			if outcome.err != nil {
+				// the results of the targets may be partial
+				runReport.Success = false
+			}
+			if runReport.Success {
+				jobLog.Printf("Run #%d of job %d considered successful", run+1, j.ID)
+			} else {
+				jobLog.Errorf("Run #%d of job %d considered failed", run+1, j.ID)
+			}
+		}
+		reports = append(reports, runReport)
+	}
+	return reports
+}
+
+// runTestsParallel runs the tests of a run of a job concurrently, at most
+// MaxParallelTests at a time if set. If the run is resumed, completed holds
+// the results of the tests completed before pausing, and running the state of
+// the targets of the tests which were running. It returns the outcomes of the
+// tests by test index, which are empty for the tests which did not start.
+// Once a test fails, no other test is started, and the outcomes are returned
+// once the running ones returned.
+func (jr *JobRunner) runTestsParallel(j *job.Job, run uint, completed map[int]*test.TestResult, running map[int][]TargetState) []testOutcome {
+	limit := int(j.MaxParallelTests)
+	if limit == 0 {
+		limit = len(j.Tests)
+	}
+	var (
+		outcomes = make([]testOutcome, len(j.Tests))
+		slots    = make(chan struct{}, limit)
+		failed   = make(chan struct{})
+		failOnce sync.Once
+		wg       sync.WaitGroup
+	)
+	// stopping returns whether no more tests should be started
+	stopping := func() bool {
+		select {
+		case <-j.CancelCh:
+		case <-j.PauseCh:
+		case <-failed:
+		default:
+			return false
+		}
+		return true
+	}
+	for idx, t := range j.Tests {
+		if res, ok := completed[idx]; ok {
+			outcomes[idx].result = res
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-j.CancelCh:
+		case <-j.PauseCh:
+		case <-failed:
+		}
+		if stopping() {
+			jobLog.Debugf("Not starting test #%d of run #%d of job %d", idx, run+1, j.ID)
+			break
+		}
+		wg.Add(1)
+		go func(idx int, t *test.Test) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			outcome := jr.runTest(j, run, idx, t, running[idx])
+			if outcome.err != nil {
+				failOnce.Do(func() { close(failed) })
+			}
+			// each goroutine sets its own outcome, which are read once
+			// all of them returned
+			outcomes[idx] = outcome
+		}(idx, t)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// testOutcome is the outcome of running a test of a job. If the job is
+// cancelled, it holds neither a result nor an error.
+type testOutcome struct {
+	result *test.TestResult
+	err    error
+	// paused is set if the job is paused during the test. pauseTargets then
+	// holds the state of its targets, if they were acquired, so that the test
+	// can be resumed.
+	paused       bool
+	pauseTargets []TargetState
+}
+
+// runTest runs a test of a job, from target acquisition to target release.
+// If the test is resumed, resumeTargets holds the state of its targets at the
+// time of pausing.
+func (jr *JobRunner) runTest(j *job.Job, run uint, idx int, t *test.Test, resumeTargets []TargetState) testOutcome {
+	if j.IsPaused() {
+		// if the test is being resumed, keep the state of its targets
+		return testOutcome{paused: true, pauseTargets: resumeTargets}
+	}
+	lockTimeout := config.LockTimeout
+	// the target manager cannot lock nor unlock the targets of the other
+	// tests of the job
+	tl := &testLocker{Locker: jr.targetLocker, jr: jr, testIndex: idx}
+	bundle := t.TargetManagerBundle
+	var (
+		targets   []*target.Target
+		targetsCh = make(chan []*target.Target, 1)
+		errCh     = make(chan error, 1)
+		// unhealthy maps the targets which failed the health check to
+		// their error, they are released as failed
+		unhealthy map[*target.Target]error
+	)
+	if len(resumeTargets) > 0 {
+		// The targets were acquired before pausing, lock them again
+		// instead of acquiring new ones.
+		jobLog.Infof("Run #%d: locking again %d target(s) for resumed test '%s'", run+1, len(resumeTargets), t.Name)
+		for _, ts := range resumeTargets {
+			targets = append(targets, ts.Target)
+		}
+		if err := tl.Lock(j.ID, targets); err != nil {
+			return testOutcome{err: fmt.Errorf("could not lock targets of resumed test '%s': %v", t.Name, err)}
+		}
+		errCh <- nil
+		targetsCh <- targets
+	} else {
+		jobLog.Infof("Run #%d: fetching targets for test '%s'", run+1, t.Name)
+		go func() {
+			// the Acquire semantic is synchronous, so that the implementation
+			// is simpler on the user's side. We run it in a goroutine in
+			// order to use a timeout for target acquisition.
+			targets, err := bundle.TargetManager.Acquire(j.ID, j.CancelCh, bundle.AcquireParameters, tl)
+			if err != nil {
+				errCh <- err
+				targetsCh <- nil
+				return
+			}
+			if allAreLocked, _, notLocked := tl.CheckLocks(j.ID, targets); !allAreLocked {
+				errCh <- fmt.Errorf("Could not lock %d targets out of %d are not locked: %v", len(notLocked), len(targets), notLocked)
+				targetsCh <- nil
+				return
+			}
+			if t.HealthCheck != nil {
+				if targets, unhealthy, err = checkHealth(j, t, tl, targets); err != nil {
+					errCh <- err
+					targetsCh <- nil
+					return
+				}
+			}
+			errCh <- nil
+			targetsCh <- targets
+		}()
+	}
+	// wait for targets up to a certain amount of time
+	select {
+	case err := <-errCh:
+		targets = <-targetsCh
+		if err != nil {
+			jobLog.Warningf("Run #%d: cannot fetch targets for test '%s': %v", run+1, t.Name, err)
+			// the target manager may hold resources even if Acquire
+			// failed, release them
+			_ = release(j, t, nil, unhealthy)
+			jr.clearTestTargets(j.ID, idx)
+			return testOutcome{err: err}
+		}
+		// Associate the targets with the job for later retrievel
+		if shared := jr.setTestTargets(j.ID, idx, targets); len(shared) > 0 {
+			err := fmt.Errorf("test '%s' acquired %d target(s) held by other tests of the job, tests running in parallel need disjoint targets: %v", t.Name, len(shared), shared)
+			// the shared targets stay locked for the tests holding them
+			_ = release(j, t, targets, nil)
+			if err := tl.Unlock(j.ID, excludeTargets(targets, shared)); err != nil {
+				jobLog.Warningf("Failed to unlock targets of test '%s': %v", t.Name, err)
+			}
+			jr.clearTestTargets(j.ID, idx)
+			return testOutcome{err: err}
+		}
+
+	case <-time.After(config.TargetManagerTimeout):
+		go releaseAcquired(j, t, errCh, targetsCh)
+		return testOutcome{err: fmt.Errorf("target manager acquire timed out after %s", config.TargetManagerTimeout)}
+	case <-j.CancelCh:
+		jobLog.Infof("cancellation requested for job ID %v", j.ID)
+		releaseAcquired(j, t, errCh, targetsCh)
+		jr.clearTestTargets(j.ID, idx)
+		return testOutcome{}
+	case <-j.PauseCh:
+		// if the test is being resumed, keep the state of its targets,
+		// otherwise targets will be acquired again upon resume
+		return testOutcome{paused: true, pauseTargets: resumeTargets}
+	}
+
+	// refresh the target locks periodically, by extending their
+	// expiration time, until the targets are released, even if the
+	// job is cancelled. If the job is paused (e.g. because we are
+	// migrating the ConTest instance or upgrading it), the locks are
+	// not released, because we may want to resume once the new ConTest
+	// instance starts. The targets of the test may change while it
+	// runs, as targets join or are detached.
+	done := make(chan struct{})
+	go func(j *job.Job, tl target.Locker, lockTimeout time.Duration) {
+		for {
+			select {
+			case <-j.PauseCh:
+				// do not unlock targets, we can resume later, or let
+				// them expire
+				log.Debugf("Received pause request, NOT releasing targets so the job can be resumed")
+				return
+			case <-done:
+				return
+			case <-time.After(lockTimeout):
+				// refresh the locks before the timeout expires
+				targets := jr.testTargets(j.ID, idx)
+				if err := tl.RefreshLocks(j.ID, targets); err != nil {
+					log.Warningf("Failed to refresh %d locks for job ID %d: %v", len(targets), j.ID, err)
+				}
+			}
+		}
+	}(j, tl, lockTimeout)
+
+	// Run the job
+	jobLog.Infof("Run #%d: running test #%d for job '%s' (job ID: %d) on %d targets", run+1, idx, j.Name, j.ID, len(targets))
+	runner := NewTestRunner()
+	var (
+		testResult *test.TestResult
+		runErr     error
+		stopStream = make(chan struct{})
+		streamDone <-chan struct{}
+		// unhealthyJoined holds the streamed targets which failed the
+		// health check
+		unhealthyJoined = make(map[*target.Target]error)
+	)
+	// targets can join the test if the target manager streams them,
+	// except when resuming a test
+	if streamer, ok := bundle.TargetManager.(target.TargetStreamer); ok && len(resumeTargets) == 0 {
+		joined := make(chan *target.Target)
+		runner.Join(joined)
+		streamDone = jr.streamTargets(j, idx, t, streamer, tl, joined, stopStream, unhealthyJoined)
+	}
+	jr.setTestRunner(j.ID, idx, &runner)
+	if len(resumeTargets) > 0 {
+		testResult, runErr = runner.Resume(j.CancelCh, j.PauseCh, t, resumeTargets, j.ID)
+	} else {
+		testResult, runErr = runner.Run(j.CancelCh, j.PauseCh, t, targets, j.ID)
+	}
+	close(stopStream)
+	if streamDone != nil {
+		<-streamDone
+	}
+	jr.setTestRunner(j.ID, idx, nil)
+	if j.IsPaused() {
+		// Targets are not released, and the results collected so far
+		// are part of the state of the targets.
+		if runErr != nil {
+			jobLog.Warningf("Test '%s' did not pause cleanly: %v", t.Name, runErr)
+		}
+		pauseTargets := runner.TargetStates(jr.testTargets(j.ID, idx))
+		jr.clearTestTargets(j.ID, idx)
+		return testOutcome{paused: true, pauseTargets: pauseTargets}
+	}
+	results := make(map[*target.Target]error, len(unhealthy)+len(unhealthyJoined))
+	for _, unhealthyTargets := range []map[*target.Target]error{unhealthy, unhealthyJoined} {
+		for tgt, err := range unhealthyTargets {
+			results[tgt] = err
+		}
+	}
+	if testResult != nil {
+		for tgt, err := range testResult.Targets() {
+			results[tgt] = err
+		}
+	}
+	// Test is done, release all the targets, even if it failed or the
+	// job was cancelled. If Release fails, whether due to an error or
+	// for a timeout, the whole Job is considered failed.
+	targets = jr.testTargets(j.ID, idx)
+	errRelease := release(j, t, targets, results)
+	// signal that we are done to the goroutine that refreshes the
+	// locks, and unlock the targets.
+	close(done)
+	if err := tl.Unlock(j.ID, targets); err != nil {
+		log.Warningf("Failed to unlock %d target(s) (%v): %v", len(targets), targets, err)
+	}
+	log.Infof("Unlocked %d target(s) for job ID %d", len(targets), j.ID)
+	jr.clearTestTargets(j.ID, idx)
+	if j.IsCancelled() {
+		jobLog.Infof("cancellation requested for job ID %v", j.ID)
+		return testOutcome{}
+	}
+	if errRelease != nil {
+		return testOutcome{err: fmt.Errorf("Failed to release targets: %v", errRelease)}
+	}
+	// return the Run error only after releasing the targets. Whether the
+	// next runs take place depends on the failure policy of the job.
+	if runErr != nil {
+		// the results collected so far are reported along with the error
+		return testOutcome{result: testResult, err: runErr}
+	}
+	if testResult == nil {
+		jobLog.Warningf("Skipping reporting phase because test did not produce any result")
+		return testOutcome{err: fmt.Errorf("Report skipped because test did not produce any result")}
+	}
+	return testOutcome{result: testResult}
+}
+
+// excludeTargets returns the targets which are not in excluded
+func excludeTargets(targets, excluded []*target.Target) []*target.Target {
+	skip := make(map[*target.Target]bool, len(excluded))
+	for _, t := range excluded {
+		skip[t] = true
+	}
+	var remaining []*target.Target
+	for _, t := range targets {
+		if !skip[t] {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
+
+// NewJobRunner returns a new JobRunner, which holds an empty registry of jobs,
+// and locks the targets of all the jobs with the given Locker.
+func NewJobRunner(tl target.Locker) *JobRunner {
+	jr := JobRunner{targetLocker: tl}
+	jr.targetMap = make(map[types.JobID]map[int][]*target.Target)
+	jr.testRunners = make(map[types.JobID]map[int]*TestRunner)
+	jr.lockedTargets = make(map[types.JobID]map[int]map[string]bool)
 	jr.targetLock = &sync.RWMutex{}
 	return &jr
 }
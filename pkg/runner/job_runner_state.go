@@ -0,0 +1,54 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/test"
+)
+
+// JobPauseState is the state of a job at the time it was paused. It contains
+// everything the JobRunner needs to resume the job later on, possibly from a
+// different ConTest instance.
+type JobPauseState struct {
+	// Run is the zero-based index of the run the job was paused in
+	Run uint
+	// TestIndex is the index of the test the job was paused in
+	TestIndex int
+	// Targets holds the position in the pipeline of the targets of the paused
+	// test. It is empty if the job was paused before acquiring targets, in
+	// which case targets are acquired again upon resume.
+	Targets []TargetState
+	// RunReports holds the reports of the runs completed before pausing
+	RunReports [][]*job.Report
+	// TestResults holds the results of the tests completed before pausing.
+	// When the tests run in parallel, it excludes the tests of the paused run.
+	TestResults []*test.TestResult
+	// CompletedTests holds, when the tests run in parallel, the results of
+	// the tests of the paused run which completed before pausing, by test
+	// index. TestIndex is then the first test which did not complete.
+	CompletedTests map[int]*test.TestResult
+	// RunningTests holds, when the tests run in parallel, the position in the
+	// pipeline of the targets of the tests which were paused, by test index.
+	// Targets is unused in that case.
+	RunningTests map[int][]TargetState
+	// ConsecutiveFailures is the number of runs which failed in a row before
+	// the paused run
+	ConsecutiveFailures uint
+}
+
+// ErrJobPaused is returned by the JobRunner when a job is paused. It carries
+// the state required to resume the job.
+type ErrJobPaused struct {
+	State *JobPauseState
+}
+
+// Error returns the error string associated with the error
+func (e *ErrJobPaused) Error() string {
+	return fmt.Sprintf("job paused during run #%d, test #%d", e.State.Run+1, e.State.TestIndex)
+}
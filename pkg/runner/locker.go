@@ -0,0 +1,131 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// testLocker wraps the Locker of the jobs for a test of a job. The locks are
+// owned by the job, so without it the target manager of a test running in
+// parallel with others could lock again the targets of another test, and
+// unlock them upon Release. testLocker does not lock the targets held by the
+// other tests of the job, nor unlock them, and records the targets it locks
+// for the test until they are unlocked or the test is over.
+type testLocker struct {
+	target.Locker
+	jr        *JobRunner
+	testIndex int
+}
+
+// Lock implements target.Locker. It fails if other tests of the job hold any
+// of the targets.
+func (l *testLocker) Lock(jobID types.JobID, targets []*target.Target) error {
+	if _, held := l.split(jobID, targets); len(held) > 0 {
+		return errHeldByOtherTests(held)
+	}
+	if err := l.Locker.Lock(jobID, targets); err != nil {
+		return err
+	}
+	return l.claim(jobID, targets)
+}
+
+// LockWait implements target.BlockingLocker. The targets held by other tests
+// of the job are removed from the request, unless it needs all of them.
+func (l *testLocker) LockWait(jobID types.JobID, cancel <-chan struct{}, req target.LockRequest) ([]*target.Target, error) {
+	free, held := l.split(jobID, req.Targets)
+	if len(held) > 0 {
+		if req.Min == 0 {
+			return nil, errHeldByOtherTests(held)
+		}
+		jobLog.Debugf("Job %d: skipping %d target(s) held by other tests: %v", jobID, len(held), held)
+		req.Targets = free
+	}
+	locked, err := target.LockWait(l.Locker, jobID, cancel, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.claim(jobID, locked); err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// Unlock implements target.Locker. The targets held by other tests of the job
+// stay locked.
+func (l *testLocker) Unlock(jobID types.JobID, targets []*target.Target) error {
+	free, held := l.split(jobID, targets)
+	if len(held) > 0 {
+		jobLog.Debugf("Job %d: not unlocking %d target(s) held by other tests: %v", jobID, len(held), held)
+	}
+	l.jr.targetLock.Lock()
+	for _, t := range free {
+		delete(l.jr.lockedTargets[jobID][l.testIndex], t.ID)
+	}
+	l.jr.targetLock.Unlock()
+	if len(free) == 0 {
+		return nil
+	}
+	return l.Locker.Unlock(jobID, free)
+}
+
+// split returns the targets which are not held by the other tests of the job,
+// and the ones which are.
+func (l *testLocker) split(jobID types.JobID, targets []*target.Target) ([]*target.Target, []*target.Target) {
+	l.jr.targetLock.RLock()
+	defer l.jr.targetLock.RUnlock()
+	return l.splitLocked(jobID, targets)
+}
+
+// splitLocked is like split, and must be called with targetLock held.
+func (l *testLocker) splitLocked(jobID types.JobID, targets []*target.Target) ([]*target.Target, []*target.Target) {
+	heldIDs := l.jr.heldByOtherTestsLocked(jobID, l.testIndex)
+	var free, held []*target.Target
+	for _, t := range targets {
+		if heldIDs[t.ID] {
+			held = append(held, t)
+		} else {
+			free = append(free, t)
+		}
+	}
+	return free, held
+}
+
+// claim records the targets locked for the test. If another test of the job
+// locked some of them in the meantime, the others are unlocked and an error
+// is returned.
+func (l *testLocker) claim(jobID types.JobID, targets []*target.Target) error {
+	l.jr.targetLock.Lock()
+	free, held := l.splitLocked(jobID, targets)
+	if len(held) == 0 {
+		if l.jr.lockedTargets[jobID] == nil {
+			l.jr.lockedTargets[jobID] = make(map[int]map[string]bool)
+		}
+		if l.jr.lockedTargets[jobID][l.testIndex] == nil {
+			l.jr.lockedTargets[jobID][l.testIndex] = make(map[string]bool)
+		}
+		for _, t := range targets {
+			l.jr.lockedTargets[jobID][l.testIndex][t.ID] = true
+		}
+	}
+	l.jr.targetLock.Unlock()
+	if len(held) == 0 {
+		return nil
+	}
+	if len(free) > 0 {
+		if err := l.Locker.Unlock(jobID, free); err != nil {
+			jobLog.Warningf("Failed to unlock %d target(s) of job %d: %v", len(free), jobID, err)
+		}
+	}
+	return errHeldByOtherTests(held)
+}
+
+func errHeldByOtherTests(held []*target.Target) error {
+	return fmt.Errorf("%d target(s) are held by other tests of the job, tests running in parallel need disjoint targets: %v", len(held), held)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/config"
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/test"
+)
+
+// EventTargetsReleased indicates that the target manager of a test released
+// its targets
+var EventTargetsReleased = event.Name("TargetsReleased")
+
+// EventTargetsReleaseFailed indicates that the target manager of a test failed
+// to release its targets, or timed out
+var EventTargetsReleaseFailed = event.Name("TargetsReleaseFailed")
+
+// releasePayload represents the payload carried by the release events
+type releasePayload struct {
+	Test    string
+	Targets int
+	Passed  int
+	Failed  int
+	Err     string `json:",omitempty"`
+}
+
+// release runs the release phase of a test, passing the results of its
+// targets to the target manager. Release is not interrupted when the job is
+// cancelled, but it is bounded by TargetManagerReleaseTimeout. The outcome is
+// recorded as a framework event.
+func release(j *job.Job, t *test.Test, targets []*target.Target, results map[*target.Target]error) error {
+	bundle := t.TargetManagerBundle
+	cancel := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		// the Release semantic is synchronous, so that the implementation is
+		// simpler on the user's side. We run it in a goroutine in order to
+		// use a timeout.
+		errCh <- bundle.TargetManager.Release(j.ID, cancel, bundle.ReleaseParameters, results)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-time.After(config.TargetManagerReleaseTimeout):
+		close(cancel)
+		err = fmt.Errorf("target manager release timed out after %s", config.TargetManagerReleaseTimeout)
+	}
+
+	payload := releasePayload{Test: t.Name, Targets: len(targets)}
+	for _, targetErr := range results {
+		if targetErr == nil {
+			payload.Passed++
+		} else {
+			payload.Failed++
+		}
+	}
+	eventName := EventTargetsReleased
+	if err != nil {
+		eventName = EventTargetsReleaseFailed
+		payload.Err = err.Error()
+		jobLog.Errorf("Failed to release targets of test '%s': %v", t.Name, err)
+	} else {
+		jobLog.Infof("Released %d target(s) of test '%s'", len(targets), t.Name)
+	}
+	emitFrameworkEvent(j, eventName, payload)
+	return err
+}
+
+// releaseAcquired waits for a pending Acquire to return, and releases the
+// targets it acquired, if any. It gives up after TargetManagerReleaseTimeout.
+func releaseAcquired(j *job.Job, t *test.Test, errCh <-chan error, targetsCh <-chan []*target.Target) {
+	select {
+	case <-errCh:
+		_ = release(j, t, <-targetsCh, nil)
+	case <-time.After(config.TargetManagerReleaseTimeout):
+		jobLog.Errorf("Target manager of test '%s' did not return from Acquire, not releasing its targets", t.Name)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/test"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// ErrTargetNotInJob is returned, possibly wrapped, when detaching a target
+// which the job does not run on.
+var ErrTargetNotInJob = errors.New("target is not in the job")
+
+// streamTargets runs the Stream method of the target manager of a test, and
+// forwards the streamed targets to the test runner via joined until stop is
+// closed. The streamed targets are added to the targets of the test, so that
+// their locks are refreshed and they are released with the others. The ones
+// failing the health check of the test are recorded in unhealthy instead. The
+// returned channel is closed once Stream returned, and joined is closed.
+func (jr *JobRunner) streamTargets(j *job.Job, testIndex int, t *test.Test, streamer target.TargetStreamer, tl target.Locker, joined chan<- *target.Target, stop <-chan struct{}, unhealthy map[*target.Target]error) <-chan struct{} {
+	cancel := make(chan struct{})
+	go func() {
+		select {
+		case <-j.CancelCh:
+		case <-j.PauseCh:
+		case <-stop:
+		}
+		close(cancel)
+	}()
+
+	streamed := make(chan *target.Target)
+	go func() {
+		defer close(streamed)
+		if err := streamer.Stream(j.ID, cancel, t.TargetManagerBundle.AcquireParameters, tl, streamed); err != nil {
+			jobLog.Warningf("Target manager of test '%s' failed to stream targets: %v", t.Name, err)
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(joined)
+		ev := storage.NewTestEventEmitter(testevent.Header{JobID: j.ID, TestName: t.Name})
+		for tgt := range streamed {
+			if t.HealthCheck != nil {
+				healthy, unhealthyTargets, _ := checkHealth(j, t, tl, []*target.Target{tgt})
+				for ut, err := range unhealthyTargets {
+					unhealthy[ut] = err
+				}
+				if len(healthy) == 0 {
+					continue
+				}
+			}
+			if !jr.addTarget(j.ID, testIndex, tgt) {
+				jobLog.Warningf("Target %s is already in job %d, not joining it again", tgt, j.ID)
+				continue
+			}
+			if err := ev.Emit(testevent.Data{EventName: target.EventTargetJoined, Target: tgt}); err != nil {
+				jobLog.Warningf("Could not emit %s event for target %s: %v", target.EventTargetJoined, tgt, err)
+			}
+			jobLog.Infof("Target %s joined test '%s' of job %d", tgt, t.Name, j.ID)
+			select {
+			case joined <- tgt:
+			case <-stop:
+				// the test is over, the target is only released
+			}
+		}
+	}()
+	return done
+}
+
+// addTarget adds a target to the targets of a test of a job, unless any test
+// of the job already has a target with the same ID. It returns whether the
+// target was added.
+func (jr *JobRunner) addTarget(jobID types.JobID, testIndex int, t *target.Target) bool {
+	jr.targetLock.Lock()
+	defer jr.targetLock.Unlock()
+	for _, targets := range jr.targetMap[jobID] {
+		for _, other := range targets {
+			if other == t || (t.ID != "" && other.ID == t.ID) {
+				return false
+			}
+		}
+	}
+	if jr.targetMap[jobID] == nil {
+		jr.targetMap[jobID] = make(map[int][]*target.Target)
+	}
+	targets := jr.targetMap[jobID][testIndex]
+	// the slice may be in use by the test runner, do not modify it in place
+	updated := make([]*target.Target, 0, len(targets)+1)
+	jr.targetMap[jobID][testIndex] = append(append(updated, targets...), t)
+	return true
+}
+
+// DetachTarget detaches a target from the test of a job running on it, e.g. to
+// repair it without cancelling the job. The target is unlocked right away,
+// and leaves the test without a result as soon as it is out of the TestStep
+// it is in, if any. It returns the detached target.
+func (jr *JobRunner) DetachTarget(jobID types.JobID, targetID string) (*target.Target, error) {
+	jr.targetLock.Lock()
+	defer jr.targetLock.Unlock()
+	if len(jr.testRunners[jobID]) == 0 {
+		return nil, fmt.Errorf("job %d is not running a test", jobID)
+	}
+	for testIndex, tr := range jr.testRunners[jobID] {
+		targets := jr.targetMap[jobID][testIndex]
+		for idx, t := range targets {
+			if t.ID != targetID {
+				continue
+			}
+			// the slice may be in use by the test runner, do not modify it in
+			// place
+			remaining := make([]*target.Target, 0, len(targets)-1)
+			jr.targetMap[jobID][testIndex] = append(append(remaining, targets[:idx]...), targets[idx+1:]...)
+			delete(jr.lockedTargets[jobID][testIndex], t.ID)
+			tr.Detach(t)
+			if err := jr.targetLocker.Unlock(jobID, []*target.Target{t}); err != nil {
+				jobLog.Warningf("Failed to unlock detached target %s: %v", t, err)
+			}
+			jobLog.Infof("Target %s detached from test #%d of job %d", t, testIndex, jobID)
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTargetNotInJob, targetID)
+}
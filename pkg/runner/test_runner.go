@@ -8,6 +8,7 @@ package runner
 import (
 	"container/list"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sync"
@@ -51,6 +52,9 @@ type routingCh struct {
 	// targetErr connects the routing block directly to the TestRunner. Failing
 	// targets are acquired by the TestRunner via this channel
 	targetErr chan<- cerrors.TargetError
+	// targetDetached connects the routing block directly to the TestRunner.
+	// Detached targets leave the pipeline via this channel
+	targetDetached chan<- *target.Target
 }
 
 // stepCh represents a set of bidirectional channels that a TestStep and its associated
@@ -92,6 +96,10 @@ type completionCh struct {
 	stepResultCh    <-chan stepResult
 	targetOut       <-chan *target.Target
 	targetErr       <-chan cerrors.TargetError
+	// targetJoined carries the targets joining the running test, and is
+	// closed when no more targets will join
+	targetJoined   <-chan *target.Target
+	targetDetached <-chan *target.Target
 }
 
 // TestRunner is the main runner of TestSteps in ConTest. `results` collects
@@ -99,6 +107,18 @@ type completionCh struct {
 type TestRunner struct {
 	state    *RunnerState
 	timeouts TestRunnerTimeouts
+
+	// skip and resumeSteps are only populated when resuming a test, and are
+	// read-only once the pipeline is running. skip maps the label of a
+	// TestStep to the targets which its routing block has to forward straight
+	// to the next routing block, as they are resumed into a later TestStep.
+	// resumeSteps holds the labels of the TestSteps which had targets in them
+	// when the test was paused.
+	skip        map[string]map[*target.Target]bool
+	resumeSteps map[string]bool
+
+	// join, if set, streams the targets joining the test while it runs
+	join <-chan *target.Target
 }
 
 // WriteTargetErrorTimeout writes a TargetError object to a TargetError channel with timeout
@@ -175,6 +195,25 @@ func (tr *TestRunner) Route(terminateRoute <-chan struct{}, bundle test.TestStep
 		injectionWg   sync.WaitGroup
 	)
 
+	// injectNext starts injecting the next buffered target, if any. The
+	// targets which were detached in the meantime leave the pipeline instead.
+	injectNext := func() error {
+		for targets.Len() > 0 {
+			t := targets.Remove(targets.Back()).(*target.Target)
+			if tr.state.DetachRequested(t) {
+				if err := tr.detachTarget(terminateRoute, bundle, t, routingCh.targetDetached, ev); err != nil {
+					return err
+				}
+				continue
+			}
+			pendingTarget = t
+			injectionWg.Add(1)
+			go tr.InjectTarget(terminateInjection, pendingTarget, injectionChannels, &injectionWg)
+			return nil
+		}
+		return nil
+	}
+
 	for {
 		select {
 		case <-terminateRoute:
@@ -195,27 +234,27 @@ func (tr *TestRunner) Route(terminateRoute <-chan struct{}, bundle test.TestStep
 			if err := ev.Emit(targetInEv); err != nil {
 				log.Warningf("Could not emit %v event for Target: %v", targetInEv, *injectionResult.target)
 			}
-			if targets.Len() > 0 {
-				pendingTarget = targets.Back().Value.(*target.Target)
-				targets.Remove(targets.Back())
-				injectionWg.Add(1)
-				go tr.InjectTarget(terminateInjection, pendingTarget, injectionChannels, &injectionWg)
-			}
+			err = injectNext()
 		case t, chanIsOpen := <-tRouteIn:
 			if !chanIsOpen {
 				// The previous routing block has closed our input channel, signaling that
 				// no more Targets will come through. Block reading from this channel
 				tRouteIn = nil
+			} else if tr.skip[bundle.TestStepLabel][t] {
+				// The test is being resumed, and the target was paused in a later
+				// TestStep. Forward it straight to the next routing block.
+				if err = tr.WriteTargetTimeout(terminateRoute, routingCh.routeOut, t, tr.timeouts.MessageTimeout); err != nil {
+					err = fmt.Errorf("routing failed while forwarding a resumed target: %v", err)
+					break
+				}
 			} else {
+				tr.state.SetTargetStep(t, bundle.TestStepLabel)
 				// Buffer the target and check if there is already an injection in progress.
 				// If so, pending targets will be dequeued only at the next result available
 				// on `injectResultCh`.
 				targets.PushFront(t)
 				if pendingTarget == nil {
-					pendingTarget = targets.Back().Value.(*target.Target)
-					targets.Remove(targets.Back())
-					injectionWg.Add(1)
-					go tr.InjectTarget(terminateInjection, pendingTarget, injectionChannels, &injectionWg)
+					err = injectNext()
 				}
 			}
 		case t, chanIsOpen := <-tStepOut:
@@ -231,9 +270,12 @@ func (tr *TestRunner) Route(terminateRoute <-chan struct{}, bundle test.TestStep
 				if err := ev.Emit(targetOutEv); err != nil {
 					log.Warningf("Could not emit %v event for Target: %v", targetOutEv, *t)
 				}
-				// Register egress time and forward target to the next routing block
+				// Register egress time and forward target to the next routing
+				// block, unless it was detached
 				egressTarget[t] = time.Now()
-				if err := tr.WriteTargetTimeout(terminateRoute, routingCh.routeOut, t, tr.timeouts.MessageTimeout); err != nil {
+				if tr.state.DetachRequested(t) {
+					err = tr.detachTarget(terminateRoute, bundle, t, routingCh.targetDetached, ev)
+				} else if err := tr.WriteTargetTimeout(terminateRoute, routingCh.routeOut, t, tr.timeouts.MessageTimeout); err != nil {
 					log.Panicf("step %s: could not forward target to the TestRunner: %+v", bundle.TestStepLabel, err)
 				}
 			}
@@ -246,6 +288,7 @@ func (tr *TestRunner) Route(terminateRoute <-chan struct{}, bundle test.TestStep
 					break
 				}
 				// Emit an event signaling that the target has lef the TestStep with an error
+				tr.state.SetTargetCompleted(targetError.Target, targetError.Err)
 				payload := json.RawMessage(fmt.Sprintf(`{"error": "%s"}`, targetError.Err))
 				targetErrEv := testevent.Data{EventName: target.EventTargetErr, Target: targetError.Target, TestStepIndex: bundle.TestStepIndex, Payload: &payload}
 				if err := ev.Emit(targetErrEv); err != nil {
@@ -317,6 +360,19 @@ func (tr *TestRunner) Route(terminateRoute <-chan struct{}, bundle test.TestStep
 	}
 }
 
+// detachTarget takes a detached target out of the pipeline, handing it to the
+// TestRunner
+func (tr *TestRunner) detachTarget(terminate <-chan struct{}, bundle test.TestStepBundle, t *target.Target, ch chan<- *target.Target, ev testevent.Emitter) error {
+	targetDetachedEv := testevent.Data{EventName: target.EventTargetDetached, TestStepIndex: bundle.TestStepIndex, Target: t}
+	if err := ev.Emit(targetDetachedEv); err != nil {
+		log.Warningf("Could not emit %v event for Target: %v", targetDetachedEv, *t)
+	}
+	if err := tr.WriteTargetTimeout(terminate, ch, t, tr.timeouts.MessageTimeout); err != nil {
+		return fmt.Errorf("routing failed while detaching a target: %v", err)
+	}
+	return nil
+}
+
 // RunTestStep runs synchronously a TestStep and peforms sanity checks on the status
 // of the input/output channels on the defer control path. When the TestStep returns,
 // the associated output channels are closed. This signals to the routing subsytem
@@ -354,7 +410,16 @@ func (tr *TestRunner) RunTestStep(cancel, pause <-chan struct{}, bundle test.Tes
 		Out: stepCh.stepOut,
 		Err: stepCh.stepErr,
 	}
-	err := bundle.TestStep.Run(cancel, pause, channels, bundle.Parameters, ev)
+	var err error
+	if tr.resumeSteps[bundle.TestStepLabel] && bundle.TestStep.CanResume() {
+		log.Printf("Resuming step %s", bundle.TestStepLabel)
+		err = bundle.TestStep.Resume(cancel, pause, channels, bundle.Parameters, ev)
+	} else {
+		if tr.resumeSteps[bundle.TestStepLabel] {
+			log.Warningf("step %s does not support resume, running it again on the targets it was processing", bundle.TestStepLabel)
+		}
+		err = bundle.TestStep.Run(cancel, pause, channels, bundle.Parameters, ev)
+	}
 
 	var (
 		cancellationAsserted bool
@@ -524,13 +589,20 @@ func (tr *TestRunner) WaitPipelineTermination(ch completionCh, bundles []test.Te
 // WaitPipelineCompletion reads results coming from results channels until all Targets
 // have completed or an error occurs. If all Targets complete successfully, it checks
 // whether TestSteps and routing blocks have completed as well. If not, returns an
-// error. Termination is signalled via terminate channel.
+// error. Termination is signalled via terminate channel. Targets joining the
+// test are waited for as well, and detached targets count as completed.
 func (tr *TestRunner) WaitPipelineCompletion(terminate <-chan struct{}, ch completionCh, bundles []test.TestStepBundle, targets []*target.Target) error {
 	var err error
+	expected := len(targets)
+	targetJoined, targetOut := ch.targetJoined, ch.targetOut
 	for {
-		if len(tr.state.CompletedTargets()) == len(targets) {
+		completed := len(tr.state.CompletedTargets()) + len(tr.state.DetachedTargets())
+		if targetJoined == nil && completed == expected {
 			break
 		}
+		if err == nil && targetJoined == nil && targetOut == nil {
+			err = fmt.Errorf("not all targets completed, but output channel is closed")
+		}
 		if err != nil {
 			return err
 		}
@@ -547,11 +619,17 @@ func (tr *TestRunner) WaitPipelineCompletion(terminate <-chan struct{}, ch compl
 			tr.state.SetStep(res.bundle.TestStepLabel, res.err)
 		case targetErr := <-ch.targetErr:
 			tr.state.SetTarget(targetErr.Target, targetErr.Err)
-		case target, chanIsOpen := <-ch.targetOut:
+		case target := <-ch.targetDetached:
+			tr.state.SetTargetDetached(target)
+		case _, chanIsOpen := <-targetJoined:
 			if !chanIsOpen {
-				if len(tr.state.CompletedTargets()) != len(targets) {
-					err = fmt.Errorf("not all targets completed, but output channel is closed")
-				}
+				targetJoined = nil
+			} else {
+				expected++
+			}
+		case target, chanIsOpen := <-targetOut:
+			if !chanIsOpen {
+				targetOut = nil
 			} else {
 				tr.state.SetTarget(target, nil)
 			}
@@ -594,12 +672,23 @@ func (tr *TestRunner) Run(cancel, pause <-chan struct{}, t *test.Test, targets [
 	routingResultCh := make(chan routeResult)
 	stepResultCh := make(chan stepResult)
 	targetErrCh := make(chan cerrors.TargetError)
+	targetJoinedCh := make(chan *target.Target)
+	targetDetachedCh := make(chan *target.Target)
 
 	var (
 		routeIn  chan *target.Target
 		routeOut chan *target.Target
 	)
 
+	// Targets which have already completed the test, i.e. when resuming a
+	// test, are not injected into the pipeline
+	var pendingTargets []*target.Target
+	for _, t := range targets {
+		if _, completed := tr.state.CompletedTargets()[t]; !completed {
+			pendingTargets = append(pendingTargets, t)
+		}
+	}
+
 	for r, testStepBundle := range testStepBundles {
 		// Input and output channels for the TestStep
 		stepInCh := make(chan *target.Target)
@@ -612,25 +701,51 @@ func (tr *TestRunner) Run(cancel, pause <-chan struct{}, t *test.Test, targets [
 		// First step of the pipeline
 		if r == 0 {
 			routeIn = make(chan *target.Target)
-			// Spawn a goroutine which injects Targets into the first routing block
-			go func(terminate <-chan struct{}, inputChannel chan<- *target.Target) {
+			// Spawn a goroutine which injects Targets into the first routing
+			// block, and then the ones joining the test as they come
+			go func(terminate <-chan struct{}, inputChannel chan<- *target.Target, joined chan<- *target.Target) {
 				defer close(inputChannel)
-				for _, target := range targets {
+				defer close(joined)
+				for _, target := range pendingTargets {
 					if err := tr.WriteTargetTimeout(terminate, inputChannel, target, tr.timeouts.MessageTimeout); err != nil {
 						log.Panic(fmt.Sprintf("could not inject target %+v into first routing block: %+v", target, err))
 					}
 				}
-			}(terminateInjection, routeIn)
+				if tr.join == nil {
+					return
+				}
+				for {
+					select {
+					case <-terminate:
+						return
+					case target, chanIsOpen := <-tr.join:
+						if !chanIsOpen {
+							return
+						}
+						// the target must be accounted for before it can
+						// complete the test
+						select {
+						case joined <- target:
+						case <-terminate:
+							return
+						}
+						if err := tr.WriteTargetTimeout(terminate, inputChannel, target, tr.timeouts.MessageTimeout); err != nil {
+							log.Panic(fmt.Sprintf("could not inject joining target %+v into first routing block: %+v", target, err))
+						}
+					}
+				}
+			}(terminateInjection, routeIn, targetJoinedCh)
 		}
 
 		stepChannels := stepCh{stepIn: stepInCh, stepErr: stepErrCh, stepOut: stepOutCh}
 		routingChannels := routingCh{
-			routeIn:   routeIn,
-			routeOut:  routeOut,
-			stepIn:    stepInCh,
-			stepErr:   stepErrCh,
-			stepOut:   stepOutCh,
-			targetErr: targetErrCh,
+			routeIn:        routeIn,
+			routeOut:       routeOut,
+			stepIn:         stepInCh,
+			stepErr:        stepErrCh,
+			stepOut:        stepOutCh,
+			targetErr:      targetErrCh,
+			targetDetached: targetDetachedCh,
 		}
 
 		// Build the Header that the the TestStep will be using for emitting events
@@ -656,6 +771,8 @@ func (tr *TestRunner) Run(cancel, pause <-chan struct{}, t *test.Test, targets [
 		stepResultCh:    stepResultCh,
 		targetErr:       targetErrCh,
 		targetOut:       routeOut,
+		targetJoined:    targetJoinedCh,
+		targetDetached:  targetDetachedCh,
 	}
 
 	// errCh collects errors coming from the routines which wait for the Test to complete
@@ -735,6 +852,74 @@ func (tr *TestRunner) Run(cancel, pause <-chan struct{}, t *test.Test, targets [
 	return &testResult, terminationError
 }
 
+// Resume resumes a test which was previously paused, given the state of its
+// targets at the time of pausing. Targets which had already completed the test
+// are not run again, while the others are routed straight to the TestStep they
+// were in, or queued for. TestSteps which had targets in them are resumed via
+// their Resume method if they support it, otherwise they are run again on
+// those targets.
+func (tr *TestRunner) Resume(cancel, pause <-chan struct{}, t *test.Test, states []TargetState, jobID types.JobID) (*test.TestResult, error) {
+	positions := make(map[string]int)
+	for idx, bundle := range t.TestStepsBundles {
+		positions[bundle.TestStepLabel] = idx
+	}
+	tr.skip = make(map[string]map[*target.Target]bool)
+	tr.resumeSteps = make(map[string]bool)
+
+	targets := make([]*target.Target, 0, len(states))
+	for _, state := range states {
+		if state.Target == nil {
+			return nil, errors.New("cannot resume test with nil target")
+		}
+		targets = append(targets, state.Target)
+		if state.Completed {
+			var err error
+			if state.Error != nil {
+				err = errors.New(*state.Error)
+			}
+			tr.state.SetTarget(state.Target, err)
+			continue
+		}
+		if state.TestStepLabel == "" {
+			// the target never entered the pipeline, it starts from the first step
+			continue
+		}
+		position, ok := positions[state.TestStepLabel]
+		if !ok {
+			return nil, fmt.Errorf("cannot resume target %s into unknown step %s", state.Target, state.TestStepLabel)
+		}
+		tr.resumeSteps[state.TestStepLabel] = true
+		for _, bundle := range t.TestStepsBundles[:position] {
+			if _, ok := tr.skip[bundle.TestStepLabel]; !ok {
+				tr.skip[bundle.TestStepLabel] = make(map[*target.Target]bool)
+			}
+			tr.skip[bundle.TestStepLabel][state.Target] = true
+		}
+	}
+	return tr.Run(cancel, pause, t, targets, jobID)
+}
+
+// Join streams the targets joining the test while it runs, which are injected
+// into the first TestStep as they come. The test completes once the channel is
+// closed and all the targets completed. It must be called before Run.
+func (tr *TestRunner) Join(targets <-chan *target.Target) {
+	tr.join = targets
+}
+
+// Detach detaches a target from the running test. The target does not enter
+// any other TestStep, and leaves the test without a result as soon as the
+// TestStep it is in, if any, returns it. It is safe to call concurrently with
+// Run.
+func (tr *TestRunner) Detach(t *target.Target) {
+	tr.state.RequestDetach(t)
+}
+
+// TargetStates returns the position in the pipeline of the given targets. It
+// is used to persist the state of the test when it is paused.
+func (tr *TestRunner) TargetStates(targets []*target.Target) []TargetState {
+	return tr.state.TargetStates(targets)
+}
+
 // NewTestRunner initializes and returns a new TestRunner object. This test
 // runner will use default timeout values
 func NewTestRunner() TestRunner {
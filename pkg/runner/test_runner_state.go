@@ -6,15 +6,44 @@
 package runner
 
 import (
+	"sync"
+
 	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/test"
 )
 
+// TargetState describes the position of a Target in the pipeline of a test.
+// It is used to persist the state of a paused test, so that it can be resumed
+// later on.
+type TargetState struct {
+	Target *target.Target
+	// TestStepLabel is the label of the TestStep the Target is in, or is
+	// queued for. An empty label means that the Target has not entered the
+	// pipeline yet.
+	TestStepLabel string `json:",omitempty"`
+	// Completed indicates whether the Target has left the pipeline, either
+	// successfully or with an error.
+	Completed bool
+	// Error is the error the Target has completed the test with, if any.
+	Error *string `json:",omitempty"`
+}
+
 // RunnerState is a structure that models the current state of the test runner
 type RunnerState struct {
 	completedSteps   map[string]error
 	completedRouting map[string]error
 	completedTargets map[*target.Target]error
+	detachedTargets  map[*target.Target]bool
+
+	// detachRequests holds the targets to detach from the test. They are
+	// requested concurrently with the routing blocks, hence the lock.
+	detachRequests     map[*target.Target]bool
+	detachRequestsLock sync.Mutex
+
+	// targetStates tracks the position of each Target in the pipeline. It is
+	// updated concurrently by the routing blocks, hence the lock.
+	targetStates     map[*target.Target]TargetState
+	targetStatesLock sync.Mutex
 }
 
 // NewRunnerState initializes a RunnerState object.
@@ -23,6 +52,9 @@ func NewRunnerState() *RunnerState {
 	r.completedSteps = make(map[string]error)
 	r.completedRouting = make(map[string]error)
 	r.completedTargets = make(map[*target.Target]error)
+	r.detachedTargets = make(map[*target.Target]bool)
+	r.detachRequests = make(map[*target.Target]bool)
+	r.targetStates = make(map[*target.Target]TargetState)
 	return &r
 }
 
@@ -32,6 +64,35 @@ func (r *RunnerState) CompletedTargets() map[*target.Target]error {
 	return r.completedTargets
 }
 
+// DetachedTargets returns the targets which were detached from the test, and
+// left the pipeline without a result
+func (r *RunnerState) DetachedTargets() map[*target.Target]bool {
+	return r.detachedTargets
+}
+
+// SetTargetDetached records that a target left the pipeline because it was
+// detached
+func (r *RunnerState) SetTargetDetached(target *target.Target) {
+	r.detachedTargets[target] = true
+	r.targetStatesLock.Lock()
+	defer r.targetStatesLock.Unlock()
+	delete(r.targetStates, target)
+}
+
+// RequestDetach flags a target to be detached from the test
+func (r *RunnerState) RequestDetach(target *target.Target) {
+	r.detachRequestsLock.Lock()
+	defer r.detachRequestsLock.Unlock()
+	r.detachRequests[target] = true
+}
+
+// DetachRequested returns whether a target was flagged to be detached
+func (r *RunnerState) DetachRequested(target *target.Target) bool {
+	r.detachRequestsLock.Lock()
+	defer r.detachRequestsLock.Unlock()
+	return r.detachRequests[target]
+}
+
 // CompletedRouting returns a map that associates each routing block with its returning error.
 // If the routing block succeeded, the error will be nil
 func (r *RunnerState) CompletedRouting() map[string]error {
@@ -52,6 +113,44 @@ func (r *RunnerState) SetRouting(testStepLabel string, err error) {
 // SetTarget sets the error associated with a target
 func (r *RunnerState) SetTarget(target *target.Target, err error) {
 	r.completedTargets[target] = err
+	r.SetTargetCompleted(target, err)
+}
+
+// SetTargetStep records the label of the TestStep a target is in, or is queued for
+func (r *RunnerState) SetTargetStep(target *target.Target, testStepLabel string) {
+	r.targetStatesLock.Lock()
+	defer r.targetStatesLock.Unlock()
+	r.targetStates[target] = TargetState{Target: target, TestStepLabel: testStepLabel}
+}
+
+// SetTargetCompleted records that a target has left the pipeline, possibly
+// with an error
+func (r *RunnerState) SetTargetCompleted(target *target.Target, err error) {
+	r.targetStatesLock.Lock()
+	defer r.targetStatesLock.Unlock()
+	state := TargetState{Target: target, Completed: true}
+	if err != nil {
+		errStr := err.Error()
+		state.Error = &errStr
+	}
+	r.targetStates[target] = state
+}
+
+// TargetStates returns the position in the pipeline of the given targets.
+// Targets for which no position has been recorded are reported as not having
+// entered the pipeline yet.
+func (r *RunnerState) TargetStates(targets []*target.Target) []TargetState {
+	r.targetStatesLock.Lock()
+	defer r.targetStatesLock.Unlock()
+	states := make([]TargetState, 0, len(targets))
+	for _, t := range targets {
+		state, ok := r.targetStates[t]
+		if !ok {
+			state = TargetState{Target: t}
+		}
+		states = append(states, state)
+	}
+	return states
 }
 
 // SetStep sets the error associated with a step
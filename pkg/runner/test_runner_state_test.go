@@ -0,0 +1,43 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/facebookincubator/contest/pkg/target"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnerStateTargetStates(t *testing.T) {
+	targets := []*target.Target{
+		&target.Target{Name: "host001", ID: "001"},
+		&target.Target{Name: "host002", ID: "002"},
+		&target.Target{Name: "host003", ID: "003"},
+		&target.Target{Name: "host004", ID: "004"},
+	}
+	state := NewRunnerState()
+	state.SetTargetStep(targets[0], "FirstStage")
+	state.SetTargetStep(targets[1], "FirstStage")
+	state.SetTargetStep(targets[1], "SecondStage")
+	state.SetTargetCompleted(targets[2], errors.New("target failed"))
+	state.SetTarget(targets[3], nil)
+
+	states := state.TargetStates(targets)
+	require.Equal(t, 4, len(states))
+	require.Equal(t, TargetState{Target: targets[0], TestStepLabel: "FirstStage"}, states[0])
+	require.Equal(t, TargetState{Target: targets[1], TestStepLabel: "SecondStage"}, states[1])
+	require.True(t, states[2].Completed)
+	require.NotNil(t, states[2].Error)
+	require.Equal(t, "target failed", *states[2].Error)
+	require.Equal(t, TargetState{Target: targets[3], Completed: true}, states[3])
+
+	// targets with no recorded position have not entered the pipeline yet
+	unknown := &target.Target{Name: "host005", ID: "005"}
+	require.Equal(t, []TargetState{TargetState{Target: unknown}}, state.TargetStates([]*target.Target{unknown}))
+}
@@ -28,13 +28,16 @@ type TestEventEmitterFetcher struct {
 	TestEventFetcher
 }
 
-// Emit emits an event using the selected storage layer
+// Emit emits an event using the selected storage layer, and publishes it to
+// the subscribers of the job
 func (e TestEventEmitter) Emit(data testevent.Data) error {
 	event := testevent.Event{Header: &e.header, Data: &data, EmitTime: time.Now()}
-	if err := storage.StoreTestEvent(event); err != nil {
-		return fmt.Errorf("could not persist event data %v: %v", data, err)
-	}
-	return nil
+	return broker.emit(e.header.JobID, StreamEvent{TestEvent: &event}, func() error {
+		if err := storage.StoreTestEvent(event); err != nil {
+			return fmt.Errorf("could not persist event data %v: %v", data, err)
+		}
+		return nil
+	})
 }
 
 // Fetch retrieves events based on QueryFields that are used to build a Query object for TestEvents
@@ -78,12 +81,15 @@ type FrameworkEventEmitterFetcher struct {
 	FrameworkEventFetcher
 }
 
-// Emit emits an event using the selected storage engine
+// Emit emits an event using the selected storage engine, and publishes it to
+// the subscribers of the job
 func (ev FrameworkEventEmitter) Emit(event frameworkevent.Event) error {
-	if err := storage.StoreFrameworkEvent(event); err != nil {
-		return fmt.Errorf("could not persist event %v: %v", event, err)
-	}
-	return nil
+	return broker.emit(event.JobID, StreamEvent{FrameworkEvent: &event}, func() error {
+		if err := storage.StoreFrameworkEvent(event); err != nil {
+			return fmt.Errorf("could not persist event %v: %v", event, err)
+		}
+		return nil
+	})
 }
 
 // Fetch retrieves events based on QueryFields that are used to build a Query object for FrameworkEvents
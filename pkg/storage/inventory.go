@@ -0,0 +1,64 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/lib/selector"
+	"github.com/facebookincubator/contest/pkg/target"
+)
+
+// TargetInventory persists and retrieves the targets known to ConTest,
+// together with their attributes.
+type TargetInventory struct {
+}
+
+// Store adds targets to the inventory, replacing the ones with the same ID
+func (i TargetInventory) Store(targets []*target.Target) error {
+	for _, t := range targets {
+		if t.ID == "" {
+			return fmt.Errorf("cannot store target %s in the inventory: empty target ID", t.Name)
+		}
+	}
+	if err := storage.StoreTargets(targets); err != nil {
+		return fmt.Errorf("could not store targets: %v", err)
+	}
+	return nil
+}
+
+// Select returns the targets of the inventory whose attributes match the
+// selector, sorted by ID
+func (i TargetInventory) Select(sel selector.Selector) ([]*target.Target, error) {
+	targets, err := storage.GetTargets()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch targets: %v", err)
+	}
+	var selected []*target.Target
+	for _, t := range targets {
+		if sel.Matches(t.Attributes) {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// Delete removes targets from the inventory
+func (i TargetInventory) Delete(targetIDs []string) error {
+	if len(targetIDs) == 0 {
+		return errors.New("no target to delete")
+	}
+	if err := storage.DeleteTargets(targetIDs); err != nil {
+		return fmt.Errorf("could not delete targets: %v", err)
+	}
+	return nil
+}
+
+// NewTargetInventory creates a TargetInventory object
+func NewTargetInventory() TargetInventory {
+	return TargetInventory{}
+}
@@ -7,6 +7,7 @@ package storage
 import (
 	"fmt"
 
+	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/types"
 )
@@ -40,11 +41,20 @@ func (rc JobRequestEmitter) Emit(request *job.Request) (types.JobID, error) {
 func (rf JobRequestFetcher) Fetch(jobID types.JobID) (*job.Request, error) {
 	request, err := storage.GetJobRequest(jobID)
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch job request: %v", err)
+		return nil, fmt.Errorf("could not fetch job request: %w", err)
 	}
 	return request, nil
 }
 
+// FetchByTag fetches from storage all the Job requests with the given tag
+func (rf JobRequestFetcher) FetchByTag(tag string) ([]*job.Request, error) {
+	requests, err := storage.GetJobRequestsByTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch job requests with tag '%s': %v", tag, err)
+	}
+	return requests, nil
+}
+
 // NewJobRequestEmitter creates a JobRequestEmitter object
 func NewJobRequestEmitter() job.RequestEmitter {
 	return JobRequestEmitter{}
@@ -62,3 +72,23 @@ func NewJobRequestEmitterFetcher() job.RequestEmitterFetcher {
 		JobRequestFetcher{},
 	}
 }
+
+// JobLister implements the Lister interface from the job package
+type JobLister struct {
+	stateEvents []event.Name
+}
+
+// List returns the jobs in storage matching the query
+func (jl JobLister) List(query *job.ListQuery) ([]job.Summary, error) {
+	jobs, err := storage.ListJobs(query, jl.stateEvents)
+	if err != nil {
+		return nil, fmt.Errorf("could not list jobs: %v", err)
+	}
+	return jobs, nil
+}
+
+// NewJobLister creates a JobLister object. The state of a job is determined by
+// the latest of its framework events whose name is in stateEvents.
+func NewJobLister(stateEvents []event.Name) job.Lister {
+	return JobLister{stateEvents: stateEvents}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// JobPauseStateEmitterFetcher persists and retrieves the state of paused jobs,
+// so that they can be resumed by a different ConTest instance. The state is
+// serialized to JSON before being handed over to the storage engine.
+type JobPauseStateEmitterFetcher struct {
+}
+
+// Emit persists the pause state of a job, replacing any previous one
+func (p JobPauseStateEmitterFetcher) Emit(jobID types.JobID, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not serialize pause state of job %d: %v", jobID, err)
+	}
+	if err := storage.StoreJobPauseState(jobID, data); err != nil {
+		return fmt.Errorf("could not store pause state of job %d: %v", jobID, err)
+	}
+	return nil
+}
+
+// FetchAll fetches the serialized pause state of all the paused jobs, indexed
+// by job ID
+func (p JobPauseStateEmitterFetcher) FetchAll() (map[types.JobID]json.RawMessage, error) {
+	states, err := storage.GetJobPauseStates()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch pause states: %v", err)
+	}
+	ret := make(map[types.JobID]json.RawMessage, len(states))
+	for jobID, state := range states {
+		ret[jobID] = json.RawMessage(state)
+	}
+	return ret, nil
+}
+
+// Delete removes the pause state of a job, e.g. once it has been resumed
+func (p JobPauseStateEmitterFetcher) Delete(jobID types.JobID) error {
+	if err := storage.DeleteJobPauseState(jobID); err != nil {
+		return fmt.Errorf("could not delete pause state of job %d: %v", jobID, err)
+	}
+	return nil
+}
+
+// NewJobPauseStateEmitterFetcher creates a JobPauseStateEmitterFetcher object
+func NewJobPauseStateEmitterFetcher() JobPauseStateEmitterFetcher {
+	return JobPauseStateEmitterFetcher{}
+}
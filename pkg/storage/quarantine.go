@@ -0,0 +1,92 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/target"
+)
+
+// ErrTargetNotQuarantined is returned, possibly wrapped, when releasing a
+// target which is not quarantined.
+var ErrTargetNotQuarantined = errors.New("target is not quarantined")
+
+// TargetQuarantine persists the targets which failed too many of their recent
+// runs, and which must not be handed to jobs until they are released.
+type TargetQuarantine struct {
+}
+
+// Quarantine puts a target in quarantine
+func (q TargetQuarantine) Quarantine(t *target.Target, reason string) error {
+	if t.ID == "" {
+		return fmt.Errorf("cannot quarantine target %s: empty target ID", t.Name)
+	}
+	qt := target.QuarantinedTarget{
+		ID:             t.ID,
+		Name:           t.Name,
+		Reason:         reason,
+		QuarantineTime: time.Now(),
+	}
+	if err := storage.StoreQuarantinedTarget(&qt); err != nil {
+		return fmt.Errorf("could not quarantine target %s: %v", t.ID, err)
+	}
+	return nil
+}
+
+// Release releases a target from quarantine. The record of the target is
+// kept, so that its runs before the release can be told apart.
+func (q TargetQuarantine) Release(targetID string) (*target.QuarantinedTarget, error) {
+	qt, err := q.Get(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if qt == nil || qt.Released() {
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotQuarantined, targetID)
+	}
+	qt.ReleaseTime = time.Now()
+	if err := storage.StoreQuarantinedTarget(qt); err != nil {
+		return nil, fmt.Errorf("could not release target %s: %v", targetID, err)
+	}
+	return qt, nil
+}
+
+// Get returns the quarantine record of a target, which may have been
+// released, or nil if the target was never quarantined
+func (q TargetQuarantine) Get(targetID string) (*target.QuarantinedTarget, error) {
+	records, err := storage.GetQuarantinedTargets()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch quarantined targets: %v", err)
+	}
+	for _, qt := range records {
+		if qt.ID == targetID {
+			return qt, nil
+		}
+	}
+	return nil, nil
+}
+
+// List returns the targets which are currently quarantined, sorted by ID
+func (q TargetQuarantine) List() ([]*target.QuarantinedTarget, error) {
+	records, err := storage.GetQuarantinedTargets()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch quarantined targets: %v", err)
+	}
+	var quarantined []*target.QuarantinedTarget
+	for _, qt := range records {
+		if !qt.Released() {
+			quarantined = append(quarantined, qt)
+		}
+	}
+	return quarantined, nil
+}
+
+// NewTargetQuarantine creates a TargetQuarantine object
+func NewTargetQuarantine() TargetQuarantine {
+	return TargetQuarantine{}
+}
@@ -34,6 +34,15 @@ func (e JobReportEmitter) Emit(jobReport *job.JobReport) error {
 	return nil
 }
 
+// EmitRunReports emits the reports of a run, whose number starts at 1, using
+// the selected storage layer
+func (e JobReportEmitter) EmitRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) error {
+	if err := storage.StoreRunReports(jobID, runNumber, reports); err != nil {
+		return fmt.Errorf("could not persist reports of run #%d: %v", runNumber, err)
+	}
+	return nil
+}
+
 // Fetch retrieves job report objects based on JobID
 func (ev JobReportFetcher) Fetch(jobID types.JobID) (*job.JobReport, error) {
 	report, err := storage.GetJobReport(jobID)
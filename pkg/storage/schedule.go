@@ -0,0 +1,70 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// ErrScheduleNotFound is returned, possibly wrapped, when the requested
+// schedule does not exist.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ScheduleEmitterFetcher persists and retrieves the schedules of recurring
+// jobs, so that they survive restarts.
+type ScheduleEmitterFetcher struct {
+}
+
+// Emit persists a schedule. A schedule with a zero ID is created, and gets a
+// new ID, otherwise the schedule with the same ID is replaced, unless it was
+// deleted.
+func (s ScheduleEmitterFetcher) Emit(schedule *job.Schedule) (types.ScheduleID, error) {
+	scheduleID, err := storage.StoreSchedule(schedule)
+	if err != nil {
+		return 0, fmt.Errorf("could not store schedule: %v", err)
+	}
+	return scheduleID, nil
+}
+
+// Fetch fetches a schedule by its ID
+func (s ScheduleEmitterFetcher) Fetch(scheduleID types.ScheduleID) (*job.Schedule, error) {
+	schedules, err := s.FetchAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		if schedule.ID == scheduleID {
+			return schedule, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrScheduleNotFound, scheduleID)
+}
+
+// FetchAll fetches all the schedules, sorted by ID
+func (s ScheduleEmitterFetcher) FetchAll() ([]*job.Schedule, error) {
+	schedules, err := storage.GetSchedules()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// Delete deletes a schedule
+func (s ScheduleEmitterFetcher) Delete(scheduleID types.ScheduleID) error {
+	if err := storage.DeleteSchedule(scheduleID); err != nil {
+		return fmt.Errorf("could not delete schedule %d: %v", scheduleID, err)
+	}
+	return nil
+}
+
+// NewScheduleEmitterFetcher creates a ScheduleEmitterFetcher object
+func NewScheduleEmitterFetcher() ScheduleEmitterFetcher {
+	return ScheduleEmitterFetcher{}
+}
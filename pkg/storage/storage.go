@@ -6,12 +6,20 @@
 package storage
 
 import (
+	"errors"
+
+	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
 	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
+// ErrJobNotFound is returned, possibly wrapped, by storage engines when the
+// requested job does not exist.
+var ErrJobNotFound = errors.New("job not found")
+
 // storage defines the events storage engine used by ConTest. It can be overridden
 // via the exported function SetStorage and it can be retrieved via the exported
 // function GetStorage
@@ -30,11 +38,44 @@ type Storage interface {
 	// Job request interface
 	StoreJobRequest(request *job.Request) (types.JobID, error)
 	GetJobRequest(jobID types.JobID) (*job.Request, error)
+	GetJobRequestsByTag(tag string) ([]*job.Request, error)
+	// ListJobs returns the jobs matching the query. The state of a job is the
+	// latest of its framework events whose name is in stateEvents.
+	ListJobs(query *job.ListQuery, stateEvents []event.Name) ([]job.Summary, error)
 
-	// Job report interface
+	// Job report interface. The reports of each run are stored as soon as the
+	// run completes, with StoreRunReports, while StoreJobReport adds the
+	// reports it is given to the ones already stored for the job.
 	StoreJobReport(report *job.JobReport) error
+	StoreRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) error
 	GetJobReport(jobID types.JobID) (*job.JobReport, error)
 
+	// Job pause state interface. The state is serialized by the caller and
+	// is opaque to the storage engine.
+	StoreJobPauseState(jobID types.JobID, state []byte) error
+	GetJobPauseStates() (map[types.JobID][]byte, error)
+	DeleteJobPauseState(jobID types.JobID) error
+
+	// Target inventory interface. Targets are identified by their ID: storing
+	// a target replaces the one with the same ID, attributes included.
+	StoreTargets(targets []*target.Target) error
+	GetTargets() ([]*target.Target, error)
+	DeleteTargets(targetIDs []string) error
+
+	// Target quarantine interface. Targets are identified by their ID: storing
+	// a quarantined target replaces the previous record of the same target.
+	// Records are kept after the targets are released.
+	StoreQuarantinedTarget(qt *target.QuarantinedTarget) error
+	GetQuarantinedTargets() ([]*target.QuarantinedTarget, error)
+
+	// Schedule interface. Storing a schedule with a zero ID creates it, and
+	// returns its new ID, otherwise it replaces the schedule with the same ID,
+	// if it still exists. Deleting a schedule which does not exist is not an
+	// error.
+	StoreSchedule(s *job.Schedule) (types.ScheduleID, error)
+	GetSchedules() ([]*job.Schedule, error)
+	DeleteSchedule(scheduleID types.ScheduleID) error
+
 	// Reset clears the state of the storage layer
 	Reset() error
 }
@@ -0,0 +1,404 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+const (
+	// DefaultStreamHistorySize is the default number of events kept for each
+	// job, so that subscribers can catch up with past events.
+	DefaultStreamHistorySize = 4096
+	// DefaultMaxStreams is the default number of job streams kept by the
+	// broker. When the limit is exceeded, the oldest closed streams without
+	// subscribers are evicted.
+	DefaultMaxStreams = 256
+	// subscriptionBufferSize is the number of live events buffered for each
+	// subscriber. Subscribers which fall further behind are dropped.
+	subscriptionBufferSize = 256
+)
+
+// broker is the EventBroker to which events emitted through the storage
+// emitters are published.
+var broker = NewEventBroker(DefaultStreamHistorySize, DefaultMaxStreams)
+
+// SetEventBroker sets the EventBroker to which events emitted through the
+// storage emitters are published.
+func SetEventBroker(b *EventBroker) {
+	broker = b
+}
+
+// GetEventBroker returns the EventBroker to which events emitted through the
+// storage emitters are published.
+func GetEventBroker() *EventBroker {
+	return broker
+}
+
+// StreamEvent is an event published to the subscribers of a job. Exactly one
+// of TestEvent and FrameworkEvent is set.
+type StreamEvent struct {
+	// ID identifies the position of the event within the events of the job
+	// in storage, see StreamEventID. IDs increase with the events of a job,
+	// and remain valid across restarts of ConTest.
+	ID             uint64
+	TestEvent      *testevent.Event
+	FrameworkEvent *frameworkevent.Event
+}
+
+// StreamEventID returns the ID of the event of a job which is preceded, and
+// included, by testCount test events and frameworkCount framework events of
+// the job in storage.
+func StreamEventID(testCount, frameworkCount uint32) uint64 {
+	return uint64(testCount)<<32 | uint64(frameworkCount)
+}
+
+// streamEventCounts is the inverse of StreamEventID.
+func streamEventCounts(id uint64) (uint32, uint32) {
+	return uint32(id >> 32), uint32(id)
+}
+
+// Subscription delivers the events of a job stream to a subscriber.
+type Subscription struct {
+	// C delivers the events in order. It is closed when the job stream is
+	// closed, or when the subscriber falls behind, in which case Dropped
+	// returns true.
+	C <-chan StreamEvent
+	// Gap is true if some of the requested events were available neither in
+	// the stream history nor in storage when subscribing.
+	Gap bool
+
+	c       chan StreamEvent
+	jobID   types.JobID
+	broker  *EventBroker
+	dropped bool
+	closed  bool
+}
+
+// Dropped returns whether the subscription was terminated because the
+// subscriber could not keep up with the events. The subscriber can subscribe
+// again from the ID of the last event it received.
+func (s *Subscription) Dropped() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.dropped
+}
+
+// Cancel terminates the subscription and closes its channel, if not closed
+// already.
+func (s *Subscription) Cancel() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	if stream, ok := s.broker.streams[s.jobID]; ok {
+		delete(stream.subscribers, s)
+	}
+	s.close()
+}
+
+// close closes the subscription channel. The caller must hold the broker lock.
+func (s *Subscription) close() {
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+}
+
+type jobStream struct {
+	// testCount and frameworkCount are the numbers of test and framework
+	// events of the job in storage, from which the event IDs are derived.
+	testCount      uint32
+	frameworkCount uint32
+	history        []StreamEvent
+	subscribers    map[*Subscription]struct{}
+	closed         bool
+}
+
+// EventBroker implements a publish/subscribe mechanism for the events of a
+// job, so that they can be followed live, without polling storage. The broker
+// keeps a bounded history of the events of each job, which subscribers can
+// catch up with, e.g. after reconnecting. Older events are fetched from
+// storage.
+type EventBroker struct {
+	// emitMu serializes the storing and publishing of events, so that the
+	// event IDs follow the order of the events in storage. It is acquired
+	// before mu.
+	emitMu      sync.Mutex
+	mu          sync.Mutex
+	historySize int
+	maxStreams  int
+	streams     map[types.JobID]*jobStream
+	// order is the creation order of the streams, used for eviction
+	order []types.JobID
+	// fetch returns the test and framework events of a job in storage, in
+	// the order they were stored.
+	fetch func(jobID types.JobID) ([]testevent.Event, []frameworkevent.Event, error)
+}
+
+// NewEventBroker creates an EventBroker which keeps up to historySize events
+// for each job, and up to maxStreams job streams.
+func NewEventBroker(historySize, maxStreams int) *EventBroker {
+	return &EventBroker{
+		historySize: historySize,
+		maxStreams:  maxStreams,
+		streams:     make(map[types.JobID]*jobStream),
+		fetch:       fetchJobEvents,
+	}
+}
+
+// fetchJobEvents returns the events of a job in the selected storage engine.
+func fetchJobEvents(jobID types.JobID) ([]testevent.Event, []frameworkevent.Event, error) {
+	if storage == nil {
+		return nil, nil, nil
+	}
+	testQuery := testevent.Query{}
+	testevent.QueryJobID(jobID)(&testQuery)
+	testEvents, err := storage.GetTestEvents(&testQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	frameworkQuery := frameworkevent.Query{}
+	frameworkevent.QueryJobID(jobID)(&frameworkQuery)
+	frameworkEvents, err := storage.GetFrameworkEvent(&frameworkQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	return testEvents, frameworkEvents, nil
+}
+
+// stream returns the stream of a job, creating it if necessary from the
+// events of the job in storage. The caller must hold emitMu, but not mu.
+func (b *EventBroker) stream(jobID types.JobID) (*jobStream, error) {
+	b.mu.Lock()
+	stream, ok := b.streams[jobID]
+	b.mu.Unlock()
+	if ok {
+		return stream, nil
+	}
+	testEvents, frameworkEvents, err := b.fetch(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the events of job %d: %v", jobID, err)
+	}
+	stream = &jobStream{
+		testCount:      uint32(len(testEvents)),
+		frameworkCount: uint32(len(frameworkEvents)),
+		subscribers:    make(map[*Subscription]struct{}),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.streams[jobID] = stream
+	b.order = append(b.order, jobID)
+	b.evict()
+	return stream, nil
+}
+
+// evict removes the oldest closed streams without subscribers, until the
+// number of streams is within the limit. The caller must hold the lock.
+func (b *EventBroker) evict() {
+	for idx := 0; idx < len(b.order) && len(b.streams) > b.maxStreams; {
+		jobID := b.order[idx]
+		stream := b.streams[jobID]
+		if stream.closed && len(stream.subscribers) == 0 {
+			delete(b.streams, jobID)
+			b.order = append(b.order[:idx], b.order[idx+1:]...)
+			continue
+		}
+		idx++
+	}
+}
+
+// emit stores an event with store, unless it is nil, and publishes it to the
+// subscribers of its job.
+func (b *EventBroker) emit(jobID types.JobID, ev StreamEvent, store func() error) error {
+	b.emitMu.Lock()
+	defer b.emitMu.Unlock()
+	// the stream is loaded before storing the event, which it would count
+	// otherwise.
+	stream, err := b.stream(jobID)
+	if store != nil {
+		if storeErr := store(); storeErr != nil {
+			return storeErr
+		}
+		if err != nil {
+			// the event is persisted, and will be counted when the stream
+			// is loaded.
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	b.publish(stream, ev)
+	return nil
+}
+
+// publish assigns the next ID of a stream to an event, and delivers it to the
+// subscribers. The caller must hold emitMu.
+func (b *EventBroker) publish(stream *jobStream, ev StreamEvent) {
+	if ev.TestEvent != nil {
+		stream.testCount++
+	} else {
+		stream.frameworkCount++
+	}
+	ev.ID = StreamEventID(stream.testCount, stream.frameworkCount)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// events emitted after the end of a job, e.g. by a late plugin, are only
+	// delivered to later subscribers, as closed streams have none.
+	stream.history = append(stream.history, ev)
+	if len(stream.history) > b.historySize {
+		stream.history = stream.history[len(stream.history)-b.historySize:]
+	}
+	for sub := range stream.subscribers {
+		select {
+		case sub.c <- ev:
+		default:
+			// the subscriber is too slow, drop it rather than blocking the
+			// emitters.
+			sub.dropped = true
+			delete(stream.subscribers, sub)
+			sub.close()
+		}
+	}
+}
+
+// PublishTestEvent publishes a test event to the subscribers of its job,
+// without storing it.
+func (b *EventBroker) PublishTestEvent(ev testevent.Event) error {
+	if ev.Header == nil {
+		return nil
+	}
+	return b.emit(ev.Header.JobID, StreamEvent{TestEvent: &ev}, nil)
+}
+
+// PublishFrameworkEvent publishes a framework event to the subscribers of its
+// job, without storing it.
+func (b *EventBroker) PublishFrameworkEvent(ev frameworkevent.Event) error {
+	return b.emit(ev.JobID, StreamEvent{FrameworkEvent: &ev}, nil)
+}
+
+// Subscribe subscribes to the events of a job which follow the event whose ID
+// is afterID, or all of them if afterID is 0. Past events are delivered
+// first, from the history or, if they are older, from storage.
+func (b *EventBroker) Subscribe(jobID types.JobID, afterID uint64) (*Subscription, error) {
+	b.emitMu.Lock()
+	defer b.emitMu.Unlock()
+	stream, err := b.stream(jobID)
+	if err != nil {
+		return nil, err
+	}
+	pending, gap, err := b.pending(jobID, stream, afterID)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := make(chan StreamEvent, len(pending)+subscriptionBufferSize)
+	sub := &Subscription{C: c, c: c, Gap: gap, jobID: jobID, broker: b}
+	for _, ev := range pending {
+		c <- ev
+	}
+	if stream.closed {
+		sub.close()
+	} else {
+		stream.subscribers[sub] = struct{}{}
+	}
+	return sub, nil
+}
+
+// pending returns the events of a stream which follow afterID, and whether
+// some of them are missing. The caller must hold emitMu.
+func (b *EventBroker) pending(jobID types.JobID, stream *jobStream, afterID uint64) ([]StreamEvent, bool, error) {
+	testCount, frameworkCount := streamEventCounts(afterID)
+	// the counts of the events older than the history
+	baseTest, baseFramework := stream.testCount, stream.frameworkCount
+	if len(stream.history) > 0 {
+		first := stream.history[0]
+		baseTest, baseFramework = streamEventCounts(first.ID)
+		if first.TestEvent != nil {
+			baseTest--
+		} else {
+			baseFramework--
+		}
+	}
+	var (
+		pending []StreamEvent
+		gap     bool
+	)
+	if testCount < baseTest || frameworkCount < baseFramework {
+		events, ok, err := b.backfill(jobID, testCount, frameworkCount, baseTest, baseFramework)
+		if err != nil {
+			return nil, false, err
+		}
+		pending, gap = events, !ok
+	}
+	for _, ev := range stream.history {
+		evTest, evFramework := streamEventCounts(ev.ID)
+		if (ev.TestEvent != nil && evTest > testCount) || (ev.FrameworkEvent != nil && evFramework > frameworkCount) {
+			pending = append(pending, ev)
+		}
+	}
+	return pending, gap, nil
+}
+
+// backfill returns the events of a job in storage which follow the given
+// counts and precede the base counts, ordered by emission time. ok is false
+// if storage does not hold them, e.g. because they were not stored.
+func (b *EventBroker) backfill(jobID types.JobID, testCount, frameworkCount, baseTest, baseFramework uint32) ([]StreamEvent, bool, error) {
+	testEvents, frameworkEvents, err := b.fetch(jobID)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not fetch the events of job %d: %v", jobID, err)
+	}
+	if uint32(len(testEvents)) < baseTest || uint32(len(frameworkEvents)) < baseFramework {
+		return nil, false, nil
+	}
+	if testCount > baseTest {
+		testCount = baseTest
+	}
+	if frameworkCount > baseFramework {
+		frameworkCount = baseFramework
+	}
+	testEvents = testEvents[testCount:baseTest]
+	frameworkEvents = frameworkEvents[frameworkCount:baseFramework]
+	var events []StreamEvent
+	for len(testEvents) > 0 || len(frameworkEvents) > 0 {
+		if len(frameworkEvents) == 0 || (len(testEvents) > 0 && testEvents[0].EmitTime.Before(frameworkEvents[0].EmitTime)) {
+			testCount++
+			events = append(events, StreamEvent{ID: StreamEventID(testCount, frameworkCount), TestEvent: &testEvents[0]})
+			testEvents = testEvents[1:]
+		} else {
+			frameworkCount++
+			events = append(events, StreamEvent{ID: StreamEventID(testCount, frameworkCount), FrameworkEvent: &frameworkEvents[0]})
+			frameworkEvents = frameworkEvents[1:]
+		}
+	}
+	return events, true, nil
+}
+
+// Close closes the stream of a job, e.g. when the job terminates. Current
+// subscribers receive the pending events and then see their channel closed.
+// Subscribing to a closed stream delivers its past events only.
+func (b *EventBroker) Close(jobID types.JobID) {
+	b.emitMu.Lock()
+	defer b.emitMu.Unlock()
+	stream, err := b.stream(jobID)
+	if err != nil {
+		// the stream will fail to load when subscribing too.
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stream.closed = true
+	for sub := range stream.subscribers {
+		delete(stream.subscribers, sub)
+		sub.close()
+	}
+	b.evict()
+}
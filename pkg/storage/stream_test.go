@@ -0,0 +1,182 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func publishN(b *EventBroker, jobID types.JobID, n int) {
+	for i := 0; i < n; i++ {
+		b.PublishFrameworkEvent(frameworkevent.Event{JobID: jobID, EventName: event.Name("SomeEvent")})
+	}
+}
+
+// receive returns the IDs of the events delivered by a subscription until its
+// channel is closed.
+func receive(sub *Subscription) []uint64 {
+	var ids []uint64
+	for ev := range sub.C {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func TestEventBrokerLive(t *testing.T) {
+	b := NewEventBroker(10, 10)
+	sub, err := b.Subscribe(1, 0)
+	require.NoError(t, err)
+	require.False(t, sub.Gap)
+	b.PublishTestEvent(testevent.Event{Header: &testevent.Header{JobID: 1}, Data: &testevent.Data{EventName: "TestEvent"}})
+	publishN(b, 1, 1)
+	// events of other jobs are not delivered
+	publishN(b, 2, 1)
+	b.Close(1)
+
+	ev := <-sub.C
+	require.Equal(t, StreamEventID(1, 0), ev.ID)
+	require.NotNil(t, ev.TestEvent)
+	require.Nil(t, ev.FrameworkEvent)
+	ev = <-sub.C
+	require.Equal(t, StreamEventID(1, 1), ev.ID)
+	require.NotNil(t, ev.FrameworkEvent)
+	_, ok := <-sub.C
+	require.False(t, ok)
+	require.False(t, sub.Dropped())
+}
+
+func TestEventBrokerHistory(t *testing.T) {
+	b := NewEventBroker(3, 10)
+	publishN(b, 1, 5)
+
+	// events 1 and 2 are neither in the history nor in storage
+	sub, err := b.Subscribe(1, 0)
+	require.NoError(t, err)
+	require.True(t, sub.Gap)
+	sub.Cancel()
+	require.Equal(t, []uint64{3, 4, 5}, receive(sub))
+
+	sub, err = b.Subscribe(1, 3)
+	require.NoError(t, err)
+	require.False(t, sub.Gap)
+	publishN(b, 1, 1)
+	b.Close(1)
+	require.Equal(t, []uint64{4, 5, 6}, receive(sub))
+
+	// a closed stream only delivers its history
+	sub, err = b.Subscribe(1, 5)
+	require.NoError(t, err)
+	require.False(t, sub.Gap)
+	require.Equal(t, []uint64{6}, receive(sub))
+}
+
+// fakeStorage stores the events emitted through a broker.
+type fakeStorage struct {
+	testEvents      []testevent.Event
+	frameworkEvents []frameworkevent.Event
+}
+
+func (s *fakeStorage) fetch(jobID types.JobID) ([]testevent.Event, []frameworkevent.Event, error) {
+	return s.testEvents, s.frameworkEvents, nil
+}
+
+// emit emits the test events of a job through a broker, each followed by a
+// framework event.
+func (s *fakeStorage) emit(b *EventBroker, jobID types.JobID, n int) {
+	for i := 0; i < n; i++ {
+		testEvent := testevent.Event{Header: &testevent.Header{JobID: jobID}, Data: &testevent.Data{EventName: "TestEvent"}, EmitTime: time.Now()}
+		_ = b.emit(jobID, StreamEvent{TestEvent: &testEvent}, func() error {
+			s.testEvents = append(s.testEvents, testEvent)
+			return nil
+		})
+		frameworkEvent := frameworkevent.Event{JobID: jobID, EventName: "SomeEvent", EmitTime: time.Now()}
+		_ = b.emit(jobID, StreamEvent{FrameworkEvent: &frameworkEvent}, func() error {
+			s.frameworkEvents = append(s.frameworkEvents, frameworkEvent)
+			return nil
+		})
+	}
+}
+
+func TestEventBrokerBackfill(t *testing.T) {
+	s := &fakeStorage{}
+	b := NewEventBroker(2, 10)
+	b.fetch = s.fetch
+	s.emit(b, 1, 3)
+	all := []uint64{
+		StreamEventID(1, 0), StreamEventID(1, 1),
+		StreamEventID(2, 1), StreamEventID(2, 2),
+		StreamEventID(3, 2), StreamEventID(3, 3),
+	}
+
+	// the events older than the history are fetched from storage
+	sub, err := b.Subscribe(1, 0)
+	require.NoError(t, err)
+	require.False(t, sub.Gap)
+	sub.Cancel()
+	require.Equal(t, all, receive(sub))
+
+	sub, err = b.Subscribe(1, all[2])
+	require.NoError(t, err)
+	sub.Cancel()
+	require.Equal(t, all[3:], receive(sub))
+
+	// a new broker, e.g. after a restart, carries on with the same IDs
+	b = NewEventBroker(2, 10)
+	b.fetch = s.fetch
+	sub, err = b.Subscribe(1, all[1])
+	require.NoError(t, err)
+	s.emit(b, 1, 1)
+	b.Close(1)
+	require.False(t, sub.Gap)
+	require.Equal(t, append(all[2:], StreamEventID(4, 3), StreamEventID(4, 4)), receive(sub))
+}
+
+func TestEventBrokerFetchError(t *testing.T) {
+	b := NewEventBroker(10, 10)
+	b.fetch = func(types.JobID) ([]testevent.Event, []frameworkevent.Event, error) {
+		return nil, nil, errors.New("unavailable")
+	}
+	_, err := b.Subscribe(1, 0)
+	require.Error(t, err)
+}
+
+func TestEventBrokerSlowSubscriber(t *testing.T) {
+	b := NewEventBroker(10, 10)
+	sub, err := b.Subscribe(1, 0)
+	require.NoError(t, err)
+	publishN(b, 1, subscriptionBufferSize+1)
+	require.Equal(t, subscriptionBufferSize, len(receive(sub)))
+	require.True(t, sub.Dropped())
+}
+
+func TestEventBrokerEviction(t *testing.T) {
+	b := NewEventBroker(10, 2)
+	publishN(b, 1, 1)
+	publishN(b, 2, 1)
+	publishN(b, 3, 1)
+	// open streams are never evicted
+	require.Equal(t, 3, len(b.streams))
+
+	b.Close(2)
+	b.Close(1)
+	require.Equal(t, 2, len(b.streams))
+	_, ok := b.streams[2]
+	require.False(t, ok)
+
+	// closed streams are evicted from the oldest
+	publishN(b, 4, 1)
+	require.Equal(t, 2, len(b.streams))
+	_, ok = b.streams[1]
+	require.False(t, ok)
+}
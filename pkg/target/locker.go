@@ -6,12 +6,24 @@
 package target
 
 import (
+	"time"
+
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
+// LockerFactory is a type representing a function which builds a Locker whose
+// locks last for the given timeout.
+type LockerFactory func(timeout time.Duration) Locker
+
+// LockerLoader is a type representing a function which returns all the needed
+// things to be able to load a Locker.
+type LockerLoader func() (string, LockerFactory)
+
 // Locker defines an interface to lock and unlock targets. It is passed
 // to TargetManager's Acquire and Release methods, and the target manager
 // implementation is required to lock all the returned targets.
+// Implementations can be checked against the conformance test suite in
+// pkg/target/lockertest.
 type Locker interface {
 	// Lock locks the specified targets. The timeout must be handled by the
 	// plugin, and configured at construction time by the plugin's
@@ -0,0 +1,307 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package lockertest implements a conformance test suite for target.Locker
+// implementations. A plugin runs it from its own tests with:
+//
+//	suite.Run(t, &lockertest.LockerSuite{NewLocker: New})
+package lockertest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	jobID      = types.JobID(123)
+	otherJobID = types.JobID(456)
+	thirdJobID = types.JobID(789)
+
+	targetOne   = &target.Target{Name: "target001", ID: "001", FQDN: "target001.example.com"}
+	targetTwo   = &target.Target{Name: "target002", ID: "002", FQDN: "target002.example.com"}
+	targetThree = &target.Target{Name: "target003", ID: "003", FQDN: "target003.example.com"}
+)
+
+// shortTimeout is the lock timeout of the tests about lock expiration.
+const shortTimeout = 200 * time.Millisecond
+
+// LockerSuite checks that a target.Locker honours the contract of the
+// interface. NewLocker is called at least once per test, and must return a
+// Locker with no locks.
+type LockerSuite struct {
+	suite.Suite
+	NewLocker target.LockerFactory
+}
+
+func (s *LockerSuite) requireLocked(tl target.Locker, owner types.JobID, targets ...*target.Target) {
+	allLocked, locked, notLocked := tl.CheckLocks(owner, targets)
+	s.Require().True(allLocked, "targets not locked by job ID %d: %v", owner, notLocked)
+	s.Require().Equal(targets, locked)
+}
+
+func (s *LockerSuite) requireNotLocked(tl target.Locker, owner types.JobID, targets ...*target.Target) {
+	allLocked, locked, notLocked := tl.CheckLocks(owner, targets)
+	s.Require().False(allLocked, "targets locked by job ID %d: %v", owner, locked)
+	s.Require().Empty(locked)
+	s.Require().Equal(targets, notLocked)
+}
+
+func (s *LockerSuite) TestNoTargets() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, nil))
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{}))
+	s.Require().NoError(tl.RefreshLocks(jobID, nil))
+	s.Require().NoError(tl.Unlock(jobID, nil))
+	allLocked, locked, notLocked := tl.CheckLocks(jobID, nil)
+	s.Require().True(allLocked)
+	s.Require().Empty(locked)
+	s.Require().Empty(notLocked)
+}
+
+func (s *LockerSuite) TestLock() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne, targetTwo}))
+	s.requireLocked(tl, jobID, targetOne, targetTwo)
+	s.requireNotLocked(tl, otherJobID, targetOne, targetTwo)
+	s.requireNotLocked(tl, jobID, targetThree)
+
+	allLocked, locked, notLocked := tl.CheckLocks(jobID, []*target.Target{targetOne, targetThree})
+	s.Require().False(allLocked)
+	s.Require().Equal([]*target.Target{targetOne}, locked)
+	s.Require().Equal([]*target.Target{targetThree}, notLocked)
+}
+
+func (s *LockerSuite) TestLockAgainByOwner() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne, targetTwo}))
+	s.requireLocked(tl, jobID, targetOne, targetTwo)
+}
+
+func (s *LockerSuite) TestLockByOtherJobFails() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	s.Require().Error(tl.Lock(otherJobID, []*target.Target{targetOne}))
+	s.requireLocked(tl, jobID, targetOne)
+}
+
+func (s *LockerSuite) TestLockIsAllOrNothing() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetTwo}))
+	// the conflict is on the second target, the first one must not stay locked
+	s.Require().Error(tl.Lock(otherJobID, []*target.Target{targetOne, targetTwo, targetThree}))
+	s.requireNotLocked(tl, otherJobID, targetOne, targetTwo, targetThree)
+	s.requireLocked(tl, jobID, targetTwo)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne, targetThree}))
+}
+
+func (s *LockerSuite) TestUnlock() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne, targetTwo}))
+	s.Require().NoError(tl.Unlock(jobID, []*target.Target{targetOne}))
+	s.requireNotLocked(tl, jobID, targetOne)
+	s.requireLocked(tl, jobID, targetTwo)
+	s.Require().NoError(tl.Lock(otherJobID, []*target.Target{targetOne}))
+}
+
+func (s *LockerSuite) TestUnlockNotLocked() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Unlock(jobID, []*target.Target{targetOne}))
+}
+
+func (s *LockerSuite) TestUnlockByOtherJobFails() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetTwo}))
+	s.Require().NoError(tl.Lock(otherJobID, []*target.Target{targetOne}))
+	// all or nothing: targetOne must stay locked by its owner too
+	s.Require().Error(tl.Unlock(otherJobID, []*target.Target{targetOne, targetTwo}))
+	s.requireLocked(tl, jobID, targetTwo)
+	s.requireLocked(tl, otherJobID, targetOne)
+}
+
+func (s *LockerSuite) TestRefreshLocks() {
+	tl := s.NewLocker(shortTimeout)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	time.Sleep(shortTimeout * 3 / 4)
+	s.Require().NoError(tl.RefreshLocks(jobID, []*target.Target{targetOne}))
+	time.Sleep(shortTimeout * 3 / 4)
+	s.requireLocked(tl, jobID, targetOne)
+}
+
+func (s *LockerSuite) TestRefreshLocksByOtherJobFails() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	s.Require().Error(tl.RefreshLocks(otherJobID, []*target.Target{targetOne}))
+	s.requireLocked(tl, jobID, targetOne)
+	s.requireNotLocked(tl, otherJobID, targetOne)
+}
+
+func (s *LockerSuite) TestLocksExpire() {
+	tl := s.NewLocker(shortTimeout)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	time.Sleep(shortTimeout * 2)
+	s.requireNotLocked(tl, jobID, targetOne)
+	s.Require().NoError(tl.Lock(otherJobID, []*target.Target{targetOne}))
+	s.Require().Error(tl.RefreshLocks(jobID, []*target.Target{targetOne}))
+	s.Require().Error(tl.Unlock(jobID, []*target.Target{targetOne}))
+}
+
+func (s *LockerSuite) TestLockWaitPartial() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetTwo}))
+	all := []*target.Target{targetOne, targetTwo, targetThree}
+
+	locked, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{Targets: all, Min: 2})
+	s.Require().NoError(err)
+	s.Require().Equal([]*target.Target{targetOne, targetThree}, locked)
+	s.requireLocked(tl, otherJobID, targetOne, targetThree)
+	s.Require().NoError(tl.Unlock(otherJobID, locked))
+
+	locked, err = target.LockWait(tl, otherJobID, nil, target.LockRequest{Targets: all, Min: 1, Max: 1})
+	s.Require().NoError(err)
+	s.Require().Len(locked, 1)
+	s.requireLocked(tl, otherJobID, locked...)
+}
+
+func (s *LockerSuite) TestLockWaitNotEnoughTargets() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetTwo}))
+
+	_, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{Targets: []*target.Target{targetOne, targetTwo, targetThree}, Min: 3})
+	s.Require().True(errors.Is(err, target.ErrNotEnoughTargets), "unexpected error: %v", err)
+	s.requireNotLocked(tl, otherJobID, targetOne, targetTwo, targetThree)
+
+	_, err = target.LockWait(tl, otherJobID, nil, target.LockRequest{Targets: []*target.Target{targetOne}, Min: 2})
+	s.Require().Error(err)
+}
+
+func (s *LockerSuite) TestLockWaitUntilUnlocked() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	go func() {
+		time.Sleep(shortTimeout)
+		_ = tl.Unlock(jobID, []*target.Target{targetOne})
+	}()
+
+	locked, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{
+		Targets:  []*target.Target{targetOne, targetTwo},
+		Deadline: time.Now().Add(10 * time.Second),
+	})
+	s.Require().NoError(err)
+	s.Require().Equal([]*target.Target{targetOne, targetTwo}, locked)
+	s.requireLocked(tl, otherJobID, targetOne, targetTwo)
+}
+
+func (s *LockerSuite) TestLockWaitUntilExpired() {
+	tl := s.NewLocker(shortTimeout)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+
+	locked, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{
+		Targets:  []*target.Target{targetOne},
+		Deadline: time.Now().Add(10 * time.Second),
+	})
+	s.Require().NoError(err)
+	s.Require().Equal([]*target.Target{targetOne}, locked)
+}
+
+func (s *LockerSuite) TestLockWaitDeadline() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+
+	start := time.Now()
+	_, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{
+		Targets:  []*target.Target{targetOne, targetTwo},
+		Deadline: start.Add(shortTimeout),
+	})
+	s.Require().True(errors.Is(err, target.ErrNotEnoughTargets), "unexpected error: %v", err)
+	s.Require().True(time.Since(start) >= shortTimeout)
+	s.requireNotLocked(tl, otherJobID, targetOne, targetTwo)
+}
+
+func (s *LockerSuite) TestLockWaitCancel() {
+	tl := s.NewLocker(time.Minute)
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+	cancel := make(chan struct{})
+	go func() {
+		time.Sleep(shortTimeout)
+		close(cancel)
+	}()
+
+	_, err := target.LockWait(tl, otherJobID, cancel, target.LockRequest{
+		Targets:  []*target.Target{targetOne},
+		Deadline: time.Now().Add(10 * time.Second),
+	})
+	s.Require().Equal(target.ErrLockWaitCancelled, err)
+	s.requireLocked(tl, jobID, targetOne)
+}
+
+func (s *LockerSuite) TestLockWaitFIFO() {
+	tl := s.NewLocker(time.Minute)
+	if _, ok := tl.(target.BlockingLocker); !ok {
+		s.T().Skip("FIFO order only applies to a target.BlockingLocker")
+	}
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+
+	type result struct {
+		jobID types.JobID
+		err   error
+	}
+	results := make(chan result)
+	wait := func(owner types.JobID, targets ...*target.Target) {
+		_, err := target.LockWait(tl, owner, nil, target.LockRequest{
+			Targets:  targets,
+			Min:      1,
+			Deadline: time.Now().Add(10 * time.Second),
+		})
+		results <- result{jobID: owner, err: err}
+	}
+	// both jobs wait for targetOne, the first one to ask must be served first
+	go wait(otherJobID, targetOne)
+	time.Sleep(shortTimeout / 4)
+	go wait(thirdJobID, targetOne)
+	time.Sleep(shortTimeout / 4)
+
+	s.Require().NoError(tl.Unlock(jobID, []*target.Target{targetOne}))
+	first := <-results
+	s.Require().NoError(first.err)
+	s.Require().Equal(otherJobID, first.jobID)
+	s.requireLocked(tl, otherJobID, targetOne)
+
+	s.Require().NoError(tl.Unlock(otherJobID, []*target.Target{targetOne}))
+	second := <-results
+	s.Require().NoError(second.err)
+	s.Require().Equal(thirdJobID, second.jobID)
+}
+
+func (s *LockerSuite) TestLockWaitReservesTargets() {
+	tl := s.NewLocker(time.Minute)
+	if _, ok := tl.(target.BlockingLocker); !ok {
+		s.T().Skip("reservations only apply to a target.BlockingLocker")
+	}
+	s.Require().NoError(tl.Lock(jobID, []*target.Target{targetOne}))
+
+	errCh := make(chan error)
+	go func() {
+		_, err := target.LockWait(tl, otherJobID, nil, target.LockRequest{
+			Targets:  []*target.Target{targetOne, targetTwo},
+			Deadline: time.Now().Add(10 * time.Second),
+		})
+		errCh <- err
+	}()
+	time.Sleep(shortTimeout / 4)
+
+	// targetTwo is free, but reserved by the waiting job
+	_, err := target.LockWait(tl, thirdJobID, nil, target.LockRequest{Targets: []*target.Target{targetTwo}})
+	s.Require().True(errors.Is(err, target.ErrNotEnoughTargets), "unexpected error: %v", err)
+
+	s.Require().NoError(tl.Unlock(jobID, []*target.Target{targetOne}))
+	s.Require().NoError(<-errCh)
+	s.requireLocked(tl, otherJobID, targetOne, targetTwo)
+}
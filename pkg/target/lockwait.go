@@ -0,0 +1,136 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package target
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// ErrNotEnoughTargets is returned, possibly wrapped, when fewer targets than
+// requested could be locked before the deadline of a LockRequest.
+var ErrNotEnoughTargets = errors.New("not enough targets could be locked")
+
+// ErrLockWaitCancelled is returned when a LockRequest is cancelled while
+// waiting for targets.
+var ErrLockWaitCancelled = errors.New("cancelled while waiting for targets")
+
+// lockPollInterval is how often LockWait tries again to lock the targets, for
+// the Lockers which cannot wait for them.
+var lockPollInterval = time.Second
+
+// LockRequest describes a request to lock some of the given targets, waiting
+// for the ones locked by other jobs to be unlocked.
+type LockRequest struct {
+	Targets []*Target
+	// Min is the minimum number of targets to lock. If zero, all the targets
+	// must be locked.
+	Min int
+	// Max is the maximum number of targets to lock. If zero, there is no
+	// maximum.
+	Max int
+	// Deadline is until when to wait for enough targets to be unlocked. If
+	// zero, the request fails immediately if not enough targets are available.
+	Deadline time.Time
+}
+
+// Bounds returns the minimum and maximum number of targets to lock, or an
+// error if the request cannot be satisfied.
+func (r LockRequest) Bounds() (int, int, error) {
+	min, max := r.Min, r.Max
+	if min == 0 {
+		min = len(r.Targets)
+	}
+	if max == 0 || max > len(r.Targets) {
+		max = len(r.Targets)
+	}
+	if min > len(r.Targets) {
+		return 0, 0, fmt.Errorf("cannot lock %d targets out of %d", min, len(r.Targets))
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("minimum number of targets %d is greater than the maximum %d", min, max)
+	}
+	return min, max, nil
+}
+
+// BlockingLocker is a Locker which can wait for targets to be unlocked by other
+// jobs. Requests waiting for the same targets are served in FIFO order: the
+// targets a request is waiting for cannot be taken by the requests which came
+// after it.
+type BlockingLocker interface {
+	Locker
+	// LockWait locks at least Min and at most Max of the targets of the
+	// request, as soon as enough of them are available, and returns them. It
+	// returns an error wrapping ErrNotEnoughTargets if the deadline expires
+	// first, and ErrLockWaitCancelled if cancel is closed first.
+	LockWait(jobID types.JobID, cancel <-chan struct{}, req LockRequest) ([]*Target, error)
+}
+
+// LockWait locks the targets of the request with the given Locker, see
+// BlockingLocker. If the Locker cannot wait for targets, LockWait tries to
+// lock them periodically until the deadline, without any fairness.
+func LockWait(tl Locker, jobID types.JobID, cancel <-chan struct{}, req LockRequest) ([]*Target, error) {
+	if bl, ok := tl.(BlockingLocker); ok {
+		return bl.LockWait(jobID, cancel, req)
+	}
+	min, max, err := req.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		locked, err := tryLock(tl, jobID, req.Targets, min, max)
+		if err == nil {
+			return locked, nil
+		}
+		wait := time.Until(req.Deadline)
+		if wait <= 0 {
+			return nil, fmt.Errorf("%w: %v", ErrNotEnoughTargets, err)
+		}
+		if wait > lockPollInterval {
+			wait = lockPollInterval
+		}
+		select {
+		case <-cancel:
+			return nil, ErrLockWaitCancelled
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tryLock locks at least min and at most max of the targets, if they are
+// available, or none of them.
+func tryLock(tl Locker, jobID types.JobID, targets []*Target, min, max int) ([]*Target, error) {
+	if min == len(targets) {
+		if err := tl.Lock(jobID, targets); err != nil {
+			return nil, err
+		}
+		return targets, nil
+	}
+	var (
+		locked  []*Target
+		lastErr error
+	)
+	for _, t := range targets {
+		if len(locked) == max {
+			break
+		}
+		if err := tl.Lock(jobID, []*Target{t}); err != nil {
+			lastErr = err
+			continue
+		}
+		locked = append(locked, t)
+	}
+	if len(locked) < min {
+		if err := tl.Unlock(jobID, locked); err != nil {
+			return nil, fmt.Errorf("locked %d target(s) out of %d required, and failed to unlock them: %v", len(locked), min, err)
+		}
+		return nil, fmt.Errorf("locked %d target(s) out of %d required: %v", len(locked), min, lastErr)
+	}
+	return locked, nil
+}
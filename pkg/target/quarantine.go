@@ -0,0 +1,28 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package target
+
+import (
+	"time"
+)
+
+// QuarantinedTarget records that a target failed too many of its recent runs.
+// Quarantined targets are not handed to jobs until they are released.
+type QuarantinedTarget struct {
+	ID   string
+	Name string
+	// Reason explains why the target was quarantined.
+	Reason         string
+	QuarantineTime time.Time
+	// ReleaseTime is when the target was released from quarantine, or the
+	// zero time if it is still quarantined.
+	ReleaseTime time.Time
+}
+
+// Released returns whether the target was released from quarantine.
+func (q *QuarantinedTarget) Released() bool {
+	return !q.ReleaseTime.IsZero()
+}
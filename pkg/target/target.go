@@ -7,6 +7,8 @@ package target
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/facebookincubator/contest/pkg/event"
 )
@@ -23,13 +25,60 @@ var EventTargetOut = event.Name("TargetOut")
 // EventTargetErr indicates that a target has encountered an error in a TestStep
 var EventTargetErr = event.Name("TargetErr")
 
-// Target represents a target to run tests on
+// EventTargetUnhealthy indicates that a target failed the health check run
+// before a test, and was released
+var EventTargetUnhealthy = event.Name("TargetUnhealthy")
+
+// EventTargetJoined indicates that a target joined a test which was already
+// running
+var EventTargetJoined = event.Name("TargetJoined")
+
+// EventTargetDetached indicates that a target was detached from a running
+// job, and left the test without a result
+var EventTargetDetached = event.Name("TargetDetached")
+
+// Target represents a target to run tests on. Attributes are arbitrary
+// key/value pairs describing the target, e.g. its SKU or the IP address of its
+// BMC, as recorded in the target inventory.
 type Target struct {
+	Name       string
+	ID         string
+	FQDN       string
+	Attributes map[string]string `json:",omitempty"`
+}
+
+// Key identifies a target regardless of its attributes. Unlike Target, it can
+// be compared and used as a map key.
+type Key struct {
 	Name string
 	ID   string
 	FQDN string
 }
 
+// Key returns the key identifying the target.
+func (t *Target) Key() Key {
+	return Key{Name: t.Name, ID: t.ID, FQDN: t.FQDN}
+}
+
+// Attr returns the value of an attribute of the target, or an error if the
+// target has no such attribute. It is meant to be used in test step parameter
+// expressions, e.g. {{ .Attr "bmc_ip" }}.
+func (t *Target) Attr(name string) (string, error) {
+	value, ok := t.Attributes[name]
+	if !ok {
+		return "", fmt.Errorf("target %s has no attribute '%s'", t.Name, name)
+	}
+	return value, nil
+}
+
 func (t *Target) String() string {
-	return fmt.Sprintf("Target{Name: \"%s\", ID: \"%s\", FQDN: \"%s\"}", t.Name, t.ID, t.FQDN)
+	if len(t.Attributes) == 0 {
+		return fmt.Sprintf("Target{Name: \"%s\", ID: \"%s\", FQDN: \"%s\"}", t.Name, t.ID, t.FQDN)
+	}
+	attrs := make([]string, 0, len(t.Attributes))
+	for name, value := range t.Attributes {
+		attrs = append(attrs, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(attrs)
+	return fmt.Sprintf("Target{Name: \"%s\", ID: \"%s\", FQDN: \"%s\", Attributes: [%s]}", t.Name, t.ID, t.FQDN, strings.Join(attrs, ", "))
 }
@@ -17,11 +17,34 @@ type TargetManagerLoader func() (string, TargetManagerFactory)
 
 // TargetManager is an interface used to acquire and release the targets to
 // run tests on.
+//
+// Release is called once per Acquire which returned, even if the test failed
+// or the job was cancelled, and its cancel channel is only closed when the
+// release times out. If Acquire times out or the job is cancelled while it
+// runs, the job runner waits for it up to the release timeout, and Release is
+// never called if Acquire did not return by then. When the job is paused,
+// Release is deferred until the job resumes and the test completes, and an
+// Acquire interrupted by the pause is never released.
+//
+// results maps the targets to the error they failed the test with, nil if
+// they passed it. The targets missing from results did not complete the test,
+// e.g. because it was cancelled. If Acquire failed, results is nil, except
+// when the health check of the test rejected all the acquired targets, which
+// results maps to their health check error.
 type TargetManager interface {
 	ValidateAcquireParameters([]byte) (interface{}, error)
 	ValidateReleaseParameters([]byte) (interface{}, error)
 	Acquire(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl Locker) ([]*Target, error)
-	Release(jobID types.JobID, cancel <-chan struct{}, parameters interface{}) error
+	Release(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, results map[*Target]error) error
+}
+
+// TargetStreamer is implemented by the target managers which can hand new
+// targets to a test while it runs, after Acquire returned. Stream sends the
+// targets, locked for the job like the acquired ones, until it has no more
+// targets to offer or cancel is closed, and then returns. The test completes
+// once Stream returned and all the targets completed it.
+type TargetStreamer interface {
+	Stream(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl Locker, targets chan<- *Target) error
 }
 
 // TargetManagerBundle bundles the selected TargetManager together with its
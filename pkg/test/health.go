@@ -0,0 +1,109 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/config"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/insomniacslk/xjson"
+)
+
+// DefaultHealthCheckTimeout is the timeout of a health check, if not set.
+const DefaultHealthCheckTimeout = 30 * time.Second
+
+// HealthCheck describes a probe run on each acquired target before the test
+// runs on it: either a TCP connection to an address, or a command run on the
+// ConTest server, if config.HealthCheckCmdEnabled is set. The targets failing
+// the probe are released, and the test runs on the remaining ones. The address, the command and its arguments are
+// expanded for each target like test step parameters, e.g. "{{ .FQDN }}:22".
+type HealthCheck struct {
+	// TCP is the host:port address to connect to.
+	TCP string `json:",omitempty"`
+	// Cmd is the executable to run, which must exit successfully.
+	Cmd     string   `json:",omitempty"`
+	Args    []string `json:",omitempty"`
+	Timeout xjson.Duration
+}
+
+// Validate checks that exactly one kind of probe is set, that the server
+// allows it, and that its parameters are valid templates.
+func (h *HealthCheck) Validate() error {
+	if (h.TCP == "") == (h.Cmd == "") {
+		return errors.New("health check must have either TCP or Cmd")
+	}
+	if h.Cmd != "" && !config.HealthCheckCmdEnabled {
+		return errors.New("command health checks are disabled on this server, use a TCP health check")
+	}
+	if h.Cmd == "" && len(h.Args) > 0 {
+		return errors.New("health check arguments require Cmd")
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("invalid health check timeout: %v", h.Timeout)
+	}
+	for _, expr := range append([]string{h.TCP, h.Cmd}, h.Args...) {
+		if _, err := template.New("").Funcs(getFuncMap()).Parse(expr); err != nil {
+			return fmt.Errorf("invalid health check parameter '%s': %v", expr, err)
+		}
+	}
+	return nil
+}
+
+// Check runs the probe on a target, and returns an error if the target is
+// unhealthy. It returns early if cancel is closed.
+func (h *HealthCheck) Check(cancel <-chan struct{}, t *target.Target) error {
+	timeout := time.Duration(h.Timeout)
+	if timeout == 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancelCtx := context.WithTimeout(context.Background(), timeout)
+	defer cancelCtx()
+	go func() {
+		select {
+		case <-cancel:
+			cancelCtx()
+		case <-ctx.Done():
+		}
+	}()
+
+	if h.TCP != "" {
+		addr, err := NewParam(h.TCP).Expand(t)
+		if err != nil {
+			return fmt.Errorf("cannot expand address '%s': %v", h.TCP, err)
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("cannot connect to %s: %v", addr, err)
+		}
+		return conn.Close()
+	}
+	cmd, err := NewParam(h.Cmd).Expand(t)
+	if err != nil {
+		return fmt.Errorf("cannot expand command '%s': %v", h.Cmd, err)
+	}
+	args := make([]string, 0, len(h.Args))
+	for _, arg := range h.Args {
+		expanded, err := NewParam(arg).Expand(t)
+		if err != nil {
+			return fmt.Errorf("cannot expand argument '%s': %v", arg, err)
+		}
+		args = append(args, expanded)
+	}
+	output, err := exec.CommandContext(ctx, cmd, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("command %s failed: %v, output: %s", cmd, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/config"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/insomniacslk/xjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheckTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	tgt := &target.Target{Name: "host1", FQDN: "127.0.0.1"}
+	hc := HealthCheck{TCP: "{{ .FQDN }}:" + strconv.Itoa(port)}
+	require.NoError(t, hc.Validate())
+	require.NoError(t, hc.Check(nil, tgt))
+
+	require.NoError(t, ln.Close())
+	require.Error(t, hc.Check(nil, tgt))
+}
+
+func TestHealthCheckCmd(t *testing.T) {
+	hc := HealthCheck{Cmd: "test", Args: []string{`{{ .Attr "sku" }}`, "=", "T6"}}
+	// commands are disabled unless the server enables them
+	require.Error(t, hc.Validate())
+	config.HealthCheckCmdEnabled = true
+	defer func() { config.HealthCheckCmdEnabled = false }()
+	require.NoError(t, hc.Validate())
+	require.NoError(t, hc.Check(nil, &target.Target{Name: "host1", Attributes: map[string]string{"sku": "T6"}}))
+	require.Error(t, hc.Check(nil, &target.Target{Name: "host2", Attributes: map[string]string{"sku": "T7"}}))
+	// missing attribute
+	require.Error(t, hc.Check(nil, &target.Target{Name: "host3"}))
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	hc := HealthCheck{Cmd: "sleep", Args: []string{"10"}, Timeout: xjson.Duration(50 * time.Millisecond)}
+	start := time.Now()
+	require.Error(t, hc.Check(nil, &target.Target{Name: "host1"}))
+	require.True(t, time.Since(start) < 5*time.Second)
+
+	cancel := make(chan struct{})
+	close(cancel)
+	hc.Timeout = 0
+	require.Error(t, hc.Check(cancel, &target.Target{Name: "host1"}))
+}
+
+func TestHealthCheckValidate(t *testing.T) {
+	for _, hc := range []HealthCheck{
+		{},
+		{TCP: "{{ .FQDN }}:22", Cmd: "true"},
+		{TCP: "{{ .FQDN }}:22", Args: []string{"-v"}},
+		{TCP: "{{ .FQDN :22"},
+		{Cmd: "true", Timeout: xjson.Duration(-time.Second)},
+	} {
+		require.Error(t, hc.Validate(), hc)
+	}
+}
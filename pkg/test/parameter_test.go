@@ -56,6 +56,16 @@ func TestParameterExpand(t *testing.T) {
 	}
 }
 
+func TestParameterExpandAttributes(t *testing.T) {
+	tgt := &target.Target{Name: "host1", ID: "1", Attributes: map[string]string{"bmc_ip": "10.0.0.1"}}
+	res, err := NewParam(`ipmitool -H {{ .Attr "bmc_ip" }} power status`).Expand(tgt)
+	require.NoError(t, err)
+	require.Equal(t, "ipmitool -H 10.0.0.1 power status", res)
+
+	_, err = NewParam(`{{ .Attr "serial_console" }}`).Expand(tgt)
+	require.Error(t, err)
+}
+
 func TestParameterExpandUserFunctions(t *testing.T) {
 	title := func(a ...string) (string, error) {
 		if len(a) == 0 {
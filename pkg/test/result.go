@@ -6,6 +6,8 @@
 package test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/facebookincubator/contest/pkg/lib/comparison"
@@ -50,7 +52,7 @@ func (r TestResult) GetResult(expression string, ignore []*target.Target) (*comp
 		var skip bool
 		for _, ignoreTarget := range ignore {
 			skip = false
-			if *t == *ignoreTarget {
+			if t.Key() == ignoreTarget.Key() {
 				skip = true
 				break
 			}
@@ -76,6 +78,54 @@ func (r TestResult) GetResult(expression string, ignore []*target.Target) (*comp
 	return res, nil
 }
 
+// targetResult is the serializable form of the result of a single Target
+type targetResult struct {
+	Target *target.Target
+	Error  *string `json:",omitempty"`
+}
+
+// testResultJSON is the serializable form of a TestResult
+type testResultJSON struct {
+	JobID   types.JobID
+	Targets []targetResult
+}
+
+// MarshalJSON serializes a TestResult. Target errors are serialized as
+// strings.
+func (r TestResult) MarshalJSON() ([]byte, error) {
+	res := testResultJSON{JobID: r.JobID, Targets: make([]targetResult, 0, len(r.results))}
+	for t, err := range r.results {
+		tr := targetResult{Target: t}
+		if err != nil {
+			errStr := err.Error()
+			tr.Error = &errStr
+		}
+		res.Targets = append(res.Targets, tr)
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON deserializes a TestResult previously serialized with
+// MarshalJSON
+func (r *TestResult) UnmarshalJSON(data []byte) error {
+	var res testResultJSON
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	*r = NewTestResult(res.JobID)
+	for _, tr := range res.Targets {
+		if tr.Target == nil {
+			return errors.New("invalid test result: target cannot be nil")
+		}
+		var err error
+		if tr.Error != nil {
+			err = errors.New(*tr.Error)
+		}
+		r.results[tr.Target] = err
+	}
+	return nil
+}
+
 // NewTestResult creates a new TestResult structure
 func NewTestResult(jobID types.JobID) TestResult {
 	tr := TestResult{}
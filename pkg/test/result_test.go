@@ -0,0 +1,48 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestResultJSONRoundTrip(t *testing.T) {
+	res := NewTestResult(types.JobID(12))
+	res.SetTarget(&target.Target{Name: "Target001", ID: "0001", FQDN: "Target001.facebook.com"}, errors.New("Target001 failed"))
+	res.SetTarget(&target.Target{Name: "Target002", ID: "0002", FQDN: "Target002.facebook.com"}, nil)
+
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+
+	var decoded TestResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, types.JobID(12), decoded.JobID)
+
+	// targets are deserialized into new objects, so compare them by value
+	results := make(map[target.Key]error)
+	for tgt, err := range decoded.Targets() {
+		results[tgt.Key()] = err
+	}
+	require.Equal(t, 2, len(results))
+	err, ok := results[target.Key{Name: "Target001", ID: "0001", FQDN: "Target001.facebook.com"}]
+	require.True(t, ok)
+	require.EqualError(t, err, "Target001 failed")
+	err, ok = results[target.Key{Name: "Target002", ID: "0002", FQDN: "Target002.facebook.com"}]
+	require.True(t, ok)
+	require.NoError(t, err)
+}
+
+func TestTestResultUnmarshalNilTarget(t *testing.T) {
+	var decoded TestResult
+	require.Error(t, json.Unmarshal([]byte(`{"JobID": 1, "Targets": [{"Target": null}]}`), &decoded))
+}
@@ -17,6 +17,8 @@ type Test struct {
 	TestStepsBundles    []TestStepBundle
 	TargetManagerBundle *target.TargetManagerBundle
 	TestFetcherBundle   *TestFetcherBundle
+	// HealthCheck is run on the acquired targets before the test, if set
+	HealthCheck *HealthCheck
 }
 
 // TestDescriptor models the JSON encoded blob which is given as input to the
@@ -28,6 +30,10 @@ type TestDescriptor struct {
 	TargetManagerAcquireParameters json.RawMessage
 	TargetManagerReleaseParameters json.RawMessage
 
+	// HealthCheck is an optional probe run on the acquired targets, see
+	// HealthCheck
+	HealthCheck *HealthCheck `json:",omitempty"`
+
 	// TestFetcher-related parameters
 	TestFetcherName            string
 	TestFetcherFetchParameters json.RawMessage
@@ -7,3 +7,6 @@ package types
 
 // JobID represents a unique job identifier
 type JobID uint64
+
+// ScheduleID represents a unique schedule identifier
+type ScheduleID uint64
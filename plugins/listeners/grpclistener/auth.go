@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey int
+
+// requestorKey is the context key of the authenticated requestor.
+const requestorKey contextKey = iota
+
+// rpcCredentials extracts the credentials of an RPC: the bearer token from the
+// "authorization" metadata, and the verified client certificate.
+func rpcCredentials(ctx context.Context) *api.Credentials {
+	var creds api.Credentials
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, auth := range md.Get("authorization") {
+			if strings.HasPrefix(auth, "Bearer ") {
+				creds.Token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+				break
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			chains := tlsInfo.State.VerifiedChains
+			if len(chains) > 0 && len(chains[0]) > 0 {
+				creds.Certificate = chains[0][0]
+			}
+		}
+	}
+	return &creds
+}
+
+// authenticate returns a context carrying the requestor authenticated from the
+// credentials of the RPC.
+func authenticate(ctx context.Context, authenticator api.Authenticator) (context.Context, error) {
+	requestor, err := authenticator.Authenticate(rpcCredentials(ctx))
+	if err == api.ErrNoCredentials {
+		err = fmt.Errorf("missing credentials")
+	}
+	if err != nil {
+		if p, ok := peer.FromContext(ctx); ok {
+			log.Warningf("Rejecting unauthenticated RPC from %s: %v", p.Addr, err)
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
+	}
+	return context.WithValue(ctx, requestorKey, requestor), nil
+}
+
+// authStream wraps a server stream to carry the authenticated context.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// AuthInterceptors returns the server options which authenticate the unary and
+// streaming RPCs with the given authenticator.
+func AuthInterceptors(authenticator api.Authenticator) []grpc.ServerOption {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+	return []grpc.ServerOption{grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream)}
+}
+
+// requestorOf returns the requestor of an RPC. If RPCs are authenticated, this
+// is the authenticated requestor, and the claimed one must either match or be
+// empty. Otherwise, the claimed requestor is trusted.
+func requestorOf(ctx context.Context, claimed string) (api.EventRequestor, error) {
+	authenticated, ok := ctx.Value(requestorKey).(api.EventRequestor)
+	if !ok {
+		return api.EventRequestor(claimed), nil
+	}
+	return api.CheckRequestor(authenticated, api.EventRequestor(claimed))
+}
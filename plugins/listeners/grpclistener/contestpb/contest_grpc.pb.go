@@ -0,0 +1,345 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: contest.proto
+
+package contestpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ConTest_Start_FullMethodName       = "/contest.v1.ConTest/Start"
+	ConTest_Stop_FullMethodName        = "/contest.v1.ConTest/Stop"
+	ConTest_Status_FullMethodName      = "/contest.v1.ConTest/Status"
+	ConTest_Retry_FullMethodName       = "/contest.v1.ConTest/Retry"
+	ConTest_Version_FullMethodName     = "/contest.v1.ConTest/Version"
+	ConTest_WatchEvents_FullMethodName = "/contest.v1.ConTest/WatchEvents"
+)
+
+// ConTestClient is the client API for ConTest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConTestClient interface {
+	// Start starts a job.
+	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error)
+	// Stop stops a running job.
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
+	// Status returns the status of a job.
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// Retry starts a new job with the descriptor of an existing one.
+	Retry(ctx context.Context, in *RetryRequest, opts ...grpc.CallOption) (*RetryResponse, error)
+	// Version returns the version of the API.
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+	// WatchEvents streams the test and framework events of a job, until the job
+	// terminates. If the client cannot keep up with the events, the stream is
+	// terminated with UNAVAILABLE, and the client can resume watching from the
+	// ID of the last event it received.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ConTest_WatchEventsClient, error)
+}
+
+type conTestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConTestClient(cc grpc.ClientConnInterface) ConTestClient {
+	return &conTestClient{cc}
+}
+
+func (c *conTestClient) Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error) {
+	out := new(StartResponse)
+	err := c.cc.Invoke(ctx, ConTest_Start_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conTestClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := c.cc.Invoke(ctx, ConTest_Stop_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conTestClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, ConTest_Status_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conTestClient) Retry(ctx context.Context, in *RetryRequest, opts ...grpc.CallOption) (*RetryResponse, error) {
+	out := new(RetryResponse)
+	err := c.cc.Invoke(ctx, ConTest_Retry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conTestClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	out := new(VersionResponse)
+	err := c.cc.Invoke(ctx, ConTest_Version_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conTestClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ConTest_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ConTest_ServiceDesc.Streams[0], ConTest_WatchEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &conTestWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ConTest_WatchEventsClient interface {
+	Recv() (*WatchEventsResponse, error)
+	grpc.ClientStream
+}
+
+type conTestWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *conTestWatchEventsClient) Recv() (*WatchEventsResponse, error) {
+	m := new(WatchEventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConTestServer is the server API for ConTest service.
+// All implementations must embed UnimplementedConTestServer
+// for forward compatibility
+type ConTestServer interface {
+	// Start starts a job.
+	Start(context.Context, *StartRequest) (*StartResponse, error)
+	// Stop stops a running job.
+	Stop(context.Context, *StopRequest) (*StopResponse, error)
+	// Status returns the status of a job.
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	// Retry starts a new job with the descriptor of an existing one.
+	Retry(context.Context, *RetryRequest) (*RetryResponse, error)
+	// Version returns the version of the API.
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	// WatchEvents streams the test and framework events of a job, until the job
+	// terminates. If the client cannot keep up with the events, the stream is
+	// terminated with UNAVAILABLE, and the client can resume watching from the
+	// ID of the last event it received.
+	WatchEvents(*WatchEventsRequest, ConTest_WatchEventsServer) error
+	mustEmbedUnimplementedConTestServer()
+}
+
+// UnimplementedConTestServer must be embedded to have forward compatible implementations.
+type UnimplementedConTestServer struct {
+}
+
+func (UnimplementedConTestServer) Start(context.Context, *StartRequest) (*StartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedConTestServer) Stop(context.Context, *StopRequest) (*StopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedConTestServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedConTestServer) Retry(context.Context, *RetryRequest) (*RetryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Retry not implemented")
+}
+func (UnimplementedConTestServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedConTestServer) WatchEvents(*WatchEventsRequest, ConTest_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedConTestServer) mustEmbedUnimplementedConTestServer() {}
+
+// UnsafeConTestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConTestServer will
+// result in compilation errors.
+type UnsafeConTestServer interface {
+	mustEmbedUnimplementedConTestServer()
+}
+
+func RegisterConTestServer(s grpc.ServiceRegistrar, srv ConTestServer) {
+	s.RegisterService(&ConTest_ServiceDesc, srv)
+}
+
+func _ConTest_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConTestServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConTest_Start_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConTestServer).Start(ctx, req.(*StartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConTest_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConTestServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConTest_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConTestServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConTest_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConTestServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConTest_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConTestServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConTest_Retry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConTestServer).Retry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConTest_Retry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConTestServer).Retry(ctx, req.(*RetryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConTest_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConTestServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConTest_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConTestServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConTest_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConTestServer).WatchEvents(m, &conTestWatchEventsServer{stream})
+}
+
+type ConTest_WatchEventsServer interface {
+	Send(*WatchEventsResponse) error
+	grpc.ServerStream
+}
+
+type conTestWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *conTestWatchEventsServer) Send(m *WatchEventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ConTest_ServiceDesc is the grpc.ServiceDesc for ConTest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConTest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "contest.v1.ConTest",
+	HandlerType: (*ConTestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Start",
+			Handler:    _ConTest_Start_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _ConTest_Stop_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _ConTest_Status_Handler,
+		},
+		{
+			MethodName: "Retry",
+			Handler:    _ConTest_Retry_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _ConTest_Version_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _ConTest_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "contest.proto",
+}
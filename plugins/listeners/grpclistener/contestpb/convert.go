@@ -0,0 +1,150 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package contestpb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/target"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp converts a time to a timestamp. The zero time, which means "not
+// set" in ConTest, is converted to nil.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// rawJSON returns the content of an optional raw JSON message.
+func rawJSON(msg *json.RawMessage) []byte {
+	if msg == nil {
+		return nil
+	}
+	return []byte(*msg)
+}
+
+// NewTarget converts a target.Target to its protobuf representation.
+func NewTarget(t *target.Target) *Target {
+	if t == nil {
+		return nil
+	}
+	return &Target{Name: t.Name, Id: t.ID, Fqdn: t.FQDN}
+}
+
+// NewTestEvent converts a testevent.Event to its protobuf representation.
+func NewTestEvent(ev *testevent.Event) *TestEvent {
+	ret := TestEvent{EmitTime: timestamp(ev.EmitTime)}
+	if ev.Header != nil {
+		ret.JobId = uint64(ev.Header.JobID)
+		ret.TestName = ev.Header.TestName
+		ret.TestStepLabel = ev.Header.TestStepLabel
+	}
+	if ev.Data != nil {
+		ret.EventName = string(ev.Data.EventName)
+		ret.TestStepIndex = uint32(ev.Data.TestStepIndex)
+		ret.Target = NewTarget(ev.Data.Target)
+		ret.Payload = rawJSON(ev.Data.Payload)
+	}
+	return &ret
+}
+
+// NewFrameworkEvent converts a frameworkevent.Event to its protobuf
+// representation.
+func NewFrameworkEvent(ev *frameworkevent.Event) *FrameworkEvent {
+	return &FrameworkEvent{
+		EmitTime:  timestamp(ev.EmitTime),
+		JobId:     uint64(ev.JobID),
+		EventName: string(ev.EventName),
+		Payload:   rawJSON(ev.Payload),
+	}
+}
+
+// NewReport converts a job.Report to its protobuf representation. The report
+// data is JSON-encoded.
+func NewReport(r *job.Report) (*Report, error) {
+	data, err := r.ToJSON()
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode report data: %v", err)
+	}
+	return &Report{
+		Success:    r.Success,
+		ReportTime: timestamp(r.ReportTime),
+		Data:       bytes.TrimSpace(data),
+	}, nil
+}
+
+// NewJobReport converts a job.JobReport to its protobuf representation.
+func NewJobReport(r *job.JobReport) (*JobReport, error) {
+	ret := JobReport{JobId: uint64(r.JobID)}
+	for _, runReports := range r.RunReports {
+		var run RunReports
+		for _, report := range runReports {
+			pbReport, err := NewReport(report)
+			if err != nil {
+				return nil, err
+			}
+			run.Reports = append(run.Reports, pbReport)
+		}
+		ret.RunReports = append(ret.RunReports, &run)
+	}
+	for _, report := range r.FinalReports {
+		pbReport, err := NewReport(report)
+		if err != nil {
+			return nil, err
+		}
+		ret.FinalReports = append(ret.FinalReports, pbReport)
+	}
+	return &ret, nil
+}
+
+// NewJobStatus converts a job.Status to its protobuf representation.
+func NewJobStatus(s *job.Status) (*JobStatus, error) {
+	ret := JobStatus{
+		Name:      s.Name,
+		State:     s.State,
+		StartTime: timestamp(s.StartTime),
+		EndTime:   timestamp(s.EndTime),
+	}
+	for _, testStatus := range s.TestStatus {
+		pbTestStatus := TestStatus{TestName: testStatus.TestName}
+		for _, stepStatus := range testStatus.TestStepStatus {
+			pbStepStatus := TestStepStatus{
+				TestStepName:  stepStatus.TestStepName,
+				TestStepLabel: stepStatus.TestStepLabel,
+			}
+			for idx := range stepStatus.Events {
+				pbStepStatus.Events = append(pbStepStatus.Events, NewTestEvent(&stepStatus.Events[idx]))
+			}
+			for _, targetStatus := range stepStatus.TargetStatus {
+				pbStepStatus.TargetStatus = append(pbStepStatus.TargetStatus, &TargetStatus{
+					Target:  NewTarget(targetStatus.Target),
+					InTime:  timestamp(targetStatus.InTime),
+					OutTime: timestamp(targetStatus.OutTime),
+					Error:   rawJSON(targetStatus.Error),
+				})
+			}
+			pbTestStatus.TestStepStatus = append(pbTestStatus.TestStepStatus, &pbStepStatus)
+		}
+		ret.TestStatus = append(ret.TestStatus, &pbTestStatus)
+	}
+	if s.JobReport != nil {
+		jobReport, err := NewJobReport(s.JobReport)
+		if err != nil {
+			return nil, err
+		}
+		ret.JobReport = jobReport
+	}
+	return &ret, nil
+}
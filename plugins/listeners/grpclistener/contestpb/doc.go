@@ -0,0 +1,11 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package contestpb contains the protobuf definitions of the ConTest gRPC API,
+// and the conversions from the ConTest types to their protobuf
+// representation.
+package contestpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative contest.proto
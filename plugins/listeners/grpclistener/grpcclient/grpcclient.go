@@ -0,0 +1,146 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package grpcclient implements a client of the ConTest gRPC API.
+package grpcclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener/contestpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client drives ConTest via its gRPC API, on behalf of a requestor. Errors
+// are gRPC status errors, whose code can be retrieved with status.Code.
+type Client struct {
+	stub      contestpb.ConTestClient
+	requestor string
+	conn      *grpc.ClientConn
+}
+
+// TokenCredentials implements credentials.PerRPCCredentials, authenticating
+// the RPCs with a bearer token. Pass it to Dial with
+// grpc.WithPerRPCCredentials.
+type TokenCredentials struct {
+	Token string
+	// AllowInsecure allows sending the token over connections without
+	// transport security, e.g. for local testing.
+	AllowInsecure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.GetRequestMetadata.
+func (t TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.Token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.RequireTransportSecurity.
+func (t TokenCredentials) RequireTransportSecurity() bool {
+	return !t.AllowInsecure
+}
+
+// New returns a Client using the given connection.
+func New(conn grpc.ClientConnInterface, requestor string) *Client {
+	return &Client{stub: contestpb.NewConTestClient(conn), requestor: requestor}
+}
+
+// Dial connects to the ConTest gRPC API at the given address, and returns a
+// Client using the connection. The connection is closed by Close.
+func Dial(addr, requestor string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := New(conn, requestor)
+	c.conn = conn
+	return c, nil
+}
+
+// Close closes the connection opened by Dial, if any.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Start starts a job with the given JSON job descriptor, and returns its ID.
+func (c *Client) Start(ctx context.Context, jobDescriptor string) (types.JobID, error) {
+	resp, err := c.stub.Start(ctx, &contestpb.StartRequest{Requestor: c.requestor, JobDescriptor: jobDescriptor})
+	if err != nil {
+		return 0, err
+	}
+	return types.JobID(resp.JobId), nil
+}
+
+// Stop stops a running job.
+func (c *Client) Stop(ctx context.Context, jobID types.JobID) error {
+	_, err := c.stub.Stop(ctx, &contestpb.StopRequest{Requestor: c.requestor, JobId: uint64(jobID)})
+	return err
+}
+
+// Status returns the status of a job.
+func (c *Client) Status(ctx context.Context, jobID types.JobID) (*contestpb.JobStatus, error) {
+	resp, err := c.stub.Status(ctx, &contestpb.StatusRequest{Requestor: c.requestor, JobId: uint64(jobID)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Retry starts a new job with the descriptor of the given one, optionally on
+// the targets that failed only, and returns the ID of the new job.
+func (c *Client) Retry(ctx context.Context, jobID types.JobID, failedTargetsOnly bool) (types.JobID, error) {
+	resp, err := c.stub.Retry(ctx, &contestpb.RetryRequest{Requestor: c.requestor, JobId: uint64(jobID), FailedTargetsOnly: failedTargetsOnly})
+	if err != nil {
+		return 0, err
+	}
+	return types.JobID(resp.NewJobId), nil
+}
+
+// Version returns the version of the API.
+func (c *Client) Version(ctx context.Context) (uint32, error) {
+	resp, err := c.stub.Version(ctx, &contestpb.VersionRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// WatchEvents calls fn for each event of a job following the one with ID
+// afterID, until the job terminates, fn returns an error or the context is
+// done. If the server drops the stream because the client cannot keep up, the
+// client resumes watching from the last event it received.
+func (c *Client) WatchEvents(ctx context.Context, jobID types.JobID, afterID uint64, fn func(*contestpb.WatchEventsResponse) error) error {
+	for {
+		stream, err := c.stub.WatchEvents(ctx, &contestpb.WatchEventsRequest{Requestor: c.requestor, JobId: uint64(jobID), AfterId: afterID})
+		if err != nil {
+			return err
+		}
+		progress := false
+		for {
+			ev, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				if status.Code(err) == codes.Unavailable && progress && ctx.Err() == nil {
+					// resume from the last event received
+					break
+				}
+				return err
+			}
+			if err := fn(ev); err != nil {
+				return err
+			}
+			afterID = ev.Id
+			progress = true
+		}
+	}
+}
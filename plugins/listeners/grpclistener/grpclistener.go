@@ -0,0 +1,245 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/logging"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener/contestpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+var log = logging.GetLogger("listeners/grpclistener")
+
+// DefaultAddr is the default address the gRPC listener listens on.
+const DefaultAddr = ":8081"
+
+// GRPCListener implements the api.Listener interface, exposing the API over
+// gRPC.
+type GRPCListener struct {
+	addr          string
+	authenticator api.Authenticator
+	tlsConfig     *tls.Config
+}
+
+// Option is an optional setting of the GRPCListener.
+type Option func(l *GRPCListener)
+
+// WithAuthenticator makes the listener authenticate the RPCs, and derive their
+// requestor from their credentials. Bearer tokens are passed via the
+// "authorization" metadata, client certificates via TLS. Signed requests are
+// not supported over gRPC.
+func WithAuthenticator(a api.Authenticator) Option {
+	return func(l *GRPCListener) {
+		l.authenticator = a
+	}
+}
+
+// WithTLSConfig makes the listener serve over TLS with the given
+// configuration.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(l *GRPCListener) {
+		l.tlsConfig = cfg
+	}
+}
+
+// New returns a GRPCListener listening on the given TCP address.
+func New(addr string, opts ...Option) *GRPCListener {
+	l := GRPCListener{addr: addr}
+	for _, opt := range opts {
+		opt(&l)
+	}
+	return &l
+}
+
+// statusError maps an API error to a gRPC status error.
+func statusError(err error) error {
+	code := codes.Internal
+	switch api.ErrorKindOf(err) {
+	case api.ErrorKindNotFound:
+		code = codes.NotFound
+	case api.ErrorKindConflict:
+		code = codes.FailedPrecondition
+	case api.ErrorKindInvalid:
+		code = codes.InvalidArgument
+	case api.ErrorKindUnavailable:
+		code = codes.Unavailable
+	case api.ErrorKindUnauthenticated:
+		code = codes.Unauthenticated
+	case api.ErrorKindPermissionDenied:
+		code = codes.PermissionDenied
+	}
+	return status.Error(code, err.Error())
+}
+
+// apiError returns the error of an API call, either returned by the API method
+// or carried by the response, as a gRPC status error.
+func apiError(resp api.Response, err error) error {
+	if err == nil {
+		err = resp.Err
+	}
+	if err == nil {
+		return nil
+	}
+	return statusError(err)
+}
+
+// server implements contestpb.ConTestServer by translating the RPCs to API
+// calls.
+type server struct {
+	contestpb.UnimplementedConTestServer
+	api *api.API
+}
+
+func (s *server) Start(ctx context.Context, req *contestpb.StartRequest) (*contestpb.StartResponse, error) {
+	requestor, err := requestorOf(ctx, req.Requestor)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := s.api.Start(requestor, req.JobDescriptor)
+	if err := apiError(resp, err); err != nil {
+		return nil, err
+	}
+	return &contestpb.StartResponse{JobId: uint64(resp.Data.(api.ResponseDataStart).JobID)}, nil
+}
+
+func (s *server) Stop(ctx context.Context, req *contestpb.StopRequest) (*contestpb.StopResponse, error) {
+	requestor, err := requestorOf(ctx, req.Requestor)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := s.api.Stop(requestor, types.JobID(req.JobId))
+	if err := apiError(resp, err); err != nil {
+		return nil, err
+	}
+	return &contestpb.StopResponse{}, nil
+}
+
+func (s *server) Status(ctx context.Context, req *contestpb.StatusRequest) (*contestpb.StatusResponse, error) {
+	requestor, err := requestorOf(ctx, req.Requestor)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := s.api.Status(requestor, types.JobID(req.JobId))
+	if err := apiError(resp, err); err != nil {
+		return nil, err
+	}
+	jobStatus := resp.Data.(api.ResponseDataStatus).Status
+	if jobStatus == nil {
+		return &contestpb.StatusResponse{}, nil
+	}
+	pbStatus, err := contestpb.NewJobStatus(jobStatus)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &contestpb.StatusResponse{Status: pbStatus}, nil
+}
+
+func (s *server) Retry(ctx context.Context, req *contestpb.RetryRequest) (*contestpb.RetryResponse, error) {
+	requestor, err := requestorOf(ctx, req.Requestor)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := s.api.Retry(requestor, types.JobID(req.JobId), req.FailedTargetsOnly)
+	if err := apiError(resp, err); err != nil {
+		return nil, err
+	}
+	data := resp.Data.(api.ResponseDataRetry)
+	return &contestpb.RetryResponse{JobId: uint64(data.JobID), NewJobId: uint64(data.NewJobID)}, nil
+}
+
+func (s *server) Version(ctx context.Context, req *contestpb.VersionRequest) (*contestpb.VersionResponse, error) {
+	return &contestpb.VersionResponse{Version: s.api.Version().Data.(api.ResponseDataVersion).Version}, nil
+}
+
+func (s *server) WatchEvents(req *contestpb.WatchEventsRequest, stream contestpb.ConTest_WatchEventsServer) error {
+	requestor, err := requestorOf(stream.Context(), req.Requestor)
+	if err != nil {
+		return statusError(err)
+	}
+	resp, err := s.api.Watch(requestor, types.JobID(req.JobId), req.AfterId)
+	if err := apiError(resp, err); err != nil {
+		return err
+	}
+	sub := resp.Data.(api.ResponseDataWatch).Subscription
+	defer sub.Cancel()
+	gap := sub.Gap
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					log.Warningf("Dropping slow watcher of job %d", req.JobId)
+					return status.Error(codes.Unavailable, "watcher cannot keep up with the events")
+				}
+				return nil
+			}
+			msg := contestpb.WatchEventsResponse{Id: ev.ID, Gap: gap}
+			gap = false
+			if ev.TestEvent != nil {
+				msg.Event = &contestpb.WatchEventsResponse_TestEvent{TestEvent: contestpb.NewTestEvent(ev.TestEvent)}
+			} else {
+				msg.Event = &contestpb.WatchEventsResponse_FrameworkEvent{FrameworkEvent: contestpb.NewFrameworkEvent(ev.FrameworkEvent)}
+			}
+			if err := stream.Send(&msg); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// NewServer returns a gRPC server exposing the given API.
+func NewServer(a *api.API, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	contestpb.RegisterConTestServer(s, &server{api: a})
+	return s
+}
+
+// Serve implements the api.Listener.Serve interface method. It starts a gRPC
+// server, and stops it when a cancellation is requested.
+func (l *GRPCListener) Serve(cancel <-chan struct{}, a *api.API) error {
+	if a == nil {
+		return errors.New("API object is nil")
+	}
+	lis, err := net.Listen("tcp", l.addr)
+	if err != nil {
+		return fmt.Errorf("gRPC listener failed: %v", err)
+	}
+	var opts []grpc.ServerOption
+	if l.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(l.tlsConfig)))
+	}
+	if l.authenticator != nil {
+		opts = append(opts, AuthInterceptors(l.authenticator)...)
+	}
+	s := NewServer(a, opts...)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Serve(lis)
+	}()
+	log.Infof("Started gRPC API listener on %s", lis.Addr())
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("gRPC listener failed: %v", err)
+	case <-cancel:
+		log.Printf("Received server shut down request")
+		s.Stop()
+	}
+	log.Printf("Server shut down successfully.")
+	return nil
+}
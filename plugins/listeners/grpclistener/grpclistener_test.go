@@ -0,0 +1,236 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener/contestpb"
+	"github.com/facebookincubator/contest/plugins/listeners/grpclistener/grpcclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient starts a gRPC server whose API consumer answers the API events
+// via the given handler, and returns a client connected to it, and a function
+// to shut both down.
+func newTestClient(t *testing.T, handler func(ev *api.Event) *api.EventResponse) (*grpcclient.Client, func()) {
+	return newTestClientWithOptions(t, handler, "test", nil)
+}
+
+// newTestClientWithOptions is like newTestClient, with the given requestor and
+// additional server and dial options.
+func newTestClientWithOptions(t *testing.T, handler func(ev *api.Event) *api.EventResponse, requestor string, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*grpcclient.Client, func()) {
+	a := api.New()
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case ev := <-a.Events:
+				resp := handler(ev)
+				resp.Requestor = ev.Msg.Requestor()
+				ev.RespCh <- resp
+			case <-done:
+				return
+			}
+		}
+	}()
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(a, serverOpts...)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	client, err := grpcclient.Dial("bufnet", requestor, dialOpts...)
+	require.NoError(t, err)
+	return client, func() {
+		_ = client.Close()
+		s.Stop()
+		close(done)
+	}
+}
+
+func TestStart(t *testing.T) {
+	client, stop := newTestClient(t, func(ev *api.Event) *api.EventResponse {
+		require.Equal(t, api.EventTypeStart, ev.Type)
+		require.Equal(t, "{}", ev.Msg.(api.EventStartMsg).JobDescriptor)
+		return &api.EventResponse{JobID: 12}
+	})
+	defer stop()
+	jobID, err := client.Start(context.Background(), "{}")
+	require.NoError(t, err)
+	require.Equal(t, types.JobID(12), jobID)
+}
+
+func TestErrorCodes(t *testing.T) {
+	for kind, code := range map[api.ErrorKind]codes.Code{
+		api.ErrorKindNotFound:    codes.NotFound,
+		api.ErrorKindConflict:    codes.FailedPrecondition,
+		api.ErrorKindInvalid:     codes.InvalidArgument,
+		api.ErrorKindUnavailable: codes.Unavailable,
+		api.ErrorKindInternal:    codes.Internal,
+
+		api.ErrorKindUnauthenticated:   codes.Unauthenticated,
+		api.ErrorKindPermissionDenied: codes.PermissionDenied,
+	} {
+		client, stop := newTestClient(t, func(ev *api.Event) *api.EventResponse {
+			return &api.EventResponse{Err: api.NewError(kind, errors.New("failure"))}
+		})
+		err := client.Stop(context.Background(), 12)
+		stop()
+		require.Error(t, err)
+		require.Equal(t, code, status.Code(err))
+		require.Equal(t, "failure", status.Convert(err).Message())
+	}
+}
+
+func TestStatus(t *testing.T) {
+	payload := json.RawMessage(`{"key": "value"}`)
+	startTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	jobStatus := &job.Status{
+		Name:      "test job",
+		State:     "JobStateCompleted",
+		StartTime: startTime,
+		TestStatus: []job.TestStatus{{
+			TestName: "test",
+			TestStepStatus: []job.TestStepStatus{{
+				TestStepName:  "cmd",
+				TestStepLabel: "label",
+				Events: []testevent.Event{{
+					Header: &testevent.Header{JobID: 12, TestName: "test", TestStepLabel: "label"},
+					Data:   &testevent.Data{EventName: "Started", Payload: &payload},
+				}},
+				TargetStatus: []job.TargetStatus{{Target: &target.Target{Name: "host", ID: "1"}, InTime: startTime}},
+			}},
+		}},
+		JobReport: &job.JobReport{
+			JobID:        12,
+			RunReports:   [][]*job.Report{{{Success: true, Data: "ok"}}},
+			FinalReports: []*job.Report{{Success: false, Data: map[string]int{"failed": 1}}},
+		},
+	}
+	client, stop := newTestClient(t, func(ev *api.Event) *api.EventResponse {
+		require.Equal(t, types.JobID(12), ev.Msg.(api.EventStatusMsg).JobID)
+		return &api.EventResponse{Status: jobStatus}
+	})
+	defer stop()
+	pbStatus, err := client.Status(context.Background(), 12)
+	require.NoError(t, err)
+	require.Equal(t, "test job", pbStatus.Name)
+	require.Equal(t, startTime, pbStatus.StartTime.AsTime())
+	require.Nil(t, pbStatus.EndTime)
+	stepStatus := pbStatus.TestStatus[0].TestStepStatus[0]
+	require.Equal(t, "Started", stepStatus.Events[0].EventName)
+	require.JSONEq(t, string(payload), string(stepStatus.Events[0].Payload))
+	require.Equal(t, "host", stepStatus.TargetStatus[0].Target.Name)
+	require.Equal(t, `"ok"`, string(pbStatus.JobReport.RunReports[0].Reports[0].Data))
+	require.Equal(t, `{"failed":1}`, string(pbStatus.JobReport.FinalReports[0].Data))
+}
+
+func TestRetry(t *testing.T) {
+	client, stop := newTestClient(t, func(ev *api.Event) *api.EventResponse {
+		msg := ev.Msg.(api.EventRetryMsg)
+		require.Equal(t, types.JobID(12), msg.JobID)
+		require.True(t, msg.FailedTargetsOnly)
+		return &api.EventResponse{JobID: 13}
+	})
+	defer stop()
+	newJobID, err := client.Retry(context.Background(), 12, true)
+	require.NoError(t, err)
+	require.Equal(t, types.JobID(13), newJobID)
+}
+
+func TestWatchEvents(t *testing.T) {
+	broker := storage.NewEventBroker(16, 16)
+	jobID := types.JobID(12)
+	broker.PublishFrameworkEvent(frameworkevent.Event{JobID: jobID, EventName: "JobStateStarted"})
+	broker.PublishTestEvent(testevent.Event{
+		Header: &testevent.Header{JobID: jobID, TestName: "test"},
+		Data:   &testevent.Data{EventName: "TargetIn", Target: &target.Target{Name: "host"}},
+	})
+	broker.PublishFrameworkEvent(frameworkevent.Event{JobID: jobID, EventName: "JobStateCompleted"})
+	broker.Close(jobID)
+
+	client, stop := newTestClient(t, func(ev *api.Event) *api.EventResponse {
+		msg := ev.Msg.(api.EventWatchMsg)
+		sub, err := broker.Subscribe(msg.JobID, msg.AfterID)
+		return &api.EventResponse{Subscription: sub, Err: err}
+	})
+	defer stop()
+	var events []*contestpb.WatchEventsResponse
+	err := client.WatchEvents(context.Background(), jobID, storage.StreamEventID(0, 1), func(ev *contestpb.WatchEventsResponse) error {
+		events = append(events, ev)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, storage.StreamEventID(1, 1), events[0].Id)
+	require.Equal(t, "host", events[0].GetTestEvent().Target.Name)
+	require.Equal(t, storage.StreamEventID(1, 2), events[1].Id)
+	require.Equal(t, "JobStateCompleted", events[1].GetFrameworkEvent().EventName)
+}
+
+// tokenAuthenticator authenticates the "secret" token as "alice".
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(creds *api.Credentials) (api.EventRequestor, error) {
+	if creds.Token == "" {
+		return "", api.ErrNoCredentials
+	}
+	if creds.Token != "secret" {
+		return "", api.NewError(api.ErrorKindUnauthenticated, errors.New("invalid token"))
+	}
+	return "alice", nil
+}
+
+func TestAuthToken(t *testing.T) {
+	var requestors []api.EventRequestor
+	handler := func(ev *api.Event) *api.EventResponse {
+		requestors = append(requestors, ev.Msg.Requestor())
+		return &api.EventResponse{}
+	}
+	serverOpts := AuthInterceptors(tokenAuthenticator{})
+	token := func(token string) grpc.DialOption {
+		return grpc.WithPerRPCCredentials(grpcclient.TokenCredentials{Token: token, AllowInsecure: true})
+	}
+	for _, tc := range []struct {
+		requestor string
+		dialOpts  []grpc.DialOption
+		code      codes.Code
+	}{
+		{requestor: "", code: codes.Unauthenticated},
+		{requestor: "", dialOpts: []grpc.DialOption{token("invalid")}, code: codes.Unauthenticated},
+		{requestor: "bob", dialOpts: []grpc.DialOption{token("secret")}, code: codes.PermissionDenied},
+		{requestor: "alice", dialOpts: []grpc.DialOption{token("secret")}, code: codes.OK},
+		{requestor: "", dialOpts: []grpc.DialOption{token("secret")}, code: codes.OK},
+	} {
+		client, stop := newTestClientWithOptions(t, handler, tc.requestor, serverOpts, tc.dialOpts...)
+		err := client.Stop(context.Background(), 12)
+		stop()
+		require.Equal(t, tc.code, status.Code(err), tc)
+	}
+	// only the authenticated requests reach the API
+	require.Equal(t, []api.EventRequestor{"alice", "alice"}, requestors)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/api"
+)
+
+// Headers of signed requests. The signature is the hex-encoded HMAC-SHA256 of
+// the message returned by api.SignatureMessage for the request, and the
+// timestamp is in seconds since the epoch.
+const (
+	HeaderKeyID     = "X-Contest-Key-Id"
+	HeaderTimestamp = "X-Contest-Timestamp"
+	HeaderSignature = "X-Contest-Signature"
+)
+
+// maxSignedBodySize is the maximum size of the body of a signed request.
+const maxSignedBodySize = 16 * 1024 * 1024
+
+// SignRequest signs a request with the given key, so that it can be
+// authenticated by an api.HMACAuthenticator. The body of the request is read
+// and replaced, so that it can still be sent.
+func SignRequest(r *http.Request, keyID string, key []byte) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("cannot read request body: %v", err)
+		}
+		_ = r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	now := time.Now()
+	mac := api.Sign(key, api.SignatureMessage(r.Method, r.URL.RequestURI(), now, body))
+	r.Header.Set(HeaderKeyID, keyID)
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	r.Header.Set(HeaderSignature, hex.EncodeToString(mac))
+	return nil
+}
+
+// requestCredentials extracts the credentials of a request: the bearer token
+// from the Authorization header, the verified client certificate, and the
+// signature.
+func requestCredentials(r *http.Request) (*api.Credentials, error) {
+	var creds api.Credentials
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		creds.Token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		creds.Certificate = r.TLS.VerifiedChains[0][0]
+	}
+	if keyID := r.Header.Get(HeaderKeyID); keyID != "" {
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature timestamp: %v", err)
+		}
+		mac, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature: %v", err)
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxSignedBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read request body: %v", err)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		creds.Signature = &api.Signature{
+			KeyID:     keyID,
+			Timestamp: time.Unix(timestamp, 0),
+			Message:   api.SignatureMessage(r.Method, r.URL.RequestURI(), time.Unix(timestamp, 0), body),
+			MAC:       mac,
+		}
+	}
+	return &creds, nil
+}
+
+type contextKey int
+
+// requestorKey is the context key of the authenticated requestor.
+const requestorKey contextKey = iota
+
+// authHandler authenticates the requests before passing them to the next
+// handler, with the authenticated requestor in their context.
+type authHandler struct {
+	next          http.Handler
+	authenticator api.Authenticator
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	creds, err := requestCredentials(r)
+	if err != nil {
+		replyError(w, http.StatusBadRequest, err)
+		return
+	}
+	requestor, err := h.authenticator.Authenticate(creds)
+	if err == api.ErrNoCredentials {
+		err = errors.New("missing credentials")
+	}
+	if err != nil {
+		log.Warningf("Rejecting unauthenticated request from %s: %v", r.RemoteAddr, err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		replyError(w, http.StatusUnauthorized, fmt.Errorf("authentication failed: %v", err))
+		return
+	}
+	h.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestorKey, requestor)))
+}
+
+// requestorOf returns the requestor of a request. If requests are
+// authenticated, this is the authenticated requestor, and the claimed one
+// must either match or be empty. Otherwise, the claimed requestor is trusted.
+func requestorOf(r *http.Request, claimed string) (api.EventRequestor, error) {
+	authenticated, ok := r.Context().Value(requestorKey).(api.EventRequestor)
+	if !ok {
+		return api.EventRequestor(claimed), nil
+	}
+	return api.CheckRequestor(authenticated, api.EventRequestor(claimed))
+}
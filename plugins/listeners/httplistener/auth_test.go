@@ -0,0 +1,122 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// authRequest sends a request to the REST handler, authenticating it with the
+// given authenticator. The API consumer replies with an empty response. It
+// returns the message received by the API consumer, if any, and the recorded
+// HTTP response.
+func authRequest(t *testing.T, authenticator api.Authenticator, req *http.Request) (api.EventMsg, *httptest.ResponseRecorder) {
+	a := api.New()
+	msgCh := make(chan api.EventMsg, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case ev := <-a.Events:
+			msgCh <- ev.Msg
+			ev.RespCh <- &api.EventResponse{Requestor: ev.Msg.Requestor()}
+		case <-done:
+		}
+	}()
+	w := httptest.NewRecorder()
+	h := &authHandler{next: &restHandler{api: a}, authenticator: authenticator}
+	h.ServeHTTP(w, req)
+	select {
+	case msg := <-msgCh:
+		return msg, w
+	default:
+		return nil, w
+	}
+}
+
+func newAuthenticator(t *testing.T, secrets string) (api.Authenticator, func()) {
+	fd, err := ioutil.TempFile("", "contest-secrets")
+	require.NoError(t, err)
+	defer fd.Close()
+	_, err = fd.WriteString(secrets)
+	require.NoError(t, err)
+	tokens, err := api.NewTokenAuthenticator(fd.Name())
+	require.NoError(t, err)
+	keys, err := api.NewHMACAuthenticator(fd.Name())
+	require.NoError(t, err)
+	return api.MultiAuthenticator{tokens, keys}, func() { _ = os.Remove(fd.Name()) }
+}
+
+func TestAuthToken(t *testing.T) {
+	authenticator, cleanup := newAuthenticator(t, "alice secret-a\n")
+	defer cleanup()
+
+	// missing credentials
+	msg, w := authRequest(t, authenticator, httptest.NewRequest("DELETE", "/v1/jobs/12", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	require.Nil(t, msg)
+
+	// invalid token
+	req := httptest.NewRequest("DELETE", "/v1/jobs/12", nil)
+	req.Header.Set("Authorization", "Bearer secret-b")
+	msg, w = authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Nil(t, msg)
+
+	// the requestor is derived from the token
+	req = httptest.NewRequest("DELETE", "/v1/jobs/12", nil)
+	req.Header.Set("Authorization", "Bearer secret-a")
+	msg, w = authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, api.EventRequestor("alice"), msg.Requestor())
+	require.Equal(t, types.JobID(12), msg.(api.EventStopMsg).JobID)
+
+	// spoofed requestor
+	req = httptest.NewRequest("DELETE", "/v1/jobs/12?requestor=bob", nil)
+	req.Header.Set("Authorization", "Bearer secret-a")
+	msg, w = authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Nil(t, msg)
+}
+
+func TestAuthSignedRequest(t *testing.T) {
+	authenticator, cleanup := newAuthenticator(t, "alice secret-a\n")
+	defer cleanup()
+
+	body := `{"Requestor": "alice", "JobDescriptor": {}}`
+	req := httptest.NewRequest("POST", "/v1/jobs", strings.NewReader(body))
+	require.NoError(t, SignRequest(req, "alice", []byte("secret-a")))
+	msg, w := authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, api.EventRequestor("alice"), msg.Requestor())
+	require.Equal(t, `{}`, msg.(api.EventStartMsg).JobDescriptor)
+
+	// the body was tampered with after signing
+	req = httptest.NewRequest("POST", "/v1/jobs", strings.NewReader(body))
+	require.NoError(t, SignRequest(req, "alice", []byte("secret-a")))
+	req.Body = ioutil.NopCloser(strings.NewReader(`{"JobDescriptor": {"JobName": "other"}}`))
+	msg, w = authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Nil(t, msg)
+
+	// malformed signature
+	req = httptest.NewRequest("POST", "/v1/jobs", strings.NewReader(body))
+	req.Header.Set(HeaderKeyID, "alice")
+	req.Header.Set(HeaderTimestamp, "now")
+	msg, w = authRequest(t, authenticator, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Nil(t, msg)
+}
@@ -0,0 +1,26 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build ignore
+
+// gen_openapi writes the OpenAPI document of the REST API to openapi.json.
+package main
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/facebookincubator/contest/plugins/listeners/httplistener"
+)
+
+func main() {
+	doc, err := httplistener.OpenAPIDocument()
+	if err != nil {
+		log.Fatalf("Cannot generate OpenAPI document: %v", err)
+	}
+	if err := ioutil.WriteFile("openapi.json", append(doc, '\n'), 0644); err != nil {
+		log.Fatalf("Cannot write OpenAPI document: %v", err)
+	}
+}
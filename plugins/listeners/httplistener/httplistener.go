@@ -7,23 +7,118 @@ package httplistener
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/logging"
 	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/insomniacslk/xjson"
 )
 
 var log = logging.GetLogger("listeners/httplistener")
 
-// HTTPListener implements the api.Listener interface.
+// Defaults of the HTTPListener settings.
+const (
+	DefaultAddr         = ":8080"
+	DefaultReadTimeout  = 10 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultMaxBodySize  = 16 * 1024 * 1024
+)
+
+// unixAddrPrefix is the prefix of listen addresses which are unix sockets.
+const unixAddrPrefix = "unix:"
+
+// HTTPListener implements the api.Listener interface. The zero value listens on
+// DefaultAddr with the default settings.
 type HTTPListener struct {
+	authenticator api.Authenticator
+	tlsConfig     *tls.Config
+	addr          string
+	basePath      string
+	maxBodySize   int64
+	readTimeout   time.Duration
+	writeTimeout  time.Duration
+}
+
+// Option is an optional setting of the HTTPListener.
+type Option func(l *HTTPListener)
+
+// WithAddr sets the listen address, either a TCP "[host]:port" address or a
+// unix socket as "unix:/path/to/socket".
+func WithAddr(addr string) Option {
+	return func(l *HTTPListener) {
+		l.addr = addr
+	}
+}
+
+// WithBasePath serves the API under the given path prefix, e.g. when the
+// listener is behind a reverse proxy which does not strip it.
+func WithBasePath(basePath string) Option {
+	return func(l *HTTPListener) {
+		l.basePath = "/" + strings.Trim(basePath, "/")
+		if l.basePath == "/" {
+			l.basePath = ""
+		}
+	}
+}
+
+// WithMaxBodySize sets the maximum size in bytes of request bodies. Larger
+// requests are rejected.
+func WithMaxBodySize(size int64) Option {
+	return func(l *HTTPListener) {
+		l.maxBodySize = size
+	}
+}
+
+// WithReadTimeout sets the maximum duration for reading a request.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(l *HTTPListener) {
+		l.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the maximum duration for handling a request and
+// writing the response. It does not apply to the requests streaming events.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(l *HTTPListener) {
+		l.writeTimeout = timeout
+	}
+}
+
+// WithAuthenticator makes the listener authenticate the requests, and derive
+// their requestor from their credentials.
+func WithAuthenticator(a api.Authenticator) Option {
+	return func(l *HTTPListener) {
+		l.authenticator = a
+	}
+}
+
+// WithTLSConfig makes the listener serve HTTPS with the given configuration.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(l *HTTPListener) {
+		l.tlsConfig = cfg
+	}
+}
+
+// New returns an HTTPListener with the given options.
+func New(opts ...Option) *HTTPListener {
+	var l HTTPListener
+	for _, opt := range opts {
+		opt(&l)
+	}
+	return &l
 }
 
 // HTTPAPIResponse is returned when an API method succeeds. It wraps the content
@@ -63,6 +158,17 @@ type HTTPAPIError struct {
 	Msg string
 }
 
+func strToScheduleID(s string) (types.ScheduleID, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, errors.New("schedule ID cannot be empty")
+	}
+	scheduleID, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return types.ScheduleID(scheduleID), nil
+}
+
 func strToJobID(s string) (types.JobID, error) {
 	if strings.TrimSpace(s) == "" {
 		return 0, errors.New("job ID cannot be empty")
@@ -74,6 +180,62 @@ func strToJobID(s string) (types.JobID, error) {
 	return types.JobID(jobIDInt), nil
 }
 
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseListQuery builds a job.ListQuery from the form values of a list request.
+// Lists are comma-separated, and times are in RFC3339 format.
+func parseListQuery(r *http.Request) (*job.ListQuery, error) {
+	var (
+		query job.ListQuery
+		err   error
+	)
+	for _, state := range splitList(r.FormValue("states")) {
+		query.States = append(query.States, event.Name(state))
+	}
+	query.Tags = splitList(r.FormValue("tags"))
+	if v := r.FormValue("allTags"); v != "" {
+		if query.MatchAllTags, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid allTags value: %v", err)
+		}
+	}
+	query.Requestor = r.FormValue("jobRequestor")
+	query.NamePattern = r.FormValue("name")
+	if v := r.FormValue("requestedAfter"); v != "" {
+		if query.RequestedAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid requestedAfter value: %v", err)
+		}
+	}
+	if v := r.FormValue("requestedBefore"); v != "" {
+		if query.RequestedBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid requestedBefore value: %v", err)
+		}
+	}
+	if v := r.FormValue("offset"); v != "" {
+		offset, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset value: %v", err)
+		}
+		query.Offset = uint(offset)
+	}
+	if v := r.FormValue("limit"); v != "" {
+		limit, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit value: %v", err)
+		}
+		query.Limit = uint(limit)
+	}
+	return &query, nil
+}
+
 type apiHandler struct {
 	api *api.API
 }
@@ -85,6 +247,16 @@ func reply(w http.ResponseWriter, status int, msg string) {
 	}
 }
 
+// replyError replies with an HTTPAPIError carrying the error message
+func replyError(w http.ResponseWriter, status int, err error) {
+	msg, jsonErr := json.Marshal(HTTPAPIError{Msg: err.Error()})
+	if jsonErr != nil {
+		panic(fmt.Sprintf("cannot marshal HTTPAPIError: %v", jsonErr))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	reply(w, status, string(msg))
+}
+
 func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	verb := strings.TrimLeft(r.URL.Path, "/")
 	var (
@@ -101,7 +273,11 @@ func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	jobIDStr := r.PostFormValue("jobID")
 	jobDesc := r.PostFormValue("jobDesc")
-	requestor := api.EventRequestor(r.PostFormValue("requestor"))
+	requestor, err := requestorOf(r, r.PostFormValue("requestor"))
+	if err != nil {
+		replyError(w, http.StatusForbidden, err)
+		return
+	}
 
 	switch verb {
 	case "start":
@@ -143,10 +319,125 @@ func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			errMsg = fmt.Sprintf("Retry failed: %v", err)
 			break
 		}
-		if resp, err = h.api.Retry(requestor, jobID); err != nil {
+		var failedTargetsOnly bool
+		if v := r.PostFormValue("failedTargetsOnly"); v != "" {
+			failedTargetsOnly, err = strconv.ParseBool(v)
+			if err != nil {
+				httpStatus = http.StatusBadRequest
+				errMsg = fmt.Sprintf("Retry failed: invalid failedTargetsOnly value: %v", err)
+				break
+			}
+		}
+		if resp, err = h.api.Retry(requestor, jobID, failedTargetsOnly); err != nil {
 			httpStatus = http.StatusBadRequest
 			errMsg = fmt.Sprintf("Retry failed: %v", err)
 		}
+	case "list":
+		query, err := parseListQuery(r)
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("List failed: %v", err)
+			break
+		}
+		if resp, err = h.api.List(requestor, *query); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("List failed: %v", err)
+		}
+	case "quarantine":
+		if resp, err = h.api.ListQuarantine(requestor); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Quarantine failed: %v", err)
+		}
+	case "release":
+		targetID := r.PostFormValue("targetID")
+		if targetID == "" {
+			httpStatus = http.StatusBadRequest
+			errMsg = "Missing target ID"
+			break
+		}
+		if resp, err = h.api.ReleaseQuarantine(requestor, targetID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Release failed: %v", err)
+		}
+	case "detach":
+		jobID, err := strToJobID(jobIDStr)
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Detach failed: %v", err)
+			break
+		}
+		targetID := r.PostFormValue("targetID")
+		if targetID == "" {
+			httpStatus = http.StatusBadRequest
+			errMsg = "Missing target ID"
+			break
+		}
+		if resp, err = h.api.DetachTarget(requestor, jobID, targetID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Detach failed: %v", err)
+		}
+	case "schedule":
+		if jobDesc == "" {
+			httpStatus = http.StatusBadRequest
+			errMsg = "Missing job description"
+			break
+		}
+		schedule := job.ScheduleDescriptor{
+			JobDescriptor: jobDesc,
+			Cron:          r.PostFormValue("cron"),
+			OverlapPolicy: job.OverlapPolicy(r.PostFormValue("overlapPolicy")),
+		}
+		if v := r.PostFormValue("interval"); v != "" {
+			interval, err := time.ParseDuration(v)
+			if err != nil {
+				httpStatus = http.StatusBadRequest
+				errMsg = fmt.Sprintf("Schedule failed: invalid interval value: %v", err)
+				break
+			}
+			schedule.Interval = xjson.Duration(interval)
+		}
+		if resp, err = h.api.CreateSchedule(requestor, schedule); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Schedule failed: %v", err)
+		}
+	case "schedules":
+		if resp, err = h.api.ListSchedules(requestor); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Schedules failed: %v", err)
+		}
+	case "pauseschedule":
+		scheduleID, err := strToScheduleID(r.PostFormValue("scheduleID"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Pause schedule failed: %v", err)
+			break
+		}
+		if resp, err = h.api.PauseSchedule(requestor, scheduleID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Pause schedule failed: %v", err)
+		}
+	case "resumeschedule":
+		scheduleID, err := strToScheduleID(r.PostFormValue("scheduleID"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Resume schedule failed: %v", err)
+			break
+		}
+		if resp, err = h.api.ResumeSchedule(requestor, scheduleID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Resume schedule failed: %v", err)
+		}
+	case "deleteschedule":
+		scheduleID, err := strToScheduleID(r.PostFormValue("scheduleID"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Delete schedule failed: %v", err)
+			break
+		}
+		if resp, err = h.api.DeleteSchedule(requestor, scheduleID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Delete schedule failed: %v", err)
+		}
 	case "version":
 		resp = h.api.Version()
 	default:
@@ -177,16 +468,173 @@ func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	reply(w, httpStatus, string(msg))
 }
 
-func listenWithCancellation(cancel <-chan struct{}, s *http.Server) error {
+// keepAliveInterval is the interval at which a comment is sent to watching
+// clients, to keep the connection alive while no event is emitted.
+var keepAliveInterval = 15 * time.Second
+
+type watchHandler struct {
+	api *api.API
+}
+
+// writeSSE writes a Server-Sent Event. The id is omitted if empty.
+func writeSSE(w http.ResponseWriter, id, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event data: %v", err)
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
+
+// ServeHTTP implements the legacy watch endpoint, see watchJob.
+func (h *watchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		reply(w, http.StatusBadRequest, "Only GET and POST requests are supported")
+		return
+	}
+	replyErr := func(err error) {
+		replyError(w, http.StatusBadRequest, fmt.Errorf("Watch failed: %v", err))
+	}
+	jobID, err := strToJobID(r.FormValue("jobID"))
+	if err != nil {
+		replyErr(err)
+		return
+	}
+	requestor, err := requestorOf(r, r.FormValue("requestor"))
+	if err != nil {
+		replyError(w, http.StatusForbidden, fmt.Errorf("Watch failed: %v", err))
+		return
+	}
+	watchJob(w, r, h.api, jobID, requestor, replyErr)
+}
+
+// watchJob streams the test and framework events of a job as Server-Sent
+// Events. Test events are named "test" and framework events "framework". A
+// "gap" event signals that some events were lost, and an "end" event that the
+// job does not emit events anymore. Clients can resume watching by passing the
+// ID of the last event they received, either via the Last-Event-ID header or
+// via the "after" parameter. Errors occurring before the stream starts are
+// passed to replyErr.
+func watchJob(w http.ResponseWriter, r *http.Request, a *api.API, jobID types.JobID, requestor api.EventRequestor, replyErr func(error)) {
+	afterStr := r.Header.Get("Last-Event-ID")
+	if afterStr == "" {
+		afterStr = r.FormValue("after")
+	}
+	var (
+		afterID uint64
+		err     error
+	)
+	if afterStr != "" {
+		if afterID, err = strconv.ParseUint(afterStr, 10, 64); err != nil {
+			replyErr(api.NewError(api.ErrorKindInvalid, fmt.Errorf("invalid event ID: %v", err)))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		replyErr(errors.New("streaming is not supported"))
+		return
+	}
+	resp, err := a.Watch(requestor, jobID, afterID)
+	if err != nil {
+		replyErr(err)
+		return
+	}
+	if resp.Err != nil {
+		replyErr(resp.Err)
+		return
+	}
+	sub := resp.Data.(api.ResponseDataWatch).Subscription
+	defer sub.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if sub.Gap {
+		if err := writeSSE(w, "", "gap", struct{}{}); err != nil {
+			log.Warningf("Cannot write to client socket: %v", err)
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					// the client is too slow, it can reconnect from the
+					// last event it received.
+					log.Warningf("Dropping slow watcher of job %d", jobID)
+					return
+				}
+				if err := writeSSE(w, "", "end", struct{}{}); err != nil {
+					log.Warningf("Cannot write to client socket: %v", err)
+				}
+				flusher.Flush()
+				return
+			}
+			id := strconv.FormatUint(ev.ID, 10)
+			if ev.TestEvent != nil {
+				err = writeSSE(w, id, "test", ev.TestEvent)
+			} else {
+				err = writeSSE(w, id, "framework", ev.FrameworkEvent)
+			}
+			if err != nil {
+				log.Warningf("Cannot write to client socket: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				log.Warningf("Cannot write to client socket: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// listen opens the listening socket for a TCP or unix socket address.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixAddrPrefix) {
+		return net.Listen("tcp", addr)
+	}
+	path := strings.TrimPrefix(addr, unixAddrPrefix)
+	// remove the socket left behind by a previous instance, if any
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("cannot remove stale socket %s: %v", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+func listenWithCancellation(cancel <-chan struct{}, s *http.Server, lis net.Listener) error {
 	var (
 		errCh = make(chan error, 1)
 	)
 	// start the listener asynchronously, and report errors and completion via
 	// channels.
 	go func() {
-		errCh <- s.ListenAndServe()
+		if s.TLSConfig != nil {
+			// the certificates are in the TLS configuration
+			errCh <- s.ServeTLS(lis, "", "")
+		} else {
+			errCh <- s.Serve(lis)
+		}
 	}()
-	log.Infof("Started HTTP API listener on %s", s.Addr)
+	log.Infof("Started HTTP API listener on %s", lis.Addr())
 	// wait for cancellation or for completion
 	select {
 	case err := <-errCh:
@@ -197,19 +645,80 @@ func listenWithCancellation(cancel <-chan struct{}, s *http.Server) error {
 	}
 }
 
+// maxBodyHandler limits the size of the request bodies.
+type maxBodyHandler struct {
+	next        http.Handler
+	maxBodySize int64
+}
+
+func (h *maxBodyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > h.maxBodySize {
+		replyError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is larger than %d bytes", h.maxBodySize))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	h.next.ServeHTTP(w, r)
+}
+
 // Serve implements the api.Listener.Serve interface method. It starts an HTTP
 // API listener and returns an api.Event channel that the caller can iterate on.
 func (h *HTTPListener) Serve(cancel <-chan struct{}, a *api.API) error {
 	if a == nil {
 		return errors.New("API object is nil")
 	}
+	var (
+		addr         = h.addr
+		maxBodySize  = h.maxBodySize
+		readTimeout  = h.readTimeout
+		writeTimeout = h.writeTimeout
+	)
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	if maxBodySize == 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	if readTimeout == 0 {
+		readTimeout = DefaultReadTimeout
+	}
+	if writeTimeout == 0 {
+		writeTimeout = DefaultWriteTimeout
+	}
+	// Watch requests stream events for as long as the job runs, so the write
+	// timeout only applies to the other requests. The legacy API is served at
+	// the root, and the REST API under RESTPrefix.
+	mux := http.NewServeMux()
+	mux.Handle("/watch", &watchHandler{api: a})
+	mux.Handle("/", http.TimeoutHandler(&apiHandler{api: a}, writeTimeout, "request timed out"))
+	rest := &restHandler{api: a}
+	restWithTimeout := http.TimeoutHandler(rest, writeTimeout, "request timed out")
+	mux.HandleFunc(RESTPrefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/events") {
+			rest.ServeHTTP(w, r)
+			return
+		}
+		restWithTimeout.ServeHTTP(w, r)
+	})
+	var handler http.Handler = mux
+	if h.basePath != "" {
+		handler = http.StripPrefix(h.basePath, handler)
+	}
+	// signatures cover the full request URI, so requests are authenticated
+	// before stripping the base path.
+	if h.authenticator != nil {
+		handler = &authHandler{next: handler, authenticator: h.authenticator}
+	}
+	handler = &maxBodyHandler{next: handler, maxBodySize: maxBodySize}
 	s := http.Server{
-		Addr:         ":8080",
-		Handler:      &apiHandler{api: a},
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		Handler:     handler,
+		ReadTimeout: readTimeout,
+		TLSConfig:   h.tlsConfig,
+	}
+	lis, err := listen(addr)
+	if err != nil {
+		return fmt.Errorf("HTTP listener failed: %v", err)
 	}
-	if err := listenWithCancellation(cancel, &s); err != nil {
+	if err := listenWithCancellation(cancel, &s, lis); err != nil {
 		return fmt.Errorf("HTTP listener failed: %v", err)
 	}
 	log.Printf("Server shut down successfully.")
@@ -0,0 +1,241 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// apiRequest sends a request for the given verb to the API handler, and
+// returns the message received by the API consumer, if any, and the HTTP
+// status.
+func apiRequest(t *testing.T, verb string, form url.Values) (api.EventMsg, int) {
+	a := api.New()
+	msgCh := make(chan api.EventMsg, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case ev := <-a.Events:
+			msgCh <- ev.Msg
+			ev.RespCh <- &api.EventResponse{Requestor: ev.Msg.Requestor()}
+		case <-done:
+		}
+	}()
+
+	req := httptest.NewRequest("POST", "/"+verb, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h := &apiHandler{api: a}
+	h.ServeHTTP(w, req)
+
+	select {
+	case msg := <-msgCh:
+		return msg, w.Code
+	default:
+		return nil, w.Code
+	}
+}
+
+// retryRequest sends a retry request to the API handler, and returns the
+// retry message received by the API consumer, if any, and the HTTP status.
+func retryRequest(t *testing.T, form url.Values) (*api.EventRetryMsg, int) {
+	msg, status := apiRequest(t, "retry", form)
+	if msg == nil {
+		return nil, status
+	}
+	retryMsg := msg.(api.EventRetryMsg)
+	return &retryMsg, status
+}
+
+// listRequest sends a list request to the API handler, and returns the list
+// message received by the API consumer, if any, and the HTTP status.
+func listRequest(t *testing.T, form url.Values) (*api.EventListMsg, int) {
+	msg, status := apiRequest(t, "list", form)
+	if msg == nil {
+		return nil, status
+	}
+	listMsg := msg.(api.EventListMsg)
+	return &listMsg, status
+}
+
+func TestRetryFailedTargetsOnly(t *testing.T) {
+	msg, status := retryRequest(t, url.Values{"requestor": {"test"}, "jobID": {"12"}, "failedTargetsOnly": {"true"}})
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, msg)
+	require.Equal(t, types.JobID(12), msg.JobID)
+	require.True(t, msg.FailedTargetsOnly)
+}
+
+func TestRetryFailedTargetsOnlyDefault(t *testing.T) {
+	msg, status := retryRequest(t, url.Values{"requestor": {"test"}, "jobID": {"12"}})
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, msg)
+	require.False(t, msg.FailedTargetsOnly)
+}
+
+func TestRetryFailedTargetsOnlyInvalid(t *testing.T) {
+	msg, status := retryRequest(t, url.Values{"requestor": {"test"}, "jobID": {"12"}, "failedTargetsOnly": {"maybe"}})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Nil(t, msg)
+}
+
+func TestListQuery(t *testing.T) {
+	msg, status := listRequest(t, url.Values{
+		"requestor":       {"test"},
+		"states":          {"JobStateCompleted, JobStateFailed"},
+		"tags":            {"a,b,"},
+		"allTags":         {"true"},
+		"jobRequestor":    {"someone"},
+		"name":            {"nightly-*"},
+		"requestedAfter":  {"2020-01-02T03:04:05Z"},
+		"requestedBefore": {"2020-02-02T03:04:05Z"},
+		"offset":          {"10"},
+		"limit":           {"5"},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, msg)
+	require.Equal(t, []event.Name{"JobStateCompleted", "JobStateFailed"}, msg.Query.States)
+	require.Equal(t, []string{"a", "b"}, msg.Query.Tags)
+	require.True(t, msg.Query.MatchAllTags)
+	require.Equal(t, "someone", msg.Query.Requestor)
+	require.Equal(t, "nightly-*", msg.Query.NamePattern)
+	require.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), msg.Query.RequestedAfter.UTC())
+	require.Equal(t, time.Date(2020, 2, 2, 3, 4, 5, 0, time.UTC), msg.Query.RequestedBefore.UTC())
+	require.Equal(t, uint(10), msg.Query.Offset)
+	require.Equal(t, uint(5), msg.Query.Limit)
+}
+
+func TestListQueryDefault(t *testing.T) {
+	msg, status := listRequest(t, url.Values{"requestor": {"test"}})
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, msg)
+	require.Equal(t, job.ListQuery{}, msg.Query)
+}
+
+func TestListQueryInvalid(t *testing.T) {
+	for _, form := range []url.Values{
+		{"requestor": {"test"}, "allTags": {"maybe"}},
+		{"requestor": {"test"}, "requestedAfter": {"yesterday"}},
+		{"requestor": {"test"}, "limit": {"-1"}},
+	} {
+		msg, status := listRequest(t, form)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Nil(t, msg)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	broker := storage.NewEventBroker(10, 10)
+	broker.PublishFrameworkEvent(frameworkevent.Event{JobID: 12, EventName: "JobStateStarted"})
+	broker.PublishTestEvent(testevent.Event{Header: &testevent.Header{JobID: 12, TestName: "test"}, Data: &testevent.Data{EventName: "TargetIn"}})
+	broker.PublishFrameworkEvent(frameworkevent.Event{JobID: 12, EventName: "JobStateCompleted"})
+	broker.Close(12)
+
+	a := api.New()
+	go func() {
+		ev := <-a.Events
+		msg := ev.Msg.(api.EventWatchMsg)
+		sub, err := broker.Subscribe(msg.JobID, msg.AfterID)
+		ev.RespCh <- &api.EventResponse{
+			JobID:        msg.JobID,
+			Requestor:    msg.Requestor(),
+			Subscription: sub,
+			Err:          err,
+		}
+	}()
+
+	req := httptest.NewRequest("GET", "/watch?requestor=test&jobID=12", nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(storage.StreamEventID(0, 1)))
+	w := httptest.NewRecorder()
+	h := &watchHandler{api: a}
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	require.Equal(t, 3, len(events))
+	require.True(t, strings.HasPrefix(events[0], fmt.Sprintf("id: %d\nevent: test\ndata: {", storage.StreamEventID(1, 1))))
+	require.Contains(t, events[0], `"EventName":"TargetIn"`)
+	require.True(t, strings.HasPrefix(events[1], fmt.Sprintf("id: %d\nevent: framework\ndata: {", storage.StreamEventID(1, 2))))
+	require.Contains(t, events[1], `"EventName":"JobStateCompleted"`)
+	require.Equal(t, "event: end\ndata: {}", events[2])
+}
+
+func TestWatchInvalidEventID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/watch?requestor=test&jobID=12&after=last", nil)
+	w := httptest.NewRecorder()
+	h := &watchHandler{api: api.New()}
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServeUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httplistener")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "contest.sock")
+
+	l := New(WithAddr("unix:"+socket), WithBasePath("/contest/"), WithMaxBodySize(64))
+	cancel := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- l.Serve(cancel, api.New())
+	}()
+	defer func() {
+		close(cancel)
+		require.NoError(t, <-errCh)
+	}()
+
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://contest/contest/v1/version"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// requests outside of the base path
+	resp, err = client.Get("http://contest/v1/version")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// requests larger than the maximum body size
+	resp, err = client.Post("http://contest/contest/v1/jobs", "application/json", strings.NewReader(`{"JobDescriptor": "`+strings.Repeat("x", 64)+`"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
@@ -0,0 +1,373 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+//go:generate go run gen_openapi.go
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// schema is a JSON schema object, as used by OpenAPI. Maps are marshalled with
+// sorted keys, so the generated document is deterministic.
+type schema map[string]interface{}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaBuilder builds JSON schemas from Go types, following the rules of
+// encoding/json. Named struct types are added to the schema components and
+// referenced by name.
+type schemaBuilder struct {
+	components map[string]schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]schema)}
+}
+
+// schemaOf returns the schema of the JSON encoding of values of type t.
+func (b *schemaBuilder) schemaOf(t reflect.Type) schema {
+	switch {
+	case t == timeType:
+		return schema{"type": "string", "format": "date-time"}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// custom encoding, e.g. json.RawMessage, anything goes
+		return schema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schemaOf(t.Elem())
+	case reflect.Interface:
+		return schema{}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return schema{"type": "integer", "format": fmt.Sprintf("int%d", t.Bits())}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer", "format": fmt.Sprintf("int%d", t.Bits()), "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return schema{"type": "string", "format": "byte"}
+		}
+		return schema{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := b.components[name]; !ok {
+			// register the name first, for recursive types
+			b.components[name] = schema{}
+			b.components[name] = b.structSchema(t)
+		}
+		return schema{"$ref": "#/components/schemas/" + name}
+	default:
+		panic(fmt.Sprintf("type %s cannot be represented in JSON", t))
+	}
+}
+
+// structSchema returns the schema of a struct type, inlining the fields of
+// embedded structs.
+func (b *schemaBuilder) structSchema(t reflect.Type) schema {
+	properties := schema{}
+	b.addFields(t, properties)
+	return schema{"type": "object", "properties": properties}
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties schema) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.addFields(fieldType, properties)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaOf(field.Type)
+	}
+}
+
+// errorResponses describes the error status codes returned by the REST API.
+var errorResponses = map[int]string{
+	http.StatusBadRequest:          "Malformed request",
+	http.StatusUnauthorized:        "Missing or invalid credentials, when authentication is enabled",
+	http.StatusForbidden:           "The requestor is not allowed to perform the request",
+	http.StatusNotFound:            "Unknown job, target, schedule or resource",
+	http.StatusConflict:            "The request conflicts with the state of the job or schedule",
+	http.StatusUnprocessableEntity: "Invalid request, e.g. an invalid job descriptor",
+	http.StatusInternalServerError: "Internal error",
+	http.StatusServiceUnavailable:  "The server cannot serve the request, e.g. because it is shutting down",
+}
+
+// operation describes an operation of the REST API.
+type operation struct {
+	summary  string
+	params   []schema
+	body     interface{}
+	status   int
+	response interface{}
+	// contentType is the content type of the response, if not JSON
+	contentType string
+	errors      []int
+}
+
+func (b *schemaBuilder) operation(op operation) schema {
+	responses := schema{}
+	content := schema{}
+	if op.contentType != "" {
+		content[op.contentType] = schema{"schema": schema{"type": "string"}}
+	} else {
+		content["application/json"] = schema{"schema": b.schemaOf(reflect.TypeOf(op.response))}
+	}
+	responses[strconv.Itoa(op.status)] = schema{
+		"description": http.StatusText(op.status),
+		"content":     content,
+	}
+	for _, status := range append(op.errors, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError, http.StatusServiceUnavailable) {
+		responses[strconv.Itoa(status)] = schema{
+			"description": errorResponses[status],
+			"content": schema{
+				"application/json": schema{"schema": b.schemaOf(reflect.TypeOf(HTTPAPIError{}))},
+			},
+		}
+	}
+	ret := schema{"summary": op.summary, "responses": responses}
+	if len(op.params) > 0 {
+		ret["parameters"] = op.params
+	}
+	if op.body != nil {
+		ret["requestBody"] = schema{
+			"required": true,
+			"content": schema{
+				"application/json": schema{"schema": b.schemaOf(reflect.TypeOf(op.body))},
+			},
+		}
+	}
+	return ret
+}
+
+func queryParam(name, description string, s schema) schema {
+	return schema{"name": name, "in": "query", "description": description, "schema": s}
+}
+
+// OpenAPIDocument returns the OpenAPI document describing the REST API. The
+// schemas are generated from the Go types exchanged with the API.
+func OpenAPIDocument() ([]byte, error) {
+	b := newSchemaBuilder()
+	str := schema{"type": "string"}
+	requestor := queryParam("requestor", "Name of the requestor", str)
+	jobID := schema{"name": "jobID", "in": "path", "required": true, "schema": b.schemaOf(reflect.TypeOf(types.JobID(0)))}
+	targetID := schema{"name": "targetID", "in": "path", "required": true, "schema": str}
+	scheduleID := schema{"name": "scheduleID", "in": "path", "required": true, "schema": b.schemaOf(reflect.TypeOf(types.ScheduleID(0)))}
+	paths := schema{
+		RESTPrefix + "/version": schema{
+			"get": b.operation(operation{
+				summary:  "Get the version of the API",
+				status:   http.StatusOK,
+				response: api.ResponseDataVersion{},
+			}),
+		},
+		RESTPrefix + "/openapi.json": schema{
+			"get": b.operation(operation{
+				summary:  "Get this document",
+				status:   http.StatusOK,
+				response: map[string]interface{}{},
+			}),
+		},
+		RESTPrefix + "/jobs": schema{
+			"get": b.operation(operation{
+				summary: "List jobs, from the most recent to the oldest",
+				params: []schema{
+					requestor,
+					queryParam("states", "Comma-separated list of job states", str),
+					queryParam("tags", "Comma-separated list of job tags", str),
+					queryParam("allTags", "Match jobs with all the tags, rather than any", schema{"type": "boolean"}),
+					queryParam("jobRequestor", "Requestor of the jobs", str),
+					queryParam("name", "Pattern of the job names, where * matches any sequence of characters and ? a single one", str),
+					queryParam("requestedAfter", "Minimum request time of the jobs", schema{"type": "string", "format": "date-time"}),
+					queryParam("requestedBefore", "Maximum request time of the jobs", schema{"type": "string", "format": "date-time"}),
+					queryParam("offset", "Number of jobs to skip", schema{"type": "integer", "minimum": 0}),
+					queryParam("limit", "Maximum number of jobs to list", schema{"type": "integer", "minimum": 0}),
+				},
+				status:   http.StatusOK,
+				response: api.ResponseDataList{},
+				errors:   []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+			}),
+			"post": b.operation(operation{
+				summary:  "Start a job",
+				body:     StartJobRequest{},
+				status:   http.StatusCreated,
+				response: api.ResponseDataStart{},
+				errors:   []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+			}),
+		},
+		RESTPrefix + "/jobs/{jobID}": schema{
+			"get": b.operation(operation{
+				summary:  "Get the status of a job",
+				params:   []schema{jobID, requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataStatus{},
+				errors:   []int{http.StatusNotFound},
+			}),
+			"delete": b.operation(operation{
+				summary:  "Stop a job",
+				params:   []schema{jobID, requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataStop{},
+				errors:   []int{http.StatusNotFound, http.StatusConflict},
+			}),
+		},
+		RESTPrefix + "/jobs/{jobID}/report": schema{
+			"get": b.operation(operation{
+				summary:  "Get the report of a job",
+				params:   []schema{jobID, requestor},
+				status:   http.StatusOK,
+				response: job.JobReport{},
+				errors:   []int{http.StatusNotFound},
+			}),
+		},
+		RESTPrefix + "/jobs/{jobID}/retry": schema{
+			"post": b.operation(operation{
+				summary:  "Retry a job",
+				params:   []schema{jobID},
+				body:     RetryJobRequest{},
+				status:   http.StatusCreated,
+				response: api.ResponseDataRetry{},
+				errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+			}),
+		},
+		RESTPrefix + "/jobs/{jobID}/events": schema{
+			"get": b.operation(operation{
+				summary: "Stream the events of a job as Server-Sent Events",
+				params: []schema{
+					jobID,
+					requestor,
+					queryParam("after", "Stream the events following the one with the given ID. The Last-Event-ID header takes precedence", schema{"type": "integer", "minimum": 0}),
+				},
+				status:      http.StatusOK,
+				contentType: "text/event-stream",
+				errors:      []int{http.StatusNotFound, http.StatusUnprocessableEntity},
+			}),
+		},
+		RESTPrefix + "/jobs/{jobID}/targets/{targetID}": schema{
+			"delete": b.operation(operation{
+				summary:  "Detach a target from the test a job is running",
+				params:   []schema{jobID, targetID, requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataDetachTarget{},
+				errors:   []int{http.StatusNotFound, http.StatusConflict},
+			}),
+		},
+		RESTPrefix + "/quarantine": schema{
+			"get": b.operation(operation{
+				summary:  "List the quarantined targets",
+				params:   []schema{requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataListQuarantine{},
+			}),
+		},
+		RESTPrefix + "/quarantine/{targetID}": schema{
+			"delete": b.operation(operation{
+				summary:  "Release a target from quarantine",
+				params:   []schema{targetID, requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataReleaseQuarantine{},
+				errors:   []int{http.StatusNotFound},
+			}),
+		},
+		RESTPrefix + "/schedules": schema{
+			"get": b.operation(operation{
+				summary:  "List the schedules of recurring jobs",
+				params:   []schema{requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataListSchedules{},
+			}),
+			"post": b.operation(operation{
+				summary:  "Create a schedule, which starts a new job each time it fires",
+				body:     CreateScheduleRequest{},
+				status:   http.StatusCreated,
+				response: api.ResponseDataCreateSchedule{},
+				errors:   []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+			}),
+		},
+		RESTPrefix + "/schedules/{scheduleID}": schema{
+			"delete": b.operation(operation{
+				summary:  "Delete a schedule",
+				params:   []schema{scheduleID, requestor},
+				status:   http.StatusOK,
+				response: api.ResponseDataDeleteSchedule{},
+				errors:   []int{http.StatusNotFound},
+			}),
+		},
+		RESTPrefix + "/schedules/{scheduleID}/pause": schema{
+			"post": b.operation(operation{
+				summary:  "Pause a schedule",
+				params:   []schema{scheduleID},
+				body:     ScheduleRequest{},
+				status:   http.StatusOK,
+				response: api.ResponseDataPauseSchedule{},
+				errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			}),
+		},
+		RESTPrefix + "/schedules/{scheduleID}/resume": schema{
+			"post": b.operation(operation{
+				summary:  "Resume a paused schedule",
+				params:   []schema{scheduleID},
+				body:     ScheduleRequest{},
+				status:   http.StatusOK,
+				response: api.ResponseDataResumeSchedule{},
+				errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+			}),
+		},
+	}
+	doc := schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":   "ConTest REST API",
+			"version": strconv.Itoa(int(api.CurrentAPIVersion)),
+		},
+		"paths":      paths,
+		"components": schema{"schemas": b.components},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,438 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/insomniacslk/xjson"
+)
+
+// RESTPrefix is the path prefix of the versioned REST API.
+const RESTPrefix = "/v1"
+
+// StartJobRequest is the body of a request to start a job.
+type StartJobRequest struct {
+	Requestor string
+	// JobDescriptor is the JSON job descriptor, as an object.
+	JobDescriptor json.RawMessage
+}
+
+// RetryJobRequest is the body of a request to retry a job.
+type RetryJobRequest struct {
+	Requestor string
+	// FailedTargetsOnly restricts the retried job to the targets that failed
+	// in the original job.
+	FailedTargetsOnly bool
+}
+
+// CreateScheduleRequest is the body of a request to create a schedule.
+// Exactly one of Cron and Interval must be set.
+type CreateScheduleRequest struct {
+	Requestor string
+	// JobDescriptor is the JSON job descriptor, as an object.
+	JobDescriptor json.RawMessage
+	// Cron is a cron expression, e.g. "0 2 * * *" for every night at 2am.
+	Cron string
+	// Interval is the interval between firings, e.g. "6h".
+	Interval      xjson.Duration
+	OverlapPolicy job.OverlapPolicy
+}
+
+// ScheduleRequest is the body of a request to pause or resume a schedule.
+type ScheduleRequest struct {
+	Requestor string
+}
+
+// statusCode maps an API error to an HTTP status code.
+func statusCode(err error) int {
+	switch api.ErrorKindOf(err) {
+	case api.ErrorKindNotFound:
+		return http.StatusNotFound
+	case api.ErrorKindConflict:
+		return http.StatusConflict
+	case api.ErrorKindInvalid:
+		return http.StatusUnprocessableEntity
+	case api.ErrorKindUnavailable:
+		return http.StatusServiceUnavailable
+	case api.ErrorKindUnauthenticated:
+		return http.StatusUnauthorized
+	case api.ErrorKindPermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// replyJSON replies with the JSON encoding of v
+func replyJSON(w http.ResponseWriter, status int, v interface{}) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		panic(fmt.Sprintf("cannot marshal response: %v", err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	reply(w, status, buffer.String())
+}
+
+// restHandler implements the versioned REST API. Requests and responses
+// carry JSON objects, and errors are reported via HTTP status codes, with an
+// HTTPAPIError as body. The requestor is passed in the body of POST requests,
+// and via the "requestor" query parameter otherwise. When requests are
+// authenticated, the requestor can be omitted.
+type restHandler struct {
+	api *api.API
+}
+
+func (h *restHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, RESTPrefix), "/")
+	segments := strings.Split(path, "/")
+	requestor, err := requestorOf(r, r.URL.Query().Get("requestor"))
+	if err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+
+	// methods maps the allowed methods of the requested resource to their
+	// handlers
+	var methods map[string]func()
+	switch {
+	case path == "version":
+		methods = map[string]func(){
+			"GET": func() { replyJSON(w, http.StatusOK, h.api.Version().Data) },
+		}
+	case path == "openapi.json":
+		methods = map[string]func(){
+			"GET": func() { h.openAPIDocument(w) },
+		}
+	case path == "jobs":
+		methods = map[string]func(){
+			"GET":  func() { h.listJobs(w, r, requestor) },
+			"POST": func() { h.startJob(w, r) },
+		}
+	case path == "quarantine":
+		methods = map[string]func(){
+			"GET": func() { h.listQuarantine(w, requestor) },
+		}
+	case len(segments) == 2 && segments[0] == "quarantine":
+		targetID := segments[1]
+		methods = map[string]func(){
+			"DELETE": func() { h.releaseQuarantine(w, targetID, requestor) },
+		}
+	case path == "schedules":
+		methods = map[string]func(){
+			"GET":  func() { h.listSchedules(w, requestor) },
+			"POST": func() { h.createSchedule(w, r) },
+		}
+	case len(segments) >= 2 && len(segments) <= 3 && segments[0] == "schedules":
+		scheduleID, err := strToScheduleID(segments[1])
+		if err != nil {
+			replyError(w, http.StatusNotFound, fmt.Errorf("invalid schedule ID '%s': %v", segments[1], err))
+			return
+		}
+		resource := ""
+		if len(segments) == 3 {
+			resource = segments[2]
+		}
+		switch resource {
+		case "":
+			methods = map[string]func(){
+				"DELETE": func() { h.deleteSchedule(w, scheduleID, requestor) },
+			}
+		case "pause":
+			methods = map[string]func(){
+				"POST": func() { h.pauseSchedule(w, r, scheduleID, true) },
+			}
+		case "resume":
+			methods = map[string]func(){
+				"POST": func() { h.pauseSchedule(w, r, scheduleID, false) },
+			}
+		}
+	case len(segments) >= 2 && len(segments) <= 4 && segments[0] == "jobs":
+		jobID, err := strToJobID(segments[1])
+		if err != nil {
+			replyError(w, http.StatusNotFound, fmt.Errorf("invalid job ID '%s': %v", segments[1], err))
+			return
+		}
+		resource := ""
+		if len(segments) >= 3 {
+			resource = segments[2]
+		}
+		if len(segments) == 4 {
+			if resource != "targets" {
+				break
+			}
+			targetID := segments[3]
+			methods = map[string]func(){
+				"DELETE": func() { h.detachTarget(w, jobID, targetID, requestor) },
+			}
+			break
+		}
+		switch resource {
+		case "":
+			methods = map[string]func(){
+				"GET":    func() { h.jobStatus(w, jobID, requestor, false) },
+				"DELETE": func() { h.stopJob(w, jobID, requestor) },
+			}
+		case "report":
+			methods = map[string]func(){
+				"GET": func() { h.jobStatus(w, jobID, requestor, true) },
+			}
+		case "retry":
+			methods = map[string]func(){
+				"POST": func() { h.retryJob(w, r, jobID) },
+			}
+		case "events":
+			methods = map[string]func(){
+				"GET": func() {
+					watchJob(w, r, h.api, jobID, requestor, func(err error) { replyError(w, statusCode(err), err) })
+				},
+			}
+		}
+	}
+	if methods == nil {
+		replyError(w, http.StatusNotFound, fmt.Errorf("unknown resource: %s", r.URL.Path))
+		return
+	}
+	handler, ok := methods[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(methods))
+		for method := range methods {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		replyError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+	handler()
+}
+
+// errBodyTooLarge is the message of the error returned when reading bodies
+// larger than the maximum size, see http.MaxBytesReader.
+const errBodyTooLarge = "http: request body too large"
+
+// decodeBody decodes the JSON body of a request into v, replying with an error
+// if the body is malformed.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if err.Error() == errBodyTooLarge {
+			replyError(w, http.StatusRequestEntityTooLarge, err)
+			return false
+		}
+		replyError(w, http.StatusBadRequest, fmt.Errorf("malformed request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// apiError returns the error of an API call, either returned by the API method
+// or carried by the response.
+func apiError(resp api.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return resp.Err
+}
+
+func (h *restHandler) startJob(w http.ResponseWriter, r *http.Request) {
+	var req StartJobRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.JobDescriptor) == 0 {
+		replyError(w, http.StatusUnprocessableEntity, errors.New("missing job descriptor"))
+		return
+	}
+	requestor, err := requestorOf(r, req.Requestor)
+	if err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	resp, err := h.api.Start(requestor, string(req.JobDescriptor))
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusCreated, resp.Data)
+}
+
+func (h *restHandler) listJobs(w http.ResponseWriter, r *http.Request, requestor api.EventRequestor) {
+	query, err := parseListQuery(r)
+	if err != nil {
+		replyError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.api.List(requestor, *query)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+// jobStatus replies with the status of a job, or with its report only.
+func (h *restHandler) jobStatus(w http.ResponseWriter, jobID types.JobID, requestor api.EventRequestor, reportOnly bool) {
+	resp, err := h.api.Status(requestor, jobID)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	data := resp.Data.(api.ResponseDataStatus)
+	if reportOnly {
+		if data.Status == nil || data.Status.JobReport == nil {
+			replyError(w, http.StatusNotFound, fmt.Errorf("no report available for job %d", jobID))
+			return
+		}
+		replyJSON(w, http.StatusOK, data.Status.JobReport)
+		return
+	}
+	replyJSON(w, http.StatusOK, data)
+}
+
+func (h *restHandler) stopJob(w http.ResponseWriter, jobID types.JobID, requestor api.EventRequestor) {
+	resp, err := h.api.Stop(requestor, jobID)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) retryJob(w http.ResponseWriter, r *http.Request, jobID types.JobID) {
+	var req RetryJobRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	requestor, err := requestorOf(r, req.Requestor)
+	if err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	resp, err := h.api.Retry(requestor, jobID, req.FailedTargetsOnly)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusCreated, resp.Data)
+}
+
+func (h *restHandler) listQuarantine(w http.ResponseWriter, requestor api.EventRequestor) {
+	resp, err := h.api.ListQuarantine(requestor)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) releaseQuarantine(w http.ResponseWriter, targetID string, requestor api.EventRequestor) {
+	resp, err := h.api.ReleaseQuarantine(requestor, targetID)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) detachTarget(w http.ResponseWriter, jobID types.JobID, targetID string, requestor api.EventRequestor) {
+	resp, err := h.api.DetachTarget(requestor, jobID, targetID)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) createSchedule(w http.ResponseWriter, r *http.Request) {
+	var req CreateScheduleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.JobDescriptor) == 0 {
+		replyError(w, http.StatusUnprocessableEntity, errors.New("missing job descriptor"))
+		return
+	}
+	requestor, err := requestorOf(r, req.Requestor)
+	if err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	resp, err := h.api.CreateSchedule(requestor, job.ScheduleDescriptor{
+		JobDescriptor: string(req.JobDescriptor),
+		Cron:          req.Cron,
+		Interval:      req.Interval,
+		OverlapPolicy: req.OverlapPolicy,
+	})
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusCreated, resp.Data)
+}
+
+func (h *restHandler) listSchedules(w http.ResponseWriter, requestor api.EventRequestor) {
+	resp, err := h.api.ListSchedules(requestor)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+// pauseSchedule pauses or resumes a schedule
+func (h *restHandler) pauseSchedule(w http.ResponseWriter, r *http.Request, scheduleID types.ScheduleID, pause bool) {
+	var req ScheduleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	requestor, err := requestorOf(r, req.Requestor)
+	if err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	var resp api.Response
+	if pause {
+		resp, err = h.api.PauseSchedule(requestor, scheduleID)
+	} else {
+		resp, err = h.api.ResumeSchedule(requestor, scheduleID)
+	}
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) deleteSchedule(w http.ResponseWriter, scheduleID types.ScheduleID, requestor api.EventRequestor) {
+	resp, err := h.api.DeleteSchedule(requestor, scheduleID)
+	if err := apiError(resp, err); err != nil {
+		replyError(w, statusCode(err), err)
+		return
+	}
+	replyJSON(w, http.StatusOK, resp.Data)
+}
+
+func (h *restHandler) openAPIDocument(w http.ResponseWriter) {
+	doc, err := OpenAPIDocument()
+	if err != nil {
+		replyError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	reply(w, http.StatusOK, string(doc))
+}
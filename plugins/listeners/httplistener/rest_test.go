@@ -0,0 +1,157 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httplistener
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/facebookincubator/contest/pkg/api"
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// restRequest sends a request to the REST handler, whose API consumer replies
+// with the given response. It returns the message received by the API
+// consumer, if any, and the recorded HTTP response.
+func restRequest(t *testing.T, method, target, body string, resp api.EventResponse) (api.EventMsg, *httptest.ResponseRecorder) {
+	a := api.New()
+	msgCh := make(chan api.EventMsg, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case ev := <-a.Events:
+			msgCh <- ev.Msg
+			resp.Requestor = ev.Msg.Requestor()
+			ev.RespCh <- &resp
+		case <-done:
+		}
+	}()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h := &restHandler{api: a}
+	h.ServeHTTP(w, req)
+
+	select {
+	case msg := <-msgCh:
+		return msg, w
+	default:
+		return nil, w
+	}
+}
+
+func TestRESTStartJob(t *testing.T) {
+	msg, w := restRequest(t, "POST", "/v1/jobs", `{"Requestor": "test", "JobDescriptor": {"JobName": "test"}}`, api.EventResponse{JobID: 12})
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.Equal(t, `{"JobName": "test"}`, msg.(api.EventStartMsg).JobDescriptor)
+	var data api.ResponseDataStart
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	require.Equal(t, types.JobID(12), data.JobID)
+}
+
+func TestRESTStartJobErrors(t *testing.T) {
+	// malformed body
+	msg, w := restRequest(t, "POST", "/v1/jobs", `{"Requestor": `, api.EventResponse{})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Nil(t, msg)
+	// missing descriptor
+	msg, w = restRequest(t, "POST", "/v1/jobs", `{"Requestor": "test"}`, api.EventResponse{})
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Nil(t, msg)
+	// invalid descriptor
+	_, w = restRequest(t, "POST", "/v1/jobs", `{"Requestor": "test", "JobDescriptor": {}}`,
+		api.EventResponse{Err: api.NewError(api.ErrorKindInvalid, errors.New("invalid descriptor"))})
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var apiErr HTTPAPIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+	require.Equal(t, "invalid descriptor", apiErr.Msg)
+	// shutting down
+	_, w = restRequest(t, "POST", "/v1/jobs", `{"Requestor": "test", "JobDescriptor": {}}`,
+		api.EventResponse{Err: api.NewError(api.ErrorKindUnavailable, errors.New("shutting down"))})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestRESTJobStatus(t *testing.T) {
+	status := &job.Status{Name: "test", JobReport: &job.JobReport{JobID: 12}}
+	msg, w := restRequest(t, "GET", "/v1/jobs/12?requestor=test", "", api.EventResponse{Status: status})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, api.EventRequestor("test"), msg.Requestor())
+	require.Equal(t, types.JobID(12), msg.(api.EventStatusMsg).JobID)
+	var data api.ResponseDataStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	require.Equal(t, "test", data.Status.Name)
+
+	_, w = restRequest(t, "GET", "/v1/jobs/12/report?requestor=test", "", api.EventResponse{Status: status})
+	require.Equal(t, http.StatusOK, w.Code)
+	var report job.JobReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Equal(t, types.JobID(12), report.JobID)
+
+	_, w = restRequest(t, "GET", "/v1/jobs/12?requestor=test", "",
+		api.EventResponse{Err: api.NewError(api.ErrorKindNotFound, errors.New("unknown job ID: 12"))})
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRESTStopJob(t *testing.T) {
+	msg, w := restRequest(t, "DELETE", "/v1/jobs/12?requestor=test", "", api.EventResponse{})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, types.JobID(12), msg.(api.EventStopMsg).JobID)
+
+	_, w = restRequest(t, "DELETE", "/v1/jobs/12?requestor=test", "",
+		api.EventResponse{Err: api.NewError(api.ErrorKindConflict, errors.New("job 12 already stopped"))})
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRESTRetryJob(t *testing.T) {
+	msg, w := restRequest(t, "POST", "/v1/jobs/12/retry", `{"Requestor": "test", "FailedTargetsOnly": true}`, api.EventResponse{JobID: 13})
+	require.Equal(t, http.StatusCreated, w.Code)
+	retryMsg := msg.(api.EventRetryMsg)
+	require.Equal(t, types.JobID(12), retryMsg.JobID)
+	require.True(t, retryMsg.FailedTargetsOnly)
+	var data api.ResponseDataRetry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	require.Equal(t, types.JobID(13), data.NewJobID)
+}
+
+func TestRESTListJobs(t *testing.T) {
+	msg, w := restRequest(t, "GET", "/v1/jobs?requestor=test&tags=a,b&limit=5", "", api.EventResponse{Jobs: []job.Summary{{JobID: 1}}})
+	require.Equal(t, http.StatusOK, w.Code)
+	query := msg.(api.EventListMsg).Query
+	require.Equal(t, []string{"a", "b"}, query.Tags)
+	require.Equal(t, uint(5), query.Limit)
+	var data api.ResponseDataList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	require.Len(t, data.Jobs, 1)
+}
+
+func TestRESTRouting(t *testing.T) {
+	for _, target := range []string{"/v1/unknown", "/v1/jobs/12/unknown", "/v1/jobs/abc", "/v1/jobs/12/retry/again"} {
+		msg, w := restRequest(t, "GET", target, "", api.EventResponse{})
+		require.Equal(t, http.StatusNotFound, w.Code, target)
+		require.Nil(t, msg)
+	}
+	msg, w := restRequest(t, "PUT", "/v1/jobs/12", "", api.EventResponse{})
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	require.ElementsMatch(t, []string{"GET", "DELETE"}, strings.Split(w.Header().Get("Allow"), ", "))
+	require.Nil(t, msg)
+}
+
+func TestOpenAPIDocumentUpToDate(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	committed, err := ioutil.ReadFile("openapi.json")
+	require.NoError(t, err)
+	require.Equal(t, string(committed), string(doc)+"\n", "openapi.json is out of date, run go generate")
+}
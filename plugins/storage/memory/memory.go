@@ -6,7 +6,11 @@
 package memory
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +19,7 @@ import (
 	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
@@ -22,12 +27,17 @@ import (
 // storage engine is very inefficient and should be used only for testing
 // purposes.
 type Memory struct {
-	lock            *sync.Mutex
-	testEvents      []testevent.Event
-	frameworkEvents []frameworkevent.Event
-	jobIDCounter    types.JobID
-	jobRequests     map[types.JobID]*job.Request
-	jobReports      map[types.JobID]*job.JobReport
+	lock              *sync.Mutex
+	testEvents        []testevent.Event
+	frameworkEvents   []frameworkevent.Event
+	jobIDCounter      types.JobID
+	jobRequests       map[types.JobID]*job.Request
+	jobReports        map[types.JobID]*job.JobReport
+	pauseStates       map[types.JobID][]byte
+	targets           map[string]*target.Target
+	quarantine        map[string]target.QuarantinedTarget
+	scheduleIDCounter types.ScheduleID
+	schedules         map[types.ScheduleID]job.Schedule
 }
 
 func emptyEventQuery(eventQuery *event.Query) bool {
@@ -46,7 +56,7 @@ func emptyFrameworkEventQuery(eventQuery *frameworkevent.Query) bool {
 // values. If so, the Query is considered "empty" and doesn't result in
 // any lookup in the database
 func emptyTestEventQuery(eventQuery *testevent.Query) bool {
-	return emptyEventQuery(&eventQuery.Query) && eventQuery.TestName == "" && eventQuery.TestStepLabel == ""
+	return emptyEventQuery(&eventQuery.Query) && eventQuery.TestName == "" && eventQuery.TestStepLabel == "" && eventQuery.TargetID == ""
 }
 
 // Reset resets the content of the in-memory storage.
@@ -57,7 +67,12 @@ func (m *Memory) Reset() error {
 	m.frameworkEvents = []frameworkevent.Event{}
 	m.jobRequests = make(map[types.JobID]*job.Request)
 	m.jobReports = make(map[types.JobID]*job.JobReport)
+	m.pauseStates = make(map[types.JobID][]byte)
+	m.targets = make(map[string]*target.Target)
+	m.quarantine = make(map[string]target.QuarantinedTarget)
+	m.schedules = make(map[types.ScheduleID]job.Schedule)
 	m.jobIDCounter = 1
+	m.scheduleIDCounter = 1
 	return nil
 }
 
@@ -107,6 +122,13 @@ func eventTestMatch(queryTestName, testName string) bool {
 	return true
 }
 
+func eventTargetMatch(queryTargetID string, t *target.Target) bool {
+	if queryTargetID == "" {
+		return true
+	}
+	return t != nil && t.ID == queryTargetID
+}
+
 func eventTestStepMatch(queryTestStepLabel, testStepLabel string) bool {
 	if queryTestStepLabel != "" && queryTestStepLabel != testStepLabel {
 		return false
@@ -130,7 +152,8 @@ func (m *Memory) GetTestEvents(eventQuery *testevent.Query) ([]testevent.Event,
 			eventNameMatch(eventQuery.EventNames, event.Data.EventName) &&
 			eventTimeMatch(eventQuery.EmittedStartTime, eventQuery.EmittedEndTime, event.EmitTime) &&
 			eventTestMatch(eventQuery.TestName, event.Header.TestName) &&
-			eventTestStepMatch(eventQuery.TestStepLabel, event.Header.TestStepLabel) {
+			eventTestStepMatch(eventQuery.TestStepLabel, event.Header.TestStepLabel) &&
+			eventTargetMatch(eventQuery.TargetID, event.Data.Target) {
 			matchingTestEvents = append(matchingTestEvents, event)
 		}
 	}
@@ -144,43 +167,219 @@ func (m *Memory) StoreJobRequest(request *job.Request) (types.JobID, error) {
 
 	request.JobID = m.jobIDCounter
 	m.jobIDCounter++
+	request.Tags = normalizeTags(request.Tags)
 	m.jobRequests[request.JobID] = request
 	return request.JobID, nil
 }
 
+// normalizeTags returns a sorted copy of the tags, without duplicates
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	normalized := sorted[:1]
+	for _, tag := range sorted[1:] {
+		if tag != normalized[len(normalized)-1] {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
 // GetJobRequest retrieves a job request from the in memory list
 func (m *Memory) GetJobRequest(jobID types.JobID) (*job.Request, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	r, ok := m.jobRequests[jobID]
 	if !ok {
-		return nil, fmt.Errorf("could not find job request with id %v", jobID)
+		return nil, fmt.Errorf("could not find job request with id %v: %w", jobID, storage.ErrJobNotFound)
 	}
 	return r, nil
 }
 
-// StoreJobReport stores a report associated to a job. Returns an error if there is
-// already a report associated to the job
+// namePatternRegexp converts a job name pattern, where `*` matches any
+// sequence of characters and `?` matches a single character, to a regexp.
+func namePatternRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func tagsMatch(queryTags []string, matchAll bool, tags []string) bool {
+	if len(queryTags) == 0 {
+		return true
+	}
+	found := 0
+	for _, queryTag := range queryTags {
+		for _, tag := range tags {
+			if tag == queryTag {
+				found++
+				break
+			}
+		}
+	}
+	if matchAll {
+		return found == len(queryTags)
+	}
+	return found > 0
+}
+
+// jobState returns the name of the latest framework event of a job among the
+// given state events. The caller must hold the lock.
+func (m *Memory) jobState(jobID types.JobID, stateEvents []event.Name) event.Name {
+	var state event.Name
+	for _, ev := range m.frameworkEvents {
+		if ev.JobID == jobID && eventNameMatch(stateEvents, ev.EventName) {
+			state = ev.EventName
+		}
+	}
+	return state
+}
+
+// ListJobs returns the jobs matching the query, from the most recent to the
+// oldest
+func (m *Memory) ListJobs(query *job.ListQuery, stateEvents []event.Name) ([]job.Summary, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var nameRegexp *regexp.Regexp
+	if query.NamePattern != "" {
+		var err error
+		if nameRegexp, err = namePatternRegexp(query.NamePattern); err != nil {
+			return nil, fmt.Errorf("invalid name pattern '%s': %v", query.NamePattern, err)
+		}
+	}
+	jobIDs := make([]types.JobID, 0, len(m.jobRequests))
+	for jobID := range m.jobRequests {
+		jobIDs = append(jobIDs, jobID)
+	}
+	sort.Slice(jobIDs, func(i, j int) bool { return jobIDs[i] > jobIDs[j] })
+
+	jobs := []job.Summary{}
+	var skipped uint
+	for _, jobID := range jobIDs {
+		request := m.jobRequests[jobID]
+		if query.Requestor != "" && request.Requestor != query.Requestor {
+			continue
+		}
+		if nameRegexp != nil && !nameRegexp.MatchString(request.JobName) {
+			continue
+		}
+		if !eventTimeMatch(query.RequestedAfter, query.RequestedBefore, request.RequestTime) {
+			continue
+		}
+		if !tagsMatch(query.Tags, query.MatchAllTags, request.Tags) {
+			continue
+		}
+		state := m.jobState(jobID, stateEvents)
+		if len(query.States) > 0 && !eventNameMatch(query.States, state) {
+			continue
+		}
+		if skipped < query.Offset {
+			skipped++
+			continue
+		}
+		jobs = append(jobs, job.Summary{
+			JobID:       jobID,
+			JobName:     request.JobName,
+			Requestor:   request.Requestor,
+			RequestTime: request.RequestTime,
+			Tags:        request.Tags,
+			State:       state,
+		})
+		if query.Limit > 0 && uint(len(jobs)) == query.Limit {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+// GetJobRequestsByTag retrieves all the job requests with the given tag, in
+// the order they were stored
+func (m *Memory) GetJobRequestsByTag(tag string) ([]*job.Request, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	requests := []*job.Request{}
+	for _, r := range m.jobRequests {
+		if tagsMatch([]string{tag}, false, r.Tags) {
+			requests = append(requests, r)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].JobID < requests[j].JobID })
+	return requests, nil
+}
+
+// StoreJobReport stores a report associated to a job, in addition to the
+// run reports already stored for the job
 func (m *Memory) StoreJobReport(report *job.JobReport) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	_, ok := m.jobReports[report.JobID]
-	if ok {
-		return fmt.Errorf("job report already present for job id %v", report.JobID)
+	for runID, runReports := range report.RunReports {
+		m.storeRunReports(report.JobID, uint(runID)+1, runReports)
 	}
-	m.jobReports[report.JobID] = report
+	stored := m.jobReport(report.JobID)
+	stored.FinalReports = append(stored.FinalReports, report.FinalReports...)
 	return nil
 }
 
+// StoreRunReports stores the reports of a run of a job. Run numbers start at
+// 1.
+func (m *Memory) StoreRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) error {
+	if runNumber == 0 {
+		return errors.New("invalid run number, cannot be zero")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.storeRunReports(jobID, runNumber, reports)
+	return nil
+}
+
+func (m *Memory) storeRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) {
+	stored := m.jobReport(jobID)
+	for uint(len(stored.RunReports)) < runNumber {
+		stored.RunReports = append(stored.RunReports, nil)
+	}
+	stored.RunReports[runNumber-1] = append(stored.RunReports[runNumber-1], reports...)
+}
+
+// jobReport returns the stored report of a job, creating it if needed. The
+// caller must hold the lock.
+func (m *Memory) jobReport(jobID types.JobID) *job.JobReport {
+	if _, ok := m.jobReports[jobID]; !ok {
+		m.jobReports[jobID] = &job.JobReport{JobID: jobID}
+	}
+	return m.jobReports[jobID]
+}
+
 // GetJobReport returns the report associated to a given job
 func (m *Memory) GetJobReport(jobID types.JobID) (*job.JobReport, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	if _, ok := m.jobReports[jobID]; !ok {
+	stored, ok := m.jobReports[jobID]
+	if !ok {
 		// return a job report with no results
 		return &job.JobReport{JobID: jobID}, nil
 	}
-	return m.jobReports[jobID], nil
+	// reports keep being added while the job runs, return a copy
+	report := job.JobReport{JobID: jobID}
+	for _, runReports := range stored.RunReports {
+		report.RunReports = append(report.RunReports, append([]*job.Report(nil), runReports...))
+	}
+	report.FinalReports = append(report.FinalReports, stored.FinalReports...)
+	return &report, nil
 }
 
 // StoreFrameworkEvent stores a framework event into the database
@@ -211,11 +410,158 @@ func (m *Memory) GetFrameworkEvent(eventQuery *frameworkevent.Query) ([]framewor
 	return matchingFrameworkEvents, nil
 }
 
+// StoreJobPauseState stores the pause state of a job, replacing any previous one
+func (m *Memory) StoreJobPauseState(jobID types.JobID, state []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pauseStates[jobID] = state
+	return nil
+}
+
+// GetJobPauseStates returns the pause state of all the paused jobs
+func (m *Memory) GetJobPauseStates() (map[types.JobID][]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	states := make(map[types.JobID][]byte, len(m.pauseStates))
+	for jobID, state := range m.pauseStates {
+		states[jobID] = state
+	}
+	return states, nil
+}
+
+// DeleteJobPauseState deletes the pause state of a job
+func (m *Memory) DeleteJobPauseState(jobID types.JobID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.pauseStates, jobID)
+	return nil
+}
+
+// copyTarget returns a copy of the target, so that the stored targets cannot
+// be modified by the caller
+func copyTarget(t *target.Target) *target.Target {
+	c := *t
+	if t.Attributes != nil {
+		c.Attributes = make(map[string]string, len(t.Attributes))
+		for name, value := range t.Attributes {
+			c.Attributes[name] = value
+		}
+	}
+	return &c
+}
+
+// StoreTargets stores targets in the inventory, replacing the ones with the
+// same ID
+func (m *Memory) StoreTargets(targets []*target.Target) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, t := range targets {
+		m.targets[t.ID] = copyTarget(t)
+	}
+	return nil
+}
+
+// GetTargets returns all the targets of the inventory, sorted by ID
+func (m *Memory) GetTargets() ([]*target.Target, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	targets := make([]*target.Target, 0, len(m.targets))
+	for _, t := range m.targets {
+		targets = append(targets, copyTarget(t))
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ID < targets[j].ID
+	})
+	return targets, nil
+}
+
+// DeleteTargets deletes targets from the inventory
+func (m *Memory) DeleteTargets(targetIDs []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, targetID := range targetIDs {
+		delete(m.targets, targetID)
+	}
+	return nil
+}
+
+// StoreQuarantinedTarget stores a quarantined target, replacing the previous
+// record of the same target
+func (m *Memory) StoreQuarantinedTarget(qt *target.QuarantinedTarget) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.quarantine[qt.ID] = *qt
+	return nil
+}
+
+// GetQuarantinedTargets returns all the quarantined targets, released ones
+// included, sorted by ID
+func (m *Memory) GetQuarantinedTargets() ([]*target.QuarantinedTarget, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	quarantined := make([]*target.QuarantinedTarget, 0, len(m.quarantine))
+	for _, qt := range m.quarantine {
+		qt := qt
+		quarantined = append(quarantined, &qt)
+	}
+	sort.Slice(quarantined, func(i, j int) bool {
+		return quarantined[i].ID < quarantined[j].ID
+	})
+	return quarantined, nil
+}
+
+// StoreSchedule creates a schedule if its ID is zero, and replaces the one
+// with the same ID otherwise, if it still exists
+func (m *Memory) StoreSchedule(s *job.Schedule) (types.ScheduleID, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stored := *s
+	if stored.ID == 0 {
+		stored.ID = m.scheduleIDCounter
+		m.scheduleIDCounter++
+	} else if _, ok := m.schedules[stored.ID]; !ok {
+		// deleted in the meantime
+		return stored.ID, nil
+	}
+	stored.NextFireTime = time.Time{}
+	stored.Queued = false
+	m.schedules[stored.ID] = stored
+	return stored.ID, nil
+}
+
+// GetSchedules returns all the schedules, sorted by ID
+func (m *Memory) GetSchedules() ([]*job.Schedule, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	schedules := make([]*job.Schedule, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		s := s
+		schedules = append(schedules, &s)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+// DeleteSchedule deletes a schedule
+func (m *Memory) DeleteSchedule(scheduleID types.ScheduleID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.schedules, scheduleID)
+	return nil
+}
+
 // New create a new Memory events storage backend
 func New() storage.Storage {
 	m := Memory{lock: &sync.Mutex{}}
 	m.jobRequests = make(map[types.JobID]*job.Request)
 	m.jobReports = make(map[types.JobID]*job.JobReport)
+	m.pauseStates = make(map[types.JobID][]byte)
+	m.targets = make(map[string]*target.Target)
+	m.quarantine = make(map[string]target.QuarantinedTarget)
+	m.schedules = make(map[types.ScheduleID]job.Schedule)
 	m.jobIDCounter = 1
+	m.scheduleIDCounter = 1
 	return &m
 }
@@ -96,6 +96,10 @@ func buildTestEventQuery(baseQuery bytes.Buffer, testEventQuery *testevent.Query
 		selectClauses = append(selectClauses, "test_step_label=?")
 		fields = append(fields, testEventQuery.TestStepLabel)
 	}
+	if testEventQuery != nil && testEventQuery.TargetID != "" {
+		selectClauses = append(selectClauses, "target_id=?")
+		fields = append(fields, testEventQuery.TargetID)
+	}
 	query, err := assembleQuery(baseQuery, selectClauses)
 	if err != nil {
 		return "", nil, fmt.Errorf("could not assemble query for framework events: %v", err)
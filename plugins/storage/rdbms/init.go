@@ -22,8 +22,13 @@ import (
 
 var log = logging.GetLogger("plugin/events/rdbms")
 
-const defaultFlushSize int = 64
-const defaultFlushInterval time.Duration = 5 * time.Second
+// DefaultFlushSize is the default size of the event buffers, see
+// TestEventsFlushSize and FrameworkEventsFlushSize.
+const DefaultFlushSize int = 64
+
+// DefaultFlushInterval is the default interval at which buffered events are
+// flushed, see TestEventsFlushInterval and FrameworkEventsFlushInterval.
+const DefaultFlushInterval time.Duration = 5 * time.Second
 
 // RDBMS implements a storage engine which stores ConTest information in a relational
 // database via the database/sql package. With the current implementation, only MySQL
@@ -75,6 +80,10 @@ func (r *RDBMS) Reset() error {
 	if err != nil {
 		return fmt.Errorf("could not truncate table jobs: %v", err)
 	}
+	_, err = r.db.Exec("truncate job_tags")
+	if err != nil {
+		return fmt.Errorf("could not truncate table job_tags: %v", err)
+	}
 	_, err = r.db.Exec("truncate run_reports")
 	if err != nil {
 		return fmt.Errorf("could not truncate table run_reports: %v", err)
@@ -83,6 +92,26 @@ func (r *RDBMS) Reset() error {
 	if err != nil {
 		return fmt.Errorf("could not truncate table final_reports: %v", err)
 	}
+	_, err = r.db.Exec("truncate paused_jobs")
+	if err != nil {
+		return fmt.Errorf("could not truncate table paused_jobs: %v", err)
+	}
+	_, err = r.db.Exec("truncate targets")
+	if err != nil {
+		return fmt.Errorf("could not truncate table targets: %v", err)
+	}
+	_, err = r.db.Exec("truncate target_attributes")
+	if err != nil {
+		return fmt.Errorf("could not truncate table target_attributes: %v", err)
+	}
+	_, err = r.db.Exec("truncate quarantined_targets")
+	if err != nil {
+		return fmt.Errorf("could not truncate table quarantined_targets: %v", err)
+	}
+	_, err = r.db.Exec("truncate schedules")
+	if err != nil {
+		return fmt.Errorf("could not truncate table schedules: %v", err)
+	}
 	return nil
 }
 
@@ -176,10 +205,10 @@ func New(dbURI string, opts ...Opt) storage.Storage {
 		testEventsLock:               &sync.Mutex{},
 		frameworkEventsLock:          &sync.Mutex{},
 		initOnce:                     &sync.Once{},
-		testEventsFlushSize:          defaultFlushSize,
-		testEventsFlushInterval:      defaultFlushInterval,
-		frameworkEventsFlushSize:     defaultFlushSize,
-		frameworkEventsFlushInterval: defaultFlushInterval,
+		testEventsFlushSize:          DefaultFlushSize,
+		testEventsFlushInterval:      DefaultFlushInterval,
+		frameworkEventsFlushSize:     DefaultFlushSize,
+		frameworkEventsFlushInterval: DefaultFlushInterval,
 	}
 	for _, Opt := range opts {
 		Opt(&backend)
@@ -0,0 +1,143 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/target"
+)
+
+// StoreTargets stores targets and their attributes in the inventory,
+// replacing the ones with the same ID
+func (r *RDBMS) StoreTargets(targets []*target.Target) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %v", err)
+	}
+	defer func() {
+		// this is a no-op if the transaction was committed
+		_ = tx.Rollback()
+	}()
+	for _, t := range targets {
+		if _, err := tx.Exec("replace into targets (target_id, name, fqdn) values (?, ?, ?)", t.ID, t.Name, t.FQDN); err != nil {
+			return fmt.Errorf("could not store target %s: %v", t.ID, err)
+		}
+		if _, err := tx.Exec("delete from target_attributes where target_id = ?", t.ID); err != nil {
+			return fmt.Errorf("could not replace attributes of target %s: %v", t.ID, err)
+		}
+		for name, value := range t.Attributes {
+			if _, err := tx.Exec("insert into target_attributes (target_id, name, value) values (?, ?, ?)", t.ID, name, value); err != nil {
+				return fmt.Errorf("could not store attribute '%s' of target %s: %v", name, t.ID, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit targets to database: %v", err)
+	}
+	return nil
+}
+
+// GetTargets retrieves all the targets of the inventory, sorted by ID
+func (r *RDBMS) GetTargets() ([]*target.Target, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	selectStatement := "select target_id, name, fqdn from targets order by target_id"
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement)
+	if err != nil {
+		return nil, fmt.Errorf("could not get targets: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+	var targets []*target.Target
+	byID := make(map[string]*target.Target)
+	for rows.Next() {
+		var t target.Target
+		if err := rows.Scan(&t.ID, &t.Name, &t.FQDN); err != nil {
+			return nil, fmt.Errorf("could not read target: %v", err)
+		}
+		targets = append(targets, &t)
+		byID[t.ID] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read targets: %v", err)
+	}
+
+	selectStatement = "select target_id, name, value from target_attributes"
+	log.Debugf("Executing query: %s", selectStatement)
+	attrRows, err := r.db.Query(selectStatement)
+	if err != nil {
+		return nil, fmt.Errorf("could not get target attributes: %v", err)
+	}
+	defer func() {
+		if err := attrRows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+	for attrRows.Next() {
+		var targetID, name, value string
+		if err := attrRows.Scan(&targetID, &name, &value); err != nil {
+			return nil, fmt.Errorf("could not read target attribute: %v", err)
+		}
+		t, ok := byID[targetID]
+		if !ok {
+			// the target was deleted in the meantime
+			continue
+		}
+		if t.Attributes == nil {
+			t.Attributes = make(map[string]string)
+		}
+		t.Attributes[name] = value
+	}
+	if err := attrRows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read target attributes: %v", err)
+	}
+	return targets, nil
+}
+
+// DeleteTargets deletes targets and their attributes from the inventory
+func (r *RDBMS) DeleteTargets(targetIDs []string) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	if len(targetIDs) == 0 {
+		return nil
+	}
+	fields := make([]interface{}, 0, len(targetIDs))
+	for _, targetID := range targetIDs {
+		fields = append(fields, targetID)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %v", err)
+	}
+	defer func() {
+		// this is a no-op if the transaction was committed
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec("delete from target_attributes where target_id in ("+placeholders(len(targetIDs))+")", fields...); err != nil {
+		return fmt.Errorf("could not delete target attributes: %v", err)
+	}
+	if _, err := tx.Exec("delete from targets where target_id in ("+placeholders(len(targetIDs))+")", fields...); err != nil {
+		return fmt.Errorf("could not delete targets: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit deletion of targets: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/types"
+)
+
+// StoreJobPauseState stores the pause state of a job, replacing any previous one
+func (r *RDBMS) StoreJobPauseState(jobID types.JobID, state []byte) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	insertStatement := "replace into paused_jobs (job_id, pause_time, state) values (?, ?, ?)"
+	if _, err := r.db.Exec(insertStatement, jobID, time.Now(), string(state)); err != nil {
+		return fmt.Errorf("could not store pause state for job %d: %v", jobID, err)
+	}
+	return nil
+}
+
+// GetJobPauseStates retrieves the pause state of all the paused jobs
+func (r *RDBMS) GetJobPauseStates() (map[types.JobID][]byte, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	selectStatement := "select job_id, state from paused_jobs"
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pause states: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+
+	states := make(map[types.JobID][]byte)
+	for rows.Next() {
+		var (
+			jobID types.JobID
+			state string
+		)
+		if err := rows.Scan(&jobID, &state); err != nil {
+			return nil, fmt.Errorf("could not read pause state: %v", err)
+		}
+		states[jobID] = []byte(state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read pause states: %v", err)
+	}
+	return states, nil
+}
+
+// DeleteJobPauseState deletes the pause state of a job
+func (r *RDBMS) DeleteJobPauseState(jobID types.JobID) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	if _, err := r.db.Exec("delete from paused_jobs where job_id = ?", jobID); err != nil {
+		return fmt.Errorf("could not delete pause state for job %d: %v", jobID, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/facebookincubator/contest/pkg/target"
+)
+
+// StoreQuarantinedTarget stores a quarantined target, replacing the previous
+// record of the same target
+func (r *RDBMS) StoreQuarantinedTarget(qt *target.QuarantinedTarget) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	releaseTime := sql.NullTime{Time: qt.ReleaseTime, Valid: qt.Released()}
+	insertStatement := "replace into quarantined_targets (target_id, target_name, reason, quarantine_time, release_time) values (?, ?, ?, ?, ?)"
+	if _, err := r.db.Exec(insertStatement, qt.ID, qt.Name, qt.Reason, qt.QuarantineTime, releaseTime); err != nil {
+		return fmt.Errorf("could not store quarantined target %s: %v", qt.ID, err)
+	}
+	return nil
+}
+
+// GetQuarantinedTargets retrieves all the quarantined targets, released ones
+// included, sorted by ID
+func (r *RDBMS) GetQuarantinedTargets() ([]*target.QuarantinedTarget, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	selectStatement := "select target_id, target_name, reason, quarantine_time, release_time from quarantined_targets order by target_id"
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement)
+	if err != nil {
+		return nil, fmt.Errorf("could not get quarantined targets: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+	var quarantined []*target.QuarantinedTarget
+	for rows.Next() {
+		var (
+			qt          target.QuarantinedTarget
+			releaseTime sql.NullTime
+		)
+		if err := rows.Scan(&qt.ID, &qt.Name, &qt.Reason, &qt.QuarantineTime, &releaseTime); err != nil {
+			return nil, fmt.Errorf("could not read quarantined target: %v", err)
+		}
+		if releaseTime.Valid {
+			qt.ReleaseTime = releaseTime.Time
+		}
+		quarantined = append(quarantined, &qt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read quarantined targets: %v", err)
+	}
+	return quarantined, nil
+}
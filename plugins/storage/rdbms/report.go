@@ -14,25 +14,45 @@ import (
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
-// StoreJobReport persists the job report on the internal storage.
+// StoreRunReports persists the reports of a run of a job on the internal
+// storage. Run numbers start at 1.
+func (r *RDBMS) StoreRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) error {
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	return r.storeRunReports(jobID, runNumber, reports)
+}
+
+func (r *RDBMS) storeRunReports(jobID types.JobID, runNumber uint, reports []*job.Report) error {
+	if runNumber == 0 {
+		return errors.New("invalid run number, cannot be zero")
+	}
+	for _, report := range reports {
+		insertStatement := "insert into run_reports (job_id, run_number, success, report_time, data) values (?, ?, ?, ?, ?)"
+		reportJSON, err := report.ToJSON()
+		if err != nil {
+			return fmt.Errorf("could not serialize run report for job %v: %v", jobID, err)
+		}
+		if _, err := r.db.Exec(insertStatement, jobID, runNumber, report.Success, report.ReportTime, reportJSON); err != nil {
+			return fmt.Errorf("could not store run report for job %v: %v", jobID, err)
+		}
+	}
+	return nil
+}
+
+// StoreJobReport persists the job report on the internal storage, in
+// addition to the run reports already stored for the job.
 func (r *RDBMS) StoreJobReport(jobReport *job.JobReport) error {
 	if err := r.init(); err != nil {
 		return fmt.Errorf("could not initialize database: %v", err)
 	}
 
 	for runID, runReports := range jobReport.RunReports {
-		for _, report := range runReports {
-			insertStatement := "insert into run_reports (job_id, run_number, success, report_time, data) values (?, ?, ?, ?, ?)"
-			reportJSON, err := report.ToJSON()
-			if err != nil {
-				return fmt.Errorf("could not serialize run report for job %v: %v", jobReport.JobID, err)
-			}
-			// note: run ID is a zero-based index, while the run number starts
-			// at 1 (hence the +1). We store the run number, not the run ID. A
-			// zero value means that something is wrong.
-			if _, err := r.db.Exec(insertStatement, jobReport.JobID, runID+1, report.Success, report.ReportTime, reportJSON); err != nil {
-				return fmt.Errorf("could not store run report for job %v: %v", jobReport.JobID, err)
-			}
+		// note: run ID is a zero-based index, while the run number starts
+		// at 1 (hence the +1). We store the run number, not the run ID. A
+		// zero value means that something is wrong.
+		if err := r.storeRunReports(jobReport.JobID, uint(runID)+1, runReports); err != nil {
+			return err
 		}
 	}
 	for _, report := range jobReport.FinalReports {
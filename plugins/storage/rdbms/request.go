@@ -6,13 +6,19 @@
 package rdbms
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"strings"
 
+	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/storage"
 	"github.com/facebookincubator/contest/pkg/types"
 )
 
-// StoreJobRequest stores a new job request in the database
+// StoreJobRequest stores a new job request in the database, together with its
+// tags
 func (r *RDBMS) StoreJobRequest(request *job.Request) (types.JobID, error) {
 
 	var jobID types.JobID
@@ -20,8 +26,16 @@ func (r *RDBMS) StoreJobRequest(request *job.Request) (types.JobID, error) {
 	if err := r.init(); err != nil {
 		return jobID, fmt.Errorf("could not initialize database: %v", err)
 	}
-	insertStatement := "insert into jobs (name, descriptor, requestor, request_time) values (?, ?, ?, ?)"
-	result, err := r.db.Exec(insertStatement, request.JobName, request.JobDescriptor, request.Requestor, request.RequestTime)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return jobID, fmt.Errorf("could not start transaction: %v", err)
+	}
+	defer func() {
+		// this is a no-op if the transaction was committed
+		_ = tx.Rollback()
+	}()
+	insertStatement := "insert into jobs (name, descriptor, requestor, request_time, retry_of) values (?, ?, ?, ?, ?)"
+	result, err := tx.Exec(insertStatement, request.JobName, request.JobDescriptor, request.Requestor, request.RequestTime, request.RetryOf)
 	if err != nil {
 		return jobID, fmt.Errorf("could not store job request in database: %v", err)
 	}
@@ -30,9 +44,55 @@ func (r *RDBMS) StoreJobRequest(request *job.Request) (types.JobID, error) {
 		return jobID, fmt.Errorf("could not extract id of last request inserted into db")
 	}
 	jobID = types.JobID(lastID)
+	for _, tag := range request.Tags {
+		// duplicated tags are ignored
+		if _, err := tx.Exec("insert ignore into job_tags (job_id, tag) values (?, ?)", jobID, tag); err != nil {
+			return types.JobID(0), fmt.Errorf("could not store tag '%s' of job request in database: %v", tag, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return types.JobID(0), fmt.Errorf("could not commit job request to database: %v", err)
+	}
 	return jobID, nil
 }
 
+// jobTags retrieves the sorted tags of the given jobs
+func (r *RDBMS) jobTags(jobIDs []types.JobID) (map[types.JobID][]string, error) {
+	tags := make(map[types.JobID][]string)
+	if len(jobIDs) == 0 {
+		return tags, nil
+	}
+	selectStatement := "select job_id, tag from job_tags where job_id in (" + placeholders(len(jobIDs)) + ") order by job_id, tag"
+	fields := make([]interface{}, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		fields = append(fields, jobID)
+	}
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement, fields...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get job tags: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+	for rows.Next() {
+		var (
+			jobID types.JobID
+			tag   string
+		)
+		if err := rows.Scan(&jobID, &tag); err != nil {
+			return nil, fmt.Errorf("could not read job tag: %v", err)
+		}
+		tags[jobID] = append(tags[jobID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read job tags: %v", err)
+	}
+	return tags, nil
+}
+
 // GetJobRequest retrieves a JobRequest from the database
 func (r *RDBMS) GetJobRequest(jobID types.JobID) (*job.Request, error) {
 
@@ -40,7 +100,7 @@ func (r *RDBMS) GetJobRequest(jobID types.JobID) (*job.Request, error) {
 		return nil, fmt.Errorf("could not initialize database: %v", err)
 	}
 
-	selectStatement := "select job_id, name, requestor, request_time, descriptor from jobs where job_id = ?"
+	selectStatement := "select job_id, name, requestor, request_time, descriptor, retry_of from jobs where job_id = ?"
 	log.Debugf("Executing query: %s", selectStatement)
 	rows, err := r.db.Query(selectStatement, jobID)
 	if err != nil {
@@ -66,6 +126,7 @@ func (r *RDBMS) GetJobRequest(jobID types.JobID) (*job.Request, error) {
 			&currRequest.Requestor,
 			&currRequest.RequestTime,
 			&currRequest.JobDescriptor,
+			&currRequest.RetryOf,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("could not get job request with job id %v: %v", jobID, err)
@@ -74,7 +135,221 @@ func (r *RDBMS) GetJobRequest(jobID types.JobID) (*job.Request, error) {
 	}
 
 	if req == nil {
-		return nil, fmt.Errorf("could not find request with JobID %d", jobID)
+		return nil, fmt.Errorf("could not find request with JobID %d: %w", jobID, storage.ErrJobNotFound)
+	}
+	tags, err := r.jobTags([]types.JobID{jobID})
+	if err != nil {
+		return nil, err
 	}
+	req.Tags = tags[jobID]
 	return req, nil
 }
+
+// GetJobRequestsByTag retrieves from the database all the job requests with
+// the given tag, in the order they were stored
+func (r *RDBMS) GetJobRequestsByTag(tag string) ([]*job.Request, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	selectStatement := "select j.job_id, j.name, j.requestor, j.request_time, j.descriptor, j.retry_of from jobs j join job_tags t on t.job_id = j.job_id where t.tag = ? order by j.job_id"
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement, tag)
+	if err != nil {
+		return nil, fmt.Errorf("could not get job requests with tag '%s': %v", tag, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+
+	requests := []*job.Request{}
+	jobIDs := []types.JobID{}
+	for rows.Next() {
+		req := job.Request{}
+		err := rows.Scan(
+			&req.JobID,
+			&req.JobName,
+			&req.Requestor,
+			&req.RequestTime,
+			&req.JobDescriptor,
+			&req.RetryOf,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not get job request with tag '%s': %v", tag, err)
+		}
+		requests = append(requests, &req)
+		jobIDs = append(jobIDs, req.JobID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get job requests with tag '%s': %v", tag, err)
+	}
+	tags, err := r.jobTags(jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		req.Tags = tags[req.JobID]
+	}
+	return requests, nil
+}
+
+// likePattern converts a job name pattern, where `*` matches any sequence of
+// characters and `?` matches a single character, to a pattern for the LIKE
+// operator.
+func likePattern(pattern string) string {
+	var b strings.Builder
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteRune('%')
+		case '?':
+			b.WriteRune('_')
+		case '%', '_', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// placeholders returns a comma-separated list of n placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// buildListJobsQuery builds the select statement to list jobs matching the
+// query, together with its arguments.
+func buildListJobsQuery(query *job.ListQuery, stateEvents []event.Name) (string, []interface{}) {
+	var (
+		baseQuery     bytes.Buffer
+		selectClauses []string
+		fields        []interface{}
+	)
+	if len(stateEvents) > 0 {
+		// the state of a job is the name of its latest state event
+		baseQuery.WriteString("select j.job_id, j.name, j.requestor, j.request_time, coalesce(fe.event_name, '') from jobs j ")
+		baseQuery.WriteString("left join framework_events fe on fe.event_id = (select max(se.event_id) from framework_events se where se.job_id = j.job_id and se.event_name in (")
+		baseQuery.WriteString(placeholders(len(stateEvents)))
+		baseQuery.WriteString("))")
+		for _, name := range stateEvents {
+			fields = append(fields, name)
+		}
+	} else {
+		baseQuery.WriteString("select j.job_id, j.name, j.requestor, j.request_time, '' from jobs j")
+	}
+
+	if len(query.States) > 0 {
+		if len(stateEvents) > 0 {
+			selectClauses = append(selectClauses, "fe.event_name in ("+placeholders(len(query.States))+")")
+			for _, state := range query.States {
+				fields = append(fields, state)
+			}
+		} else {
+			// no job can be in any state
+			selectClauses = append(selectClauses, "false")
+		}
+	}
+	if len(query.Tags) > 0 {
+		tagClauses := make([]string, 0, len(query.Tags))
+		for _, tag := range query.Tags {
+			tagClauses = append(tagClauses, "exists (select 1 from job_tags t where t.job_id = j.job_id and t.tag = ?)")
+			fields = append(fields, tag)
+		}
+		op := " or "
+		if query.MatchAllTags {
+			op = " and "
+		}
+		selectClauses = append(selectClauses, "("+strings.Join(tagClauses, op)+")")
+	}
+	if query.Requestor != "" {
+		selectClauses = append(selectClauses, "j.requestor=?")
+		fields = append(fields, query.Requestor)
+	}
+	if query.NamePattern != "" {
+		selectClauses = append(selectClauses, "j.name like ?")
+		fields = append(fields, likePattern(query.NamePattern))
+	}
+	if !query.RequestedAfter.IsZero() {
+		selectClauses = append(selectClauses, "j.request_time>=?")
+		fields = append(fields, query.RequestedAfter)
+	}
+	if !query.RequestedBefore.IsZero() {
+		selectClauses = append(selectClauses, "j.request_time<=?")
+		fields = append(fields, query.RequestedBefore)
+	}
+	if len(selectClauses) > 0 {
+		baseQuery.WriteString(" where ")
+		baseQuery.WriteString(strings.Join(selectClauses, " and "))
+	}
+	baseQuery.WriteString(" order by j.job_id desc")
+	if query.Limit > 0 || query.Offset > 0 {
+		limit := uint64(query.Limit)
+		if limit == 0 {
+			// MySQL does not support an offset without a limit
+			limit = math.MaxInt64
+		}
+		baseQuery.WriteString(" limit ? offset ?")
+		fields = append(fields, limit, uint64(query.Offset))
+	}
+	return baseQuery.String(), fields
+}
+
+// ListJobs returns the jobs matching the query, from the most recent to the
+// oldest
+func (r *RDBMS) ListJobs(query *job.ListQuery, stateEvents []event.Name) ([]job.Summary, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	// Flush pending events, as they determine the state of the jobs
+	if err := r.FlushFrameworkEvents(); err != nil {
+		return nil, fmt.Errorf("could not flush events before listing jobs: %v", err)
+	}
+
+	selectStatement, fields := buildListJobsQuery(query, stateEvents)
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement, fields...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list jobs: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+
+	jobs := []job.Summary{}
+	jobIDs := []types.JobID{}
+	for rows.Next() {
+		var summary job.Summary
+		err := rows.Scan(
+			&summary.JobID,
+			&summary.JobName,
+			&summary.Requestor,
+			&summary.RequestTime,
+			&summary.State,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not read job: %v", err)
+		}
+		jobs = append(jobs, summary)
+		jobIDs = append(jobIDs, summary.JobID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read jobs: %v", err)
+	}
+	tags, err := r.jobTags(jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range jobs {
+		jobs[idx].Tags = tags[jobs[idx].JobID]
+	}
+	return jobs, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/job"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	"github.com/insomniacslk/xjson"
+)
+
+// StoreSchedule creates a schedule if its ID is zero, and updates the one with
+// the same ID otherwise, if it still exists
+func (r *RDBMS) StoreSchedule(s *job.Schedule) (types.ScheduleID, error) {
+
+	if err := r.init(); err != nil {
+		return 0, fmt.Errorf("could not initialize database: %v", err)
+	}
+	lastFireTime := sql.NullTime{Time: s.LastFireTime, Valid: !s.LastFireTime.IsZero()}
+	if s.ID != 0 {
+		updateStatement := "update schedules set job_name = ?, requestor = ?, create_time = ?, descriptor = ?, cron = ?, interval_ns = ?, overlap_policy = ?, paused = ?, last_fire_time = ?, last_job_id = ? where schedule_id = ?"
+		if _, err := r.db.Exec(updateStatement, s.JobName, s.Requestor, s.CreateTime, s.JobDescriptor, s.Cron, int64(s.Interval), string(s.OverlapPolicy), s.Paused, lastFireTime, s.LastJobID, s.ID); err != nil {
+			return 0, fmt.Errorf("could not update schedule %d: %v", s.ID, err)
+		}
+		return s.ID, nil
+	}
+	insertStatement := "insert into schedules (job_name, requestor, create_time, descriptor, cron, interval_ns, overlap_policy, paused, last_fire_time, last_job_id) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.Exec(insertStatement, s.JobName, s.Requestor, s.CreateTime, s.JobDescriptor, s.Cron, int64(s.Interval), string(s.OverlapPolicy), s.Paused, lastFireTime, s.LastJobID)
+	if err != nil {
+		return 0, fmt.Errorf("could not store schedule: %v", err)
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("could not extract id of last schedule inserted into db")
+	}
+	return types.ScheduleID(lastID), nil
+}
+
+// GetSchedules retrieves all the schedules, sorted by ID
+func (r *RDBMS) GetSchedules() ([]*job.Schedule, error) {
+
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("could not initialize database: %v", err)
+	}
+
+	selectStatement := "select schedule_id, job_name, requestor, create_time, descriptor, cron, interval_ns, overlap_policy, paused, last_fire_time, last_job_id from schedules order by schedule_id"
+	log.Debugf("Executing query: %s", selectStatement)
+	rows, err := r.db.Query(selectStatement)
+	if err != nil {
+		return nil, fmt.Errorf("could not get schedules: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("failed to close rows from query statement: %v", err)
+		}
+	}()
+	var schedules []*job.Schedule
+	for rows.Next() {
+		var (
+			s             job.Schedule
+			interval      int64
+			overlapPolicy string
+			lastFireTime  sql.NullTime
+		)
+		if err := rows.Scan(&s.ID, &s.JobName, &s.Requestor, &s.CreateTime, &s.JobDescriptor, &s.Cron, &interval, &overlapPolicy, &s.Paused, &lastFireTime, &s.LastJobID); err != nil {
+			return nil, fmt.Errorf("could not read schedule: %v", err)
+		}
+		s.Interval = xjson.Duration(time.Duration(interval))
+		s.OverlapPolicy = job.OverlapPolicy(overlapPolicy)
+		if lastFireTime.Valid {
+			s.LastFireTime = lastFireTime.Time
+		}
+		schedules = append(schedules, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule deletes a schedule
+func (r *RDBMS) DeleteSchedule(scheduleID types.ScheduleID) error {
+
+	if err := r.init(); err != nil {
+		return fmt.Errorf("could not initialize database: %v", err)
+	}
+	if _, err := r.db.Exec("delete from schedules where schedule_id = ?", scheduleID); err != nil {
+		return fmt.Errorf("could not delete schedule %d: %v", scheduleID, err)
+	}
+	return nil
+}
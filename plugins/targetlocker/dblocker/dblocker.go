@@ -0,0 +1,296 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package dblocker implements a target locker which stores the locks in a
+// relational database via the database/sql package, so that all the ConTest
+// servers using the same database see each other's locks. Locks are stored in
+// the target_locks table, keyed by target ID, see
+// docker/mysql/create_contest_db.sql. The queries are portable SQL, and they
+// are tested against MySQL.
+//
+// Lock expiration is computed with the clock of the ConTest servers, which
+// are expected to be synchronized.
+package dblocker
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/logging"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+
+	// this blank import registers the mysql driver
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Name is the name used to look this plugin up.
+var Name = "DBLocker"
+
+var log = logging.GetLogger("targetlocker/" + strings.ToLower(Name))
+
+// DBLocker is a target locker backed by a relational database. Every operation
+// runs in a transaction, which reaps the expired locks first.
+type DBLocker struct {
+	driverName string
+	dbURI      string
+	timeout    time.Duration
+
+	initOnce *sync.Once
+	initErr  error
+	db       *sql.DB
+}
+
+func (tl *DBLocker) init() error {
+	tl.initOnce.Do(func() {
+		db, err := sql.Open(tl.driverName, tl.dbURI)
+		if err != nil {
+			tl.initErr = fmt.Errorf("could not initialize database for target locks: %v", err)
+			return
+		}
+		tl.db = db
+	})
+	return tl.initErr
+}
+
+// transact runs f in a transaction, which is rolled back if f fails.
+func (tl *DBLocker) transact(f func(tx *sql.Tx) error) error {
+	if err := tl.init(); err != nil {
+		return err
+	}
+	tx, err := tl.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %v", err)
+	}
+	if err := f(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Warningf("Failed to roll back transaction: %v", rbErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %v", err)
+	}
+	return nil
+}
+
+// targetIDs returns the IDs of the targets as query arguments. Locks are keyed
+// by target ID, so targets without an ID cannot be locked.
+func targetIDs(targets []*target.Target) ([]interface{}, error) {
+	ids := make([]interface{}, 0, len(targets))
+	for _, t := range targets {
+		if t.ID == "" {
+			return nil, fmt.Errorf("cannot lock target without ID: %s", t)
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// reap deletes the locks which expired before now.
+func reap(tx *sql.Tx, now time.Time) error {
+	res, err := tx.Exec("delete from target_locks where expires_at <= ?", now)
+	if err != nil {
+		return fmt.Errorf("could not reap expired target locks: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		log.Debugf("Reaped %d expired target lock(s)", n)
+	}
+	return nil
+}
+
+// owners returns the owners of the locks on the given target IDs which expire
+// after now, keyed by target ID.
+func owners(tx *sql.Tx, ids []interface{}, now time.Time) (map[string]types.JobID, error) {
+	query := fmt.Sprintf("select target_id, job_id from target_locks where expires_at > ? and target_id in (%s)", placeholders(len(ids)))
+	rows, err := tx.Query(query, append([]interface{}{now}, ids...)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch target locks: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warningf("Failed to close rows of target locks: %v", err)
+		}
+	}()
+	owners := make(map[string]types.JobID)
+	for rows.Next() {
+		var (
+			targetID string
+			owner    types.JobID
+		)
+		if err := rows.Scan(&targetID, &owner); err != nil {
+			return nil, fmt.Errorf("could not read target lock: %v", err)
+		}
+		owners[targetID] = owner
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not fetch target locks: %v", err)
+	}
+	return owners, nil
+}
+
+// Lock locks the specified targets for the configured timeout. Targets which
+// are already locked by the same job have their lock extended. If any of the
+// targets is locked by another job, none of them is locked.
+func (tl *DBLocker) Lock(jobID types.JobID, targets []*target.Target) error {
+	log.Infof("Trying to lock %d targets for job ID %d", len(targets), jobID)
+	if len(targets) == 0 {
+		return nil
+	}
+	ids, err := targetIDs(targets)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	expiresAt := now.Add(tl.timeout)
+	return tl.transact(func(tx *sql.Tx) error {
+		if err := reap(tx, now); err != nil {
+			return err
+		}
+		lockOwners, err := owners(tx, ids, now)
+		if err != nil {
+			return err
+		}
+		for _, t := range targets {
+			owner, locked := lockOwners[t.ID]
+			switch {
+			case !locked:
+				if _, err := tx.Exec("insert into target_locks (target_id, job_id, locked_at, expires_at) values (?, ?, ?, ?)", t.ID, jobID, now, expiresAt); err != nil {
+					return fmt.Errorf("could not lock target %s: %v", t, err)
+				}
+				lockOwners[t.ID] = jobID
+			case owner == jobID:
+				if _, err := tx.Exec("update target_locks set expires_at = ? where target_id = ? and job_id = ?", expiresAt, t.ID, jobID); err != nil {
+					return fmt.Errorf("could not extend lock on target %s: %v", t, err)
+				}
+			default:
+				return fmt.Errorf("target already locked by job ID %d: %s", owner, t)
+			}
+		}
+		return nil
+	})
+}
+
+// Unlock unlocks the specified targets. If any of the targets is locked by
+// another job, none of them is unlocked.
+func (tl *DBLocker) Unlock(jobID types.JobID, targets []*target.Target) error {
+	log.Infof("Trying to unlock %d targets for job ID %d", len(targets), jobID)
+	if len(targets) == 0 {
+		return nil
+	}
+	ids, err := targetIDs(targets)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	return tl.transact(func(tx *sql.Tx) error {
+		if err := reap(tx, now); err != nil {
+			return err
+		}
+		lockOwners, err := owners(tx, ids, now)
+		if err != nil {
+			return err
+		}
+		for _, t := range targets {
+			owner, locked := lockOwners[t.ID]
+			if !locked {
+				log.Debugf("Target is not locked, but received unlock request: %s", t)
+			} else if owner != jobID {
+				return fmt.Errorf("target locked by job ID %d, cannot unlock it for job ID %d: %s", owner, jobID, t)
+			}
+		}
+		query := fmt.Sprintf("delete from target_locks where job_id = ? and target_id in (%s)", placeholders(len(ids)))
+		if _, err := tx.Exec(query, append([]interface{}{jobID}, ids...)...); err != nil {
+			return fmt.Errorf("could not unlock targets: %v", err)
+		}
+		return nil
+	})
+}
+
+// RefreshLocks extends the locks by the configured timeout. Like Lock, it
+// locks again the targets whose lock expired in the meantime, unless another
+// job locked them.
+func (tl *DBLocker) RefreshLocks(jobID types.JobID, targets []*target.Target) error {
+	log.Infof("Trying to refresh locks on %d targets by %s", len(targets), tl.timeout)
+	return tl.Lock(jobID, targets)
+}
+
+// CheckLocks tells whether all the targets are locked by the given job ID. It
+// also returns the targets which are locked by it, and the ones which are not.
+func (tl *DBLocker) CheckLocks(jobID types.JobID, targets []*target.Target) (bool, []*target.Target, []*target.Target) {
+	log.Infof("Checking if %d target(s) are locked by job ID %d", len(targets), jobID)
+	locked := make([]*target.Target, 0)
+	notLocked := make([]*target.Target, 0)
+	if len(targets) == 0 {
+		return true, locked, notLocked
+	}
+	var lockOwners map[string]types.JobID
+	ids, err := targetIDs(targets)
+	if err == nil {
+		err = tl.transact(func(tx *sql.Tx) error {
+			var err error
+			lockOwners, err = owners(tx, ids, time.Now().UTC())
+			return err
+		})
+	}
+	if err != nil {
+		// TODO the target.Locker.CheckLocks interface has to change to return an
+		// error as well. Just log an error for now, and consider the targets
+		// not locked.
+		log.Warningf("Error when checking target locks: %v", err)
+		return false, locked, targets
+	}
+	for _, t := range targets {
+		if owner, ok := lockOwners[t.ID]; ok && owner == jobID {
+			locked = append(locked, t)
+		} else {
+			notLocked = append(notLocked, t)
+		}
+	}
+	return len(notLocked) == 0, locked, notLocked
+}
+
+// Opt is a function type that sets parameters on the DBLocker object
+type Opt func(tl *DBLocker)
+
+// DriverName sets the database/sql driver used to connect to the database.
+// The driver must be registered by the program. The default is mysql.
+func DriverName(name string) Opt {
+	return func(tl *DBLocker) {
+		tl.driverName = name
+	}
+}
+
+// New returns a DBLocker storing the locks in the database at dbURI. Locks
+// last for the given timeout.
+func New(dbURI string, timeout time.Duration, opts ...Opt) target.Locker {
+	tl := DBLocker{
+		driverName: "mysql",
+		dbURI:      dbURI,
+		timeout:    timeout,
+		initOnce:   &sync.Once{},
+	}
+	for _, opt := range opts {
+		opt(&tl)
+	}
+	return &tl
+}
+
+// Loader returns a loader of the DBLocker plugin, whose locks are stored in
+// the database at dbURI.
+func Loader(dbURI string, opts ...Opt) target.LockerLoader {
+	return func() (string, target.LockerFactory) {
+		return Name, func(timeout time.Duration) target.Locker {
+			return New(dbURI, timeout, opts...)
+		}
+	}
+}
@@ -33,9 +33,14 @@ type request struct {
 	// timeout is how long the lock should be held for. There is no lower or
 	// upper bound on how long the lock can be held.
 	timeout time.Duration
+	// min, max and deadline are the number of targets to lock and until when
+	// to wait for them, for the requests waiting for targets.
+	min, max int
+	deadline time.Time
 	// locked and notLocked are arrays of targets that are respectively already locked
 	// by a given job ID, and that are not locked by a given job ID. This is only
-	// populated when checking locks for such targets.
+	// populated when checking locks for such targets, and when a request
+	// waiting for targets is served.
 	locked, notLocked []*target.Target
 	// err reports whether there were errors in any lock-related operation.
 	err chan error
@@ -49,56 +54,138 @@ type lock struct {
 
 // broker is the broker of locking requests, and it's the only goroutine with
 // access to the locks map, in accordance with Go's "share memory by
-// communicating" principle.
-func broker(lockRequests, unlockRequests, checkLocksRequests <-chan request, done <-chan struct{}) {
-	locks := make(map[target.Target]lock)
+// communicating" principle. Each request is handled as a transaction: it
+// either succeeds on all of its targets, or changes none of them.
+//
+// Requests waiting for targets are queued, and served in FIFO order whenever
+// targets may have become available.
+func broker(lockRequests, unlockRequests, checkLocksRequests, waitRequests, withdrawRequests <-chan *request, done <-chan struct{}) {
+	locks := make(map[target.Key]lock)
+	var waiters []*request
+	// lockedBy returns the owner of the lock on the target, if the target is
+	// locked. Expired locks are purged.
+	lockedBy := func(t *target.Target, now time.Time) (types.JobID, bool) {
+		l, ok := locks[t.Key()]
+		if !ok {
+			return 0, false
+		}
+		if now.After(l.expiresAt) {
+			log.Debugf("Purged expired lock for target %+v. Lock time is %s, expiration time is %s", t, l.lockedAt, l.expiresAt)
+			delete(locks, t.Key())
+			return 0, false
+		}
+		return l.owner, true
+	}
+	// conflict returns an error if any of the targets is locked by another
+	// owner than the one of the request.
+	conflict := func(req *request, now time.Time) error {
+		for _, t := range req.targets {
+			if owner, locked := lockedBy(t, now); locked && owner != req.owner {
+				return fmt.Errorf("target locked by job ID %d, not by job ID %d: %+v", owner, req.owner, t)
+			}
+		}
+		return nil
+	}
+	// serve serves the waiting requests in FIFO order. The targets of a request
+	// which keeps waiting are reserved, so that the requests which came after
+	// it cannot take them.
+	serve := func(now time.Time) {
+		reserved := make(map[target.Key]bool)
+		var stillWaiting []*request
+		for _, req := range waiters {
+			var available []*target.Target
+			for _, t := range req.targets {
+				if reserved[t.Key()] {
+					continue
+				}
+				if owner, locked := lockedBy(t, now); locked && owner != req.owner {
+					continue
+				}
+				available = append(available, t)
+			}
+			switch {
+			case len(available) >= req.min:
+				if len(available) > req.max {
+					available = available[:req.max]
+				}
+				for _, t := range available {
+					locks[t.Key()] = lock{
+						owner:     req.owner,
+						lockedAt:  now,
+						expiresAt: now.Add(req.timeout),
+					}
+				}
+				log.Debugf("Locked %d targets out of %d for job ID %d", len(available), len(req.targets), req.owner)
+				req.locked = available
+				req.err <- nil
+			case !now.Before(req.deadline):
+				req.err <- fmt.Errorf("%w: %d target(s) available out of %d, %d required", target.ErrNotEnoughTargets, len(available), len(req.targets), req.min)
+			default:
+				for _, t := range req.targets {
+					reserved[t.Key()] = true
+				}
+				stillWaiting = append(stillWaiting, req)
+			}
+		}
+		waiters = stillWaiting
+	}
+	// nextWakeUp returns when the waiting requests have to be served again,
+	// either because a lock expires or because a deadline is reached.
+	nextWakeUp := func() <-chan time.Time {
+		if len(waiters) == 0 {
+			return nil
+		}
+		next := waiters[0].deadline
+		for _, req := range waiters {
+			if req.deadline.Before(next) {
+				next = req.deadline
+			}
+			for _, t := range req.targets {
+				if l, ok := locks[t.Key()]; ok && l.expiresAt.Before(next) {
+					next = l.expiresAt
+				}
+			}
+		}
+		// lockedBy considers locks expired strictly after their expiration
+		return time.After(time.Until(next) + time.Millisecond)
+	}
 	for {
+		wakeUp := nextWakeUp()
 		select {
 		case <-done:
 			log.Debugf("Shutting down in-memory target locker")
 			return
 		case req := <-lockRequests:
 			log.Debugf("Requested to lock %d targets: %v", len(req.targets), req.targets)
-			var lockErr error
+			now := time.Now()
+			if err := conflict(req, now); err != nil {
+				req.err <- err
+				break
+			}
 			for _, t := range req.targets {
-				now := time.Now()
-				if l, ok := locks[*t]; ok {
-					// target has been locked before. Is it still locked, or did
-					// it expire?
-					if now.After(l.expiresAt) {
-						// lock has expired, consider it unlocked
-						locks[*t] = lock{
-							owner:     req.owner,
-							lockedAt:  now,
-							expiresAt: now.Add(req.timeout),
-						}
-					} else {
-						// target is locked. Is it us or someone else?
-						if l.owner == req.owner {
-							// we are trying to extend a lock.
-							l := locks[*t]
-							l.expiresAt = time.Now().Add(req.timeout)
-							locks[*t] = l
-						} else {
-							lockErr = fmt.Errorf("target already locked: %+v", t)
-						}
-						break
-					}
+				if l, ok := locks[t.Key()]; ok {
+					// we are trying to extend a lock.
+					l.expiresAt = now.Add(req.timeout)
+					locks[t.Key()] = l
 				} else {
-					// target not locked and never seen, create new lock
-					locks[*t] = lock{
+					locks[t.Key()] = lock{
 						owner:     req.owner,
 						lockedAt:  now,
 						expiresAt: now.Add(req.timeout),
 					}
 				}
 			}
-			req.err <- lockErr
+			req.err <- nil
 		case req := <-unlockRequests:
 			log.Debugf("Requested to transactionally unlock %d targets: %v", len(req.targets), req.targets)
+			now := time.Now()
+			if err := conflict(req, now); err != nil {
+				req.err <- err
+				break
+			}
 			for _, t := range req.targets {
-				if _, ok := locks[*t]; ok {
-					delete(locks, *t)
+				if _, locked := lockedBy(t, now); locked {
+					delete(locks, t.Key())
 				} else {
 					log.Debugf("Target is not locked, but received unlock request: %+v", t)
 				}
@@ -106,59 +193,68 @@ func broker(lockRequests, unlockRequests, checkLocksRequests <-chan request, don
 			req.err <- nil
 		case req := <-checkLocksRequests:
 			log.Debugf("Requested to check locks for %d targets: %v", len(req.targets), req.targets)
+			now := time.Now()
 			locked := make([]*target.Target, 0)
 			notLocked := make([]*target.Target, 0)
 			for _, t := range req.targets {
-				if l, ok := locks[*t]; ok {
-					now := time.Now()
-					if now.After(l.expiresAt) {
-						// target was locked but lock expired, purge the entry
-						log.Debugf("Purged expired lock for target %+v. Lock time is %s, expiration timeout is %s", t, l.lockedAt, req.timeout)
-						delete(locks, *t)
-						notLocked = append(notLocked, t)
-					} else {
-						// target is locked
-						locked = append(locked, t)
-					}
+				if owner, ok := lockedBy(t, now); ok && owner == req.owner {
+					locked = append(locked, t)
 				} else {
-					// target is not locked
 					notLocked = append(notLocked, t)
 				}
 			}
 			req.locked = locked
 			req.notLocked = notLocked
 			req.err <- nil
+		case req := <-waitRequests:
+			log.Debugf("Requested to lock between %d and %d targets out of %d, waiting until %s: %v", req.min, req.max, len(req.targets), req.deadline, req.targets)
+			waiters = append(waiters, req)
+		case req := <-withdrawRequests:
+			for idx, w := range waiters {
+				if w == req {
+					waiters = append(waiters[:idx], waiters[idx+1:]...)
+					req.err <- target.ErrLockWaitCancelled
+					break
+				}
+			}
+		case <-wakeUp:
 		}
+		serve(time.Now())
 	}
 }
 
-// InMemory is the no-op target locker. It does nothing.
+// InMemory is a target locker which keeps the locks in memory.
 type InMemory struct {
-	lockRequests, unlockRequests, checkLocksRequests chan request
+	lockRequests, unlockRequests, checkLocksRequests chan *request
+	waitRequests, withdrawRequests                   chan *request
 	done                                             chan struct{}
 	timeout                                          time.Duration
 }
 
-func newReq(targets []*target.Target) request {
-	return request{
+func newReq(jobID types.JobID, targets []*target.Target) *request {
+	return &request{
 		targets: targets,
-		err:     make(chan error),
+		owner:   jobID,
+		// the broker must not block on requests which are withdrawn
+		err: make(chan error, 1),
 	}
 }
 
-// Lock locks the specified targets by doing nothing.
+// Lock locks the specified targets for the configured timeout. If any of the
+// targets is locked by another job, none of them is locked.
 func (tl *InMemory) Lock(jobID types.JobID, targets []*target.Target) error {
 	log.Infof("Trying to lock %d targets", len(targets))
-	req := newReq(targets)
+	req := newReq(jobID, targets)
 	req.timeout = tl.timeout
 	tl.lockRequests <- req
 	return <-req.err
 }
 
-// Unlock unlocks the specified targets by doing nothing.
+// Unlock unlocks the specified targets. If any of the targets is locked by
+// another job, none of them is unlocked.
 func (tl *InMemory) Unlock(jobID types.JobID, targets []*target.Target) error {
 	log.Infof("Trying to unlock %d targets", len(targets))
-	req := newReq(targets)
+	req := newReq(jobID, targets)
 	tl.unlockRequests <- req
 	return <-req.err
 }
@@ -167,7 +263,7 @@ func (tl *InMemory) Unlock(jobID types.JobID, targets []*target.Target) error {
 // the owner is different, the request is rejected.
 func (tl *InMemory) RefreshLocks(jobID types.JobID, targets []*target.Target) error {
 	log.Infof("Trying to refresh locks on %d targets by %s", len(targets), tl.timeout)
-	req := newReq(targets)
+	req := newReq(jobID, targets)
 	req.timeout = tl.timeout
 	// refreshing a lock is just a lock operation with the same owner and a new
 	// duration.
@@ -175,34 +271,75 @@ func (tl *InMemory) RefreshLocks(jobID types.JobID, targets []*target.Target) er
 	return <-req.err
 }
 
-// CheckLocks tells whether all the targets are locked. They all are, always. It
-// also returns the ones that are not locked.
+// LockWait locks between the minimum and maximum number of targets of the
+// request, waiting for them until the deadline. Requests waiting for the same
+// targets are served in FIFO order.
+func (tl *InMemory) LockWait(jobID types.JobID, cancel <-chan struct{}, lr target.LockRequest) ([]*target.Target, error) {
+	log.Infof("Trying to lock %d targets, waiting until %s", len(lr.Targets), lr.Deadline)
+	min, max, err := lr.Bounds()
+	if err != nil {
+		return nil, err
+	}
+	req := newReq(jobID, lr.Targets)
+	req.timeout = tl.timeout
+	req.min, req.max = min, max
+	req.deadline = lr.Deadline
+	tl.waitRequests <- req
+	select {
+	case err := <-req.err:
+		if err != nil {
+			return nil, err
+		}
+		return req.locked, nil
+	case <-cancel:
+		tl.withdrawRequests <- req
+		// the request may have been served in the meantime
+		if err := <-req.err; err == nil {
+			if err := tl.Unlock(jobID, req.locked); err != nil {
+				log.Warningf("Failed to unlock %d targets after cancellation: %v", len(req.locked), err)
+			}
+		}
+		return nil, target.ErrLockWaitCancelled
+	}
+}
+
+// CheckLocks tells whether all the targets are locked by the given job ID. It
+// also returns the ones that are locked by it, and the ones that are not.
 func (tl *InMemory) CheckLocks(jobID types.JobID, targets []*target.Target) (bool, []*target.Target, []*target.Target) {
 	log.Infof("Checking if %d target(s) are locked by job ID %d", len(targets), jobID)
-	req := newReq(targets)
+	req := newReq(jobID, targets)
 	tl.checkLocksRequests <- req
 	if err := <-req.err; err != nil {
 		// TODO the target.Locker.CheckLocks interface has to change to return an
 		// error as well, because lock checking can fail, e.g. when using
 		// external services.
 		// Just log an error for now.
-		log.Warningf("Error when trying to unlock targets: %v", err)
+		log.Warningf("Error when trying to check target locks: %v", err)
 	}
-	return len(req.notLocked) != len(targets), req.locked, req.notLocked
+	return len(req.notLocked) == 0, req.locked, req.notLocked
 }
 
-// New initializes and returns a new ExampleTestStep.
+// New initializes and returns a new InMemory target locker.
 func New(timeout time.Duration) target.Locker {
-	lockRequests := make(chan request)
-	unlockRequests := make(chan request)
-	checkLocksRequests := make(chan request)
+	lockRequests := make(chan *request)
+	unlockRequests := make(chan *request)
+	checkLocksRequests := make(chan *request)
+	waitRequests := make(chan *request)
+	withdrawRequests := make(chan *request)
 	done := make(chan struct{}, 1)
-	go broker(lockRequests, unlockRequests, checkLocksRequests, done)
+	go broker(lockRequests, unlockRequests, checkLocksRequests, waitRequests, withdrawRequests, done)
 	return &InMemory{
 		lockRequests:       lockRequests,
 		unlockRequests:     unlockRequests,
 		checkLocksRequests: checkLocksRequests,
+		waitRequests:       waitRequests,
+		withdrawRequests:   withdrawRequests,
 		done:               done,
 		timeout:            timeout,
 	}
 }
+
+// Load returns the name and factory which are needed to register the Locker.
+func Load() (string, target.LockerFactory) {
+	return Name, New
+}
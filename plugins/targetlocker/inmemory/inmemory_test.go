@@ -10,10 +10,16 @@ import (
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/target/lockertest"
 	"github.com/facebookincubator/contest/pkg/types"
 	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
 )
 
+func TestInMemoryLockerSuite(t *testing.T) {
+	suite.Run(t, &lockertest.LockerSuite{NewLocker: New})
+}
+
 func TestInMemoryNew(t *testing.T) {
 	tl := New(time.Second)
 	require.NotNil(t, tl)
@@ -20,8 +20,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
@@ -34,11 +36,16 @@ var (
 )
 
 // AcquireParameters contains the parameters necessary to acquire targets.
+// The matching hosts are shuffled, and between MinNumberDevices (at least one)
+// and MaxNumberDevices (no maximum if zero) of them are locked, skipping the
+// ones locked by other jobs. If fewer are available, Acquire waits up to
+// LockWaitTimeout for other jobs to unlock them.
 type AcquireParameters struct {
 	FileURI          *xjson.URL
 	MinNumberDevices uint32
 	MaxNumberDevices uint32
 	HostPrefixes     []string
+	LockWaitTimeout  xjson.Duration
 }
 
 // ReleaseParameters contains the parameters necessary to release targets.
@@ -49,6 +56,11 @@ type ReleaseParameters struct {
 // CSV entries from a text file.
 type CSVFileTargetManager struct {
 	hosts []*target.Target
+	// locker is the Locker the hosts were locked with, used to unlock them
+	// upon Release.
+	locker target.Locker
+	// shuffle shuffles the candidate hosts, see rand.Shuffle.
+	shuffle func(n int, swap func(i, j int))
 }
 
 // ValidateAcquireParameters performs sanity checks on the fields of the
@@ -66,6 +78,12 @@ func (tf CSVFileTargetManager) ValidateAcquireParameters(params []byte) (interfa
 		// reassign after removing surrounding spaces
 		ap.HostPrefixes[idx] = hp
 	}
+	if ap.MaxNumberDevices > 0 && ap.MinNumberDevices > ap.MaxNumberDevices {
+		return nil, fmt.Errorf("MinNumberDevices (%d) cannot be greater than MaxNumberDevices (%d)", ap.MinNumberDevices, ap.MaxNumberDevices)
+	}
+	if ap.LockWaitTimeout < 0 {
+		return nil, fmt.Errorf("LockWaitTimeout cannot be negative")
+	}
 	if ap.FileURI == nil {
 		return nil, fmt.Errorf("file URI not specified in acquire parameters")
 	}
@@ -143,25 +161,56 @@ func (tf *CSVFileTargetManager) Acquire(jobID types.JobID, cancel <-chan struct{
 			len(hosts),
 		)
 	}
-	if uint32(len(hosts)) > acquireParameters.MaxNumberDevices {
-		hosts = hosts[:acquireParameters.MaxNumberDevices]
+	tf.shuffle(len(hosts), func(i, j int) {
+		hosts[i], hosts[j] = hosts[j], hosts[i]
+	})
+	select {
+	case <-cancel:
+		return nil, target.ErrLockWaitCancelled
+	default:
 	}
-
-	if err := tl.Lock(jobID, hosts); err != nil {
-		return nil, fmt.Errorf("failed to lock %d targets: %v", len(hosts), err)
+	minHosts := int(acquireParameters.MinNumberDevices)
+	if minHosts == 0 && len(hosts) > 0 {
+		minHosts = 1
+	}
+	var deadline time.Time
+	if acquireParameters.LockWaitTimeout > 0 {
+		deadline = time.Now().Add(time.Duration(acquireParameters.LockWaitTimeout))
 	}
-	tf.hosts = hosts
-	return hosts, nil
+	locked, err := target.LockWait(tl, jobID, cancel, target.LockRequest{
+		Targets:  hosts,
+		Min:      minHosts,
+		Max:      int(acquireParameters.MaxNumberDevices),
+		Deadline: deadline,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock at least %d targets out of %d: %w", minHosts, len(hosts), err)
+	}
+	tf.hosts = locked
+	tf.locker = tl
+	return locked, nil
 }
 
-// Release releases the acquired resources.
-func (tf *CSVFileTargetManager) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}) error {
+// Release releases the acquired resources, unlocking the acquired hosts which
+// are still locked by the job.
+func (tf *CSVFileTargetManager) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}, results map[*target.Target]error) error {
+	if tf.locker == nil || len(tf.hosts) == 0 {
+		return nil
+	}
+	// some of the hosts may have been unlocked already, and locked by other
+	// jobs since then.
+	_, locked, _ := tf.locker.CheckLocks(jobID, tf.hosts)
+	if err := tf.locker.Unlock(jobID, locked); err != nil {
+		return fmt.Errorf("failed to unlock %d hosts: %v", len(locked), err)
+	}
+	tf.hosts, tf.locker = nil, nil
 	return nil
 }
 
 // New builds a CSVFileTargetManager
 func New() target.TargetManager {
-	return &CSVFileTargetManager{}
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &CSVFileTargetManager{shuffle: rnd.Shuffle}
 }
 
 // Load returns the name and factory which are needed to register the
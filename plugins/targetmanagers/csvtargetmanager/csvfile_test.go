@@ -0,0 +1,147 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package csvtargetmanager
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+const hostsCSV = `compute1.example.com,1
+compute2.example.com,2
+compute3.example.com,3
+storage1.example.com,4
+`
+
+// newManager returns a CSVFileTargetManager reading the hosts from a
+// temporary file, which does not shuffle them, and a function removing the
+// file.
+func newManager(t *testing.T) (*CSVFileTargetManager, string, func()) {
+	fd, err := ioutil.TempFile("", "hosts*.csv")
+	require.NoError(t, err)
+	_, err = fd.WriteString(hostsCSV)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	tm := New().(*CSVFileTargetManager)
+	tm.shuffle = func(n int, swap func(i, j int)) {}
+	return tm, fd.Name(), func() { os.Remove(fd.Name()) }
+}
+
+func acquire(t *testing.T, tm *CSVFileTargetManager, tl target.Locker, jobID types.JobID, cancel <-chan struct{}, params string) ([]*target.Target, error) {
+	ap, err := tm.ValidateAcquireParameters([]byte(params))
+	require.NoError(t, err)
+	return tm.Acquire(jobID, cancel, ap, tl)
+}
+
+func names(targets []*target.Target) []string {
+	var ret []string
+	for _, t := range targets {
+		ret = append(ret, t.Name)
+	}
+	return ret
+}
+
+func TestAcquireMaxNumberDevices(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	targets, err := acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s", "MaxNumberDevices": 2, "HostPrefixes": ["compute"]}`, path))
+	require.NoError(t, err)
+	require.Equal(t, []string{"compute1.example.com", "compute2.example.com"}, names(targets))
+	allLocked, _, _ := tl.CheckLocks(types.JobID(1), targets)
+	require.True(t, allLocked)
+}
+
+func TestAcquireSkipsBusyHosts(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	require.NoError(t, tl.Lock(types.JobID(2), []*target.Target{{Name: "compute1.example.com", ID: "1"}}))
+	targets, err := acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s", "MinNumberDevices": 2}`, path))
+	require.NoError(t, err)
+	require.Equal(t, []string{"compute2.example.com", "compute3.example.com", "storage1.example.com"}, names(targets))
+
+	// not enough hosts are available
+	_, err = acquire(t, tm, tl, types.JobID(3), nil, fmt.Sprintf(`{"FileURI": "%s", "MinNumberDevices": 1}`, path))
+	require.Error(t, err)
+}
+
+func TestAcquireMinNumberDevices(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	_, err := acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s", "MinNumberDevices": 4, "HostPrefixes": ["compute"]}`, path))
+	require.Error(t, err)
+
+	require.NoError(t, tl.Lock(types.JobID(2), []*target.Target{{Name: "compute1.example.com", ID: "1"}}))
+	_, err = acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s", "MinNumberDevices": 3, "HostPrefixes": ["compute"]}`, path))
+	require.Error(t, err)
+	// none of the hosts were left locked
+	allLocked, locked, _ := tl.CheckLocks(types.JobID(1), []*target.Target{{Name: "compute2.example.com", ID: "2"}})
+	require.False(t, allLocked)
+	require.Empty(t, locked)
+}
+
+func TestAcquireShuffles(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	// reverse the hosts
+	tm.shuffle = func(n int, swap func(i, j int)) {
+		for i := 0; i < n/2; i++ {
+			swap(i, n-1-i)
+		}
+	}
+	targets, err := acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s", "MaxNumberDevices": 1}`, path))
+	require.NoError(t, err)
+	require.Equal(t, []string{"storage1.example.com"}, names(targets))
+}
+
+func TestAcquireCancel(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	require.NoError(t, tl.Lock(types.JobID(2), []*target.Target{{Name: "storage1.example.com", ID: "4"}}))
+	cancel := make(chan struct{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(cancel)
+	}()
+	_, err := acquire(t, tm, tl, types.JobID(1), cancel, fmt.Sprintf(`{"FileURI": "%s", "HostPrefixes": ["storage"], "LockWaitTimeout": "1m"}`, path))
+	require.True(t, errors.Is(err, target.ErrLockWaitCancelled), err)
+}
+
+func TestReleaseUnlocks(t *testing.T) {
+	tm, path, cleanup := newManager(t)
+	defer cleanup()
+	tl := inmemory.New(time.Minute)
+
+	targets, err := acquire(t, tm, tl, types.JobID(1), nil, fmt.Sprintf(`{"FileURI": "%s"}`, path))
+	require.NoError(t, err)
+	require.Equal(t, 4, len(targets))
+	// a host was unlocked, and locked by another job in the meantime
+	require.NoError(t, tl.Unlock(types.JobID(1), targets[:1]))
+	require.NoError(t, tl.Lock(types.JobID(2), targets[:1]))
+
+	require.NoError(t, tm.Release(types.JobID(1), nil, ReleaseParameters{}, nil))
+	require.NoError(t, tl.Lock(types.JobID(3), targets[1:]))
+	allLocked, _, _ := tl.CheckLocks(types.JobID(2), targets[:1])
+	require.True(t, allLocked)
+}
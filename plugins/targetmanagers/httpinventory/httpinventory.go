@@ -0,0 +1,435 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package httpinventory implements a target manager that fetches the targets
+// from an inventory served over HTTP, as JSON. Use it as follows in a job
+// descriptor:
+// "TargetManager": "HTTPInventory",
+// "TargetManagerAcquireParameters": {
+//     "URL": "https://inventory.example.com/api/hosts",
+//     "Query": {"sku": "T6", "state": "ready"},
+//     "Headers": {"Authorization": "Bearer secret"},
+//     "TargetsPath": "$.data.hosts",
+//     "Fields": {
+//         "Name": "$.hostname",
+//         "ID": "$.asset_id",
+//         "FQDN": "$.fqdn",
+//         "Attributes": {"bmc_ip": "$.bmc.ip"}
+//     },
+//     "MinNumberDevices": 1,
+//     "MaxNumberDevices": 4,
+//     "Timeout": "30s",
+//     "Retries": 3,
+//     "RetryInterval": "1s",
+//     "LockWaitTimeout": "5m"
+// },
+// "TargetManagerReleaseParameters": {
+//     "URL": "https://inventory.example.com/api/release",
+//     "Headers": {"Authorization": "Bearer secret"}
+// }
+//
+// Acquire GETs the URL with the given query parameters and headers, and maps
+// each item of the array at TargetsPath in the response to a target, with the
+// paths in Fields. Paths are a subset of JSONPath, see parsePath. Between
+// MinNumberDevices (at least one) and MaxNumberDevices (no maximum if zero) of
+// the targets are locked, waiting up to LockWaitTimeout for the ones locked by
+// other jobs. Requests failing with a network error or a 5xx or 429 status are
+// retried.
+//
+// Release unlocks the targets, and POSTs them to the release URL if any, as
+// {"JobID": 1, "Targets": [{"Name": "host1", "ID": "1", ...}], "Passed": [...],
+// "Failed": [...]}, where Passed and Failed are the targets which passed or
+// failed the test, so that the inventory can e.g. send the failed ones to
+// repair. The other targets did not complete the test.
+package httpinventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/logging"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/insomniacslk/xjson"
+)
+
+// Name defined the name of the plugin
+var (
+	Name = "HTTPInventory"
+)
+
+var log = logging.GetLogger("targetmanagers/" + strings.ToLower(Name))
+
+// DefaultTimeout is the default timeout of the HTTP requests.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultRetryInterval is the default interval between the attempts of a
+// failed HTTP request.
+const DefaultRetryInterval = time.Second
+
+// maxResponseSize is the maximum size of the responses of the inventory.
+const maxResponseSize = 64 << 20
+
+// Fields contains the paths of the fields of the targets in the items of the
+// response.
+type Fields struct {
+	Name       string
+	ID         string
+	FQDN       string
+	Attributes map[string]string
+}
+
+// RequestParameters contains the parameters of an HTTP request. A failed
+// request is attempted again up to Retries times.
+type RequestParameters struct {
+	URL           string
+	Headers       map[string]string
+	Timeout       xjson.Duration
+	Retries       uint32
+	RetryInterval xjson.Duration
+}
+
+// AcquireParameters contains the parameters necessary to acquire targets.
+type AcquireParameters struct {
+	RequestParameters
+	Query            map[string]string
+	TargetsPath      string
+	Fields           Fields
+	MinNumberDevices uint32
+	MaxNumberDevices uint32
+	LockWaitTimeout  xjson.Duration
+}
+
+// ReleaseParameters contains the parameters necessary to release targets. If
+// the URL is empty, the targets are only unlocked.
+type ReleaseParameters struct {
+	RequestParameters
+}
+
+// releaseRequest is the body of the release requests.
+type releaseRequest struct {
+	JobID   types.JobID
+	Targets []*target.Target
+	Passed  []*target.Target
+	Failed  []*target.Target
+}
+
+// HTTPInventory implements the contest.TargetManager interface, fetching the
+// targets from an HTTP inventory.
+type HTTPInventory struct {
+	targets []*target.Target
+	// locker is the Locker the targets were locked with, used to unlock them
+	// upon Release.
+	locker target.Locker
+}
+
+// validate checks the request parameters and sets the defaults.
+func (rp *RequestParameters) validate(required bool) error {
+	if rp.URL == "" {
+		if required {
+			return errors.New("URL cannot be empty")
+		}
+		return nil
+	}
+	u, err := url.Parse(rp.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL '%s': %v", rp.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme: '%s', only 'http' and 'https' are accepted", u.Scheme)
+	}
+	if rp.Timeout < 0 {
+		return errors.New("Timeout cannot be negative")
+	}
+	if rp.Timeout == 0 {
+		rp.Timeout = xjson.Duration(DefaultTimeout)
+	}
+	if rp.RetryInterval < 0 {
+		return errors.New("RetryInterval cannot be negative")
+	}
+	if rp.RetryInterval == 0 {
+		rp.RetryInterval = xjson.Duration(DefaultRetryInterval)
+	}
+	return nil
+}
+
+// ValidateAcquireParameters performs sanity checks on the fields of the
+// parameters that will be passed to Acquire.
+func (h HTTPInventory) ValidateAcquireParameters(params []byte) (interface{}, error) {
+	var ap AcquireParameters
+	if err := json.Unmarshal(params, &ap); err != nil {
+		return nil, err
+	}
+	if err := ap.validate(true); err != nil {
+		return nil, err
+	}
+	if ap.Fields.Name == "" {
+		ap.Fields.Name = "Name"
+	}
+	if ap.Fields.ID == "" {
+		ap.Fields.ID = "ID"
+	}
+	paths := []string{ap.TargetsPath, ap.Fields.Name, ap.Fields.ID}
+	if ap.Fields.FQDN != "" {
+		paths = append(paths, ap.Fields.FQDN)
+	}
+	for _, path := range ap.Fields.Attributes {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		if _, err := parsePath(path); err != nil {
+			return nil, err
+		}
+	}
+	if ap.MaxNumberDevices > 0 && ap.MinNumberDevices > ap.MaxNumberDevices {
+		return nil, fmt.Errorf("MinNumberDevices (%d) cannot be greater than MaxNumberDevices (%d)", ap.MinNumberDevices, ap.MaxNumberDevices)
+	}
+	if ap.LockWaitTimeout < 0 {
+		return nil, errors.New("LockWaitTimeout cannot be negative")
+	}
+	return ap, nil
+}
+
+// ValidateReleaseParameters performs sanity checks on the fields of the
+// parameters that will be passed to Release.
+func (h HTTPInventory) ValidateReleaseParameters(params []byte) (interface{}, error) {
+	var rp ReleaseParameters
+	if err := json.Unmarshal(params, &rp); err != nil {
+		return nil, err
+	}
+	if err := rp.validate(false); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// retryable tells whether a request failing with the given status can be
+// retried.
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// do sends a request built by newRequest, retrying it if it fails, and returns
+// the body of the response.
+func do(cancel <-chan struct{}, rp RequestParameters, newRequest func() (*http.Request, error)) ([]byte, error) {
+	client := &http.Client{Timeout: time.Duration(rp.Timeout)}
+	var lastErr error
+	for attempt := uint32(0); attempt <= rp.Retries; attempt++ {
+		if attempt > 0 {
+			log.Warningf("Attempt %d of %d failed: %v", attempt, rp.Retries+1, lastErr)
+			select {
+			case <-cancel:
+				return nil, errors.New("cancelled")
+			case <-time.After(time.Duration(rp.RetryInterval)):
+			}
+		}
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range rp.Headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("could not read response: %v", err)
+			continue
+		}
+		if resp.StatusCode/100 != 2 {
+			// do not leak credentials in the URL
+			u := *req.URL
+			u.User = nil
+			lastErr = fmt.Errorf("%s %s: unexpected status %s: %s", req.Method, u.String(), resp.Status, strings.TrimSpace(string(body)))
+			if !retryable(resp.StatusCode) {
+				return nil, lastErr
+			}
+			continue
+		}
+		return body, nil
+	}
+	return nil, lastErr
+}
+
+// parseTargets maps the items of the array at the targets path of the response
+// to targets.
+func parseTargets(body []byte, targetsPath string, fields Fields) ([]*target.Target, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %v", err)
+	}
+	items, err := lookup(doc, targetsPath)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := items.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value at path '%s' is not an array: %T", targetsPath, items)
+	}
+	targets := make([]*target.Target, 0, len(list))
+	for idx, item := range list {
+		var (
+			t   target.Target
+			err error
+		)
+		if t.Name, err = lookupString(item, fields.Name); err != nil {
+			return nil, fmt.Errorf("target #%d: %v", idx, err)
+		}
+		if t.ID, err = lookupString(item, fields.ID); err != nil {
+			return nil, fmt.Errorf("target #%d: %v", idx, err)
+		}
+		if t.Name == "" || t.ID == "" {
+			return nil, fmt.Errorf("target #%d: invalid empty string for name or ID", idx)
+		}
+		if fields.FQDN != "" {
+			if t.FQDN, err = lookupString(item, fields.FQDN); err != nil {
+				return nil, fmt.Errorf("target #%d: %v", idx, err)
+			}
+		}
+		for name, path := range fields.Attributes {
+			value, err := lookupString(item, path)
+			if err != nil {
+				return nil, fmt.Errorf("target #%d: attribute '%s': %v", idx, name, err)
+			}
+			if t.Attributes == nil {
+				t.Attributes = make(map[string]string, len(fields.Attributes))
+			}
+			t.Attributes[name] = value
+		}
+		targets = append(targets, &t)
+	}
+	return targets, nil
+}
+
+// Acquire implements contest.TargetManager.Acquire, fetching the targets from
+// the inventory and locking them.
+func (h *HTTPInventory) Acquire(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl target.Locker) ([]*target.Target, error) {
+	acquireParameters, ok := parameters.(AcquireParameters)
+	if !ok {
+		return nil, fmt.Errorf("Acquire expects %T object, got %T", acquireParameters, parameters)
+	}
+	body, err := do(cancel, acquireParameters.RequestParameters, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, acquireParameters.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		query := req.URL.Query()
+		for name, value := range acquireParameters.Query {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch targets: %v", err)
+	}
+	candidates, err := parseTargets(body, acquireParameters.TargetsPath, acquireParameters.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse targets: %v", err)
+	}
+	if len(candidates) == 0 || uint32(len(candidates)) < acquireParameters.MinNumberDevices {
+		return nil, fmt.Errorf("not enough targets in the inventory, want %d, got %d", acquireParameters.MinNumberDevices, len(candidates))
+	}
+
+	minTargets := int(acquireParameters.MinNumberDevices)
+	if minTargets == 0 {
+		minTargets = 1
+	}
+	var deadline time.Time
+	if acquireParameters.LockWaitTimeout > 0 {
+		deadline = time.Now().Add(time.Duration(acquireParameters.LockWaitTimeout))
+	}
+	locked, err := target.LockWait(tl, jobID, cancel, target.LockRequest{
+		Targets:  candidates,
+		Min:      minTargets,
+		Max:      int(acquireParameters.MaxNumberDevices),
+		Deadline: deadline,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock at least %d targets out of %d: %w", minTargets, len(candidates), err)
+	}
+	h.targets = locked
+	h.locker = tl
+	log.Infof("Acquired %d targets out of %d", len(locked), len(candidates))
+	return locked, nil
+}
+
+// Release releases the acquired resources, unlocking the targets which are
+// still locked by the job, and notifying the inventory if configured.
+func (h *HTTPInventory) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}, results map[*target.Target]error) error {
+	releaseParameters, ok := params.(ReleaseParameters)
+	if !ok {
+		return fmt.Errorf("Release expects %T object, got %T", releaseParameters, params)
+	}
+	if h.locker == nil || len(h.targets) == 0 {
+		return nil
+	}
+	targets := h.targets
+	// some of the targets may have been unlocked already, and locked by other
+	// jobs since then.
+	_, locked, _ := h.locker.CheckLocks(jobID, targets)
+	if err := h.locker.Unlock(jobID, locked); err != nil {
+		return fmt.Errorf("failed to unlock %d targets: %v", len(locked), err)
+	}
+	h.targets, h.locker = nil, nil
+	if releaseParameters.URL == "" {
+		return nil
+	}
+	body := releaseRequest{JobID: jobID, Targets: targets}
+	for _, t := range targets {
+		err, done := results[t]
+		switch {
+		case !done:
+		case err == nil:
+			body.Passed = append(body.Passed, t)
+		default:
+			body.Failed = append(body.Failed, t)
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("could not serialize release request: %v", err)
+	}
+	_, err = do(cancel, releaseParameters.RequestParameters, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, releaseParameters.URL, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not release targets: %v", err)
+	}
+	log.Infof("Released %d targets, %d passed and %d failed", len(targets), len(body.Passed), len(body.Failed))
+	return nil
+}
+
+// New builds a new HTTPInventory object.
+func New() target.TargetManager {
+	return &HTTPInventory{}
+}
+
+// Load returns the name and factory which are needed to register the
+// TargetManager.
+func Load() (string, target.TargetManagerFactory) {
+	return Name, New
+}
@@ -0,0 +1,198 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httpinventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hostsResponse = `{
+	"data": {
+		"hosts": [
+			{"hostname": "host1", "asset_id": 1, "fqdn": "host1.example.com", "bmc": {"ip": "10.0.0.1"}},
+			{"hostname": "host2", "asset_id": 2, "fqdn": "host2.example.com", "bmc": {"ip": "10.0.0.2"}}
+		]
+	}
+}`
+
+const acquireParams = `{
+	"URL": "%s/hosts",
+	"Query": {"sku": "T6"},
+	"Headers": {"Authorization": "Bearer secret"},
+	"TargetsPath": "$.data.hosts",
+	"Fields": {
+		"Name": "$.hostname",
+		"ID": "$.asset_id",
+		"FQDN": "fqdn",
+		"Attributes": {"bmc_ip": "$.bmc.ip"}
+	},
+	"Retries": 2,
+	"RetryInterval": "10ms"
+	%s
+}`
+
+func acquire(t *testing.T, tm target.TargetManager, tl target.Locker, jobID types.JobID, params string) ([]*target.Target, error) {
+	ap, err := tm.ValidateAcquireParameters([]byte(params))
+	require.NoError(t, err)
+	return tm.Acquire(jobID, make(chan struct{}), ap, tl)
+}
+
+func release(t *testing.T, tm target.TargetManager, params string, results map[*target.Target]error) error {
+	rp, err := tm.ValidateReleaseParameters([]byte(params))
+	require.NoError(t, err)
+	return tm.Release(types.JobID(1), make(chan struct{}), rp, results)
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	var released releaseRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/hosts":
+			assert.Equal(t, "T6", r.URL.Query().Get("sku"))
+			fmt.Fprint(w, hostsResponse)
+		case "/release":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&released))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	tm := New()
+	tl := inmemory.New(time.Minute)
+
+	targets, err := acquire(t, tm, tl, types.JobID(1), fmt.Sprintf(acquireParams, srv.URL, ""))
+	require.NoError(t, err)
+	require.Equal(t, []*target.Target{
+		{Name: "host1", ID: "1", FQDN: "host1.example.com", Attributes: map[string]string{"bmc_ip": "10.0.0.1"}},
+		{Name: "host2", ID: "2", FQDN: "host2.example.com", Attributes: map[string]string{"bmc_ip": "10.0.0.2"}},
+	}, targets)
+	allLocked, _, _ := tl.CheckLocks(types.JobID(1), targets)
+	require.True(t, allLocked)
+
+	results := map[*target.Target]error{targets[0]: errors.New("test failed")}
+	require.NoError(t, release(t, tm, fmt.Sprintf(`{"URL": "%s/release", "Headers": {"Authorization": "Bearer secret"}}`, srv.URL), results))
+	require.Equal(t, types.JobID(1), released.JobID)
+	require.Equal(t, targets, released.Targets)
+	require.Equal(t, targets[:1], released.Failed)
+	require.Empty(t, released.Passed)
+	allLocked, _, _ = tl.CheckLocks(types.JobID(1), targets)
+	require.False(t, allLocked)
+}
+
+func TestAcquireMaxNumberDevices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, hostsResponse)
+	}))
+	defer srv.Close()
+	tl := inmemory.New(time.Minute)
+
+	// host1 is locked by another job
+	require.NoError(t, tl.Lock(types.JobID(2), []*target.Target{{Name: "host1", ID: "1", FQDN: "host1.example.com"}}))
+	targets, err := acquire(t, New(), tl, types.JobID(1), fmt.Sprintf(acquireParams, srv.URL, `, "MaxNumberDevices": 1`))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets))
+	require.Equal(t, "host2", targets[0].Name)
+
+	// all the targets are locked
+	_, err = acquire(t, New(), tl, types.JobID(3), fmt.Sprintf(acquireParams, srv.URL, `, "MinNumberDevices": 1`))
+	require.Error(t, err)
+}
+
+func TestAcquireRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, hostsResponse)
+	}))
+	defer srv.Close()
+
+	targets, err := acquire(t, New(), inmemory.New(time.Minute), types.JobID(1), fmt.Sprintf(acquireParams, srv.URL, ""))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestAcquireDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, err := acquire(t, New(), inmemory.New(time.Minute), types.JobID(1), fmt.Sprintf(acquireParams, srv.URL, ""))
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	_, err := acquire(t, New(), inmemory.New(time.Minute), types.JobID(1), fmt.Sprintf(acquireParams, srv.URL, `, "Timeout": "50ms", "Retries": 0`))
+	require.Error(t, err)
+}
+
+func TestValidateAcquireParameters(t *testing.T) {
+	for _, params := range []string{
+		`{}`,
+		`{"URL": "ftp://inventory.example.com"}`,
+		`{"URL": "http://inventory.example.com", "TargetsPath": "$.hosts[x]"}`,
+		`{"URL": "http://inventory.example.com", "Fields": {"Name": "$..name"}}`,
+		`{"URL": "http://inventory.example.com", "MinNumberDevices": 2, "MaxNumberDevices": 1}`,
+		`{"URL": "http://inventory.example.com", "Timeout": "-1s"}`,
+	} {
+		_, err := New().ValidateAcquireParameters([]byte(params))
+		require.Error(t, err, params)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": [{"c": "x"}, {"c": true}]}}`), &doc))
+
+	value, err := lookupString(doc, "$.a.b[0].c")
+	require.NoError(t, err)
+	require.Equal(t, "x", value)
+	value, err = lookupString(doc, "a.b[1].c")
+	require.NoError(t, err)
+	require.Equal(t, "true", value)
+
+	_, err = lookupString(doc, "$.a.b")
+	require.Error(t, err)
+	_, err = lookup(doc, "$.a.b[2]")
+	require.Error(t, err)
+	_, err = lookup(doc, "$.a.d")
+	require.Error(t, err)
+}
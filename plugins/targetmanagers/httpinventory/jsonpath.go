@@ -0,0 +1,112 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package httpinventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is a single selector of a path: either a field of an object, or an
+// item of an array.
+type step struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// parsePath parses a path into its selectors. Paths are a subset of JSONPath:
+// "$" is the document itself, followed by any number of ".field" and "[index]"
+// selectors, e.g. "$.data.hosts[0].name". The leading "$." can be omitted.
+func parsePath(path string) ([]step, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+	var steps []step
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path '%s': empty field name", path)
+			}
+			steps = append(steps, step{field: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ']'", path)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid path '%s': invalid index '%s'", path, rest[1:end])
+			}
+			steps = append(steps, step{index: idx, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path '%s'", path)
+		}
+	}
+	return steps, nil
+}
+
+// lookup returns the value at the given path in a decoded JSON document, see
+// parsePath.
+func lookup(doc interface{}, path string) (interface{}, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	v := doc
+	for _, s := range steps {
+		if s.isIndex {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot get item %d of %T in path '%s'", s.index, v, path)
+			}
+			if s.index >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range in path '%s'", s.index, path)
+			}
+			v = arr[s.index]
+			continue
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot get field '%s' of %T in path '%s'", s.field, v, path)
+		}
+		if v, ok = obj[s.field]; !ok {
+			return nil, fmt.Errorf("no field '%s' in path '%s'", s.field, path)
+		}
+	}
+	return v, nil
+}
+
+// lookupString returns the scalar value at the given path as a string. The
+// document must be decoded with json.Decoder.UseNumber.
+func lookupString(doc interface{}, path string) (string, error) {
+	v, err := lookup(doc, path)
+	if err != nil {
+		return "", err
+	}
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("value at path '%s' is not a scalar: %T", path, v)
+}
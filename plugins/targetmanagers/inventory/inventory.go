@@ -0,0 +1,275 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package inventory implements a target manager that selects targets from the
+// target inventory of the ConTest server, by their attributes. Use it as
+// follows in a job descriptor:
+// "TargetManager": "Inventory",
+// "TargetManagerAcquireParameters": {
+//     "Selector": "sku=T6 && rack in (A1,A2)",
+//     "Count": 4,
+//     "LockWaitTimeout": "5m",
+//     "StreamInterval": "1m",
+//     "StreamDuration": "12h"
+// },
+// "TargetManagerReleaseParameters": {
+//     "PassedAttributes": {"state": "ready"},
+//     "FailedAttributes": {"state": "repair"}
+// }
+//
+// Count targets among the ones matching the selector are locked, or all of
+// them if Count is zero. If fewer are available, Acquire waits up to
+// LockWaitTimeout for other jobs to unlock them. The attributes of the
+// targets can be used in the parameters of the test steps, e.g.
+// {{ .Attr "bmc_ip" }}. See the selector package for the syntax of selectors.
+//
+// If StreamInterval is set, the inventory is checked every StreamInterval
+// while the test runs, and the targets matching the selector which became
+// available join the test. Targets keep joining for StreamDuration, or until
+// the job ends if it is zero, and the test completes afterwards.
+//
+// Release sets the PassedAttributes on the targets which passed the test, and
+// the FailedAttributes on the ones which failed it, in the inventory, e.g. to
+// move the failed targets out of the pool the job selected them from.
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/lib/selector"
+	"github.com/facebookincubator/contest/pkg/logging"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/insomniacslk/xjson"
+)
+
+// Name defined the name of the plugin
+var (
+	Name = "Inventory"
+)
+
+var log = logging.GetLogger("targetmanagers/" + strings.ToLower(Name))
+
+// AcquireParameters contains the parameters necessary to acquire targets.
+type AcquireParameters struct {
+	Selector        string
+	Count           uint32
+	LockWaitTimeout xjson.Duration
+	StreamInterval  xjson.Duration
+	StreamDuration  xjson.Duration
+}
+
+// ReleaseParameters contains the parameters necessary to release targets.
+type ReleaseParameters struct {
+	PassedAttributes map[string]string
+	FailedAttributes map[string]string
+}
+
+// Inventory implements the contest.TargetManager interface, selecting targets
+// from the target inventory.
+type Inventory struct {
+	targets []*target.Target
+}
+
+// ValidateAcquireParameters performs sanity checks on the fields of the
+// parameters that will be passed to Acquire.
+func (i Inventory) ValidateAcquireParameters(params []byte) (interface{}, error) {
+	var ap AcquireParameters
+	if err := json.Unmarshal(params, &ap); err != nil {
+		return nil, err
+	}
+	if _, err := selector.Parse(ap.Selector); err != nil {
+		return nil, err
+	}
+	if ap.LockWaitTimeout < 0 {
+		return nil, errors.New("LockWaitTimeout cannot be negative")
+	}
+	if ap.StreamInterval < 0 || ap.StreamDuration < 0 {
+		return nil, errors.New("StreamInterval and StreamDuration cannot be negative")
+	}
+	if ap.StreamDuration > 0 && ap.StreamInterval == 0 {
+		return nil, errors.New("StreamDuration requires StreamInterval")
+	}
+	return ap, nil
+}
+
+// ValidateReleaseParameters performs sanity checks on the fields of the
+// parameters that will be passed to Release.
+func (i Inventory) ValidateReleaseParameters(params []byte) (interface{}, error) {
+	var rp ReleaseParameters
+	if err := json.Unmarshal(params, &rp); err != nil {
+		return nil, err
+	}
+	for _, attrs := range []map[string]string{rp.PassedAttributes, rp.FailedAttributes} {
+		for name := range attrs {
+			if name == "" {
+				return nil, errors.New("attribute names cannot be empty")
+			}
+		}
+	}
+	return rp, nil
+}
+
+// Acquire implements contest.TargetManager.Acquire, locking the requested
+// number of targets among the ones of the inventory matching the selector.
+func (i *Inventory) Acquire(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl target.Locker) ([]*target.Target, error) {
+	acquireParameters, ok := parameters.(AcquireParameters)
+	if !ok {
+		return nil, fmt.Errorf("Acquire expects %T object, got %T", acquireParameters, parameters)
+	}
+	sel, err := selector.Parse(acquireParameters.Selector)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := storage.NewTargetInventory().Select(sel)
+	if err != nil {
+		return nil, err
+	}
+	count := int(acquireParameters.Count)
+	if len(candidates) == 0 || len(candidates) < count {
+		return nil, fmt.Errorf("not enough targets matching selector '%s' in the inventory, want %d, got %d", sel, count, len(candidates))
+	}
+
+	var deadline time.Time
+	if acquireParameters.LockWaitTimeout > 0 {
+		deadline = time.Now().Add(time.Duration(acquireParameters.LockWaitTimeout))
+	}
+	locked, err := target.LockWait(tl, jobID, cancel, target.LockRequest{
+		Targets:  candidates,
+		Min:      count,
+		Max:      count,
+		Deadline: deadline,
+	})
+	if err != nil {
+		log.Warningf("Failed to lock targets matching selector '%s': %v", sel, err)
+		return nil, err
+	}
+	i.targets = locked
+	log.Infof("Acquired %d targets out of %d matching selector '%s'", len(locked), len(candidates), sel)
+	return locked, nil
+}
+
+// Stream implements target.TargetStreamer, locking the targets matching the
+// selector which become available while the test runs, if StreamInterval is
+// set.
+func (i *Inventory) Stream(jobID types.JobID, cancel <-chan struct{}, parameters interface{}, tl target.Locker, targets chan<- *target.Target) error {
+	acquireParameters, ok := parameters.(AcquireParameters)
+	if !ok {
+		return fmt.Errorf("Stream expects %T object, got %T", acquireParameters, parameters)
+	}
+	if acquireParameters.StreamInterval == 0 {
+		return nil
+	}
+	sel, err := selector.Parse(acquireParameters.Selector)
+	if err != nil {
+		return err
+	}
+	var end <-chan time.Time
+	if acquireParameters.StreamDuration > 0 {
+		end = time.After(time.Duration(acquireParameters.StreamDuration))
+	}
+	for {
+		select {
+		case <-cancel:
+			return nil
+		case <-end:
+			return nil
+		case <-time.After(time.Duration(acquireParameters.StreamInterval)):
+		}
+		candidates, err := storage.NewTargetInventory().Select(sel)
+		if err != nil {
+			log.Warningf("Failed to select targets matching selector '%s': %v", sel, err)
+			continue
+		}
+		acquired := make(map[string]bool, len(i.targets))
+		for _, t := range i.targets {
+			acquired[t.ID] = true
+		}
+		var fresh []*target.Target
+		for _, t := range candidates {
+			if !acquired[t.ID] {
+				fresh = append(fresh, t)
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		locked, err := target.LockWait(tl, jobID, cancel, target.LockRequest{Targets: fresh, Min: 1})
+		if err != nil {
+			log.Debugf("No new target matching selector '%s' could be locked: %v", sel, err)
+			continue
+		}
+		i.targets = append(i.targets, locked...)
+		for idx, t := range locked {
+			select {
+			case targets <- t:
+			case <-cancel:
+				// the remaining targets never joined the test
+				if err := tl.Unlock(jobID, locked[idx:]); err != nil {
+					log.Warningf("Failed to unlock %d targets: %v", len(locked[idx:]), err)
+				}
+				i.targets = i.targets[:len(i.targets)-len(locked[idx:])]
+				return nil
+			}
+		}
+		log.Infof("%d new targets matching selector '%s' joined the test", len(locked), sel)
+	}
+}
+
+// Release releases the acquired resources, updating the attributes of the
+// targets in the inventory according to their results.
+func (i *Inventory) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}, results map[*target.Target]error) error {
+	releaseParameters, ok := params.(ReleaseParameters)
+	if !ok {
+		return fmt.Errorf("Release expects %T object, got %T", releaseParameters, params)
+	}
+	var updated []*target.Target
+	for _, t := range i.targets {
+		err, done := results[t]
+		if !done {
+			continue
+		}
+		attrs := releaseParameters.PassedAttributes
+		if err != nil {
+			attrs = releaseParameters.FailedAttributes
+		}
+		if len(attrs) == 0 {
+			continue
+		}
+		u := *t
+		u.Attributes = make(map[string]string, len(t.Attributes)+len(attrs))
+		for name, value := range t.Attributes {
+			u.Attributes[name] = value
+		}
+		for name, value := range attrs {
+			u.Attributes[name] = value
+		}
+		updated = append(updated, &u)
+	}
+	if len(updated) > 0 {
+		if err := storage.NewTargetInventory().Store(updated); err != nil {
+			return fmt.Errorf("failed to update %d targets: %v", len(updated), err)
+		}
+	}
+	log.Infof("Released %d targets, updated %d of them", len(i.targets), len(updated))
+	i.targets = nil
+	return nil
+}
+
+// New builds a new Inventory object.
+func New() target.TargetManager {
+	return &Inventory{}
+}
+
+// Load returns the name and factory which are needed to register the
+// TargetManager.
+func Load() (string, target.TargetManagerFactory) {
+	return Name, New
+}
@@ -0,0 +1,153 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package inventory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/lib/selector"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/storage/memory"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInventory(t *testing.T) {
+	storage.SetStorage(memory.New())
+	require.NoError(t, storage.NewTargetInventory().Store([]*target.Target{
+		{Name: "host1", ID: "1", Attributes: map[string]string{"sku": "T6", "rack": "A1", "bmc_ip": "10.0.0.1"}},
+		{Name: "host2", ID: "2", Attributes: map[string]string{"sku": "T6", "rack": "A2", "bmc_ip": "10.0.0.2"}},
+		{Name: "host3", ID: "3", Attributes: map[string]string{"sku": "T6", "rack": "B1", "bmc_ip": "10.0.0.3"}},
+		{Name: "host4", ID: "4", Attributes: map[string]string{"sku": "T7", "rack": "A1", "bmc_ip": "10.0.0.4"}},
+	}))
+}
+
+func acquire(t *testing.T, tl target.Locker, jobID types.JobID, params string) ([]*target.Target, error) {
+	tm := New()
+	ap, err := tm.ValidateAcquireParameters([]byte(params))
+	require.NoError(t, err)
+	return tm.Acquire(jobID, make(chan struct{}), ap, tl)
+}
+
+func TestAcquireSelectsTargets(t *testing.T) {
+	setupInventory(t)
+	tl := inmemory.New(time.Minute)
+
+	targets, err := acquire(t, tl, types.JobID(1), `{"Selector": "sku=T6 && rack in (A1,A2)"}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+	require.Equal(t, "host1", targets[0].Name)
+	require.Equal(t, "host2", targets[1].Name)
+	bmcIP, err := targets[1].Attr("bmc_ip")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2", bmcIP)
+	allLocked, _, _ := tl.CheckLocks(types.JobID(1), targets)
+	require.True(t, allLocked)
+}
+
+func TestAcquireCount(t *testing.T) {
+	setupInventory(t)
+	tl := inmemory.New(time.Minute)
+
+	// host1 is locked by another job
+	require.NoError(t, tl.Lock(types.JobID(2), []*target.Target{{Name: "host1", ID: "1"}}))
+	targets, err := acquire(t, tl, types.JobID(1), `{"Selector": "sku=T6", "Count": 2}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+	require.Equal(t, "host2", targets[0].Name)
+	require.Equal(t, "host3", targets[1].Name)
+}
+
+func TestAcquireNotEnoughTargets(t *testing.T) {
+	setupInventory(t)
+	tl := inmemory.New(time.Minute)
+
+	_, err := acquire(t, tl, types.JobID(1), `{"Selector": "sku=T7", "Count": 2}`)
+	require.Error(t, err)
+	_, err = acquire(t, tl, types.JobID(1), `{"Selector": "sku=T8"}`)
+	require.Error(t, err)
+}
+
+func TestValidateAcquireParameters(t *testing.T) {
+	tm := New()
+	_, err := tm.ValidateAcquireParameters([]byte(`{"Selector": "sku=T6 ||"}`))
+	require.Error(t, err)
+	_, err = tm.ValidateAcquireParameters([]byte(`{"Selector": "sku=T6", "LockWaitTimeout": "-1s"}`))
+	require.Error(t, err)
+	_, err = tm.ValidateAcquireParameters([]byte(`{"Selector": "sku=T6", "StreamDuration": "1h"}`))
+	require.Error(t, err)
+}
+
+func TestStreamLocksAvailableTargets(t *testing.T) {
+	setupInventory(t)
+	tl := inmemory.New(time.Minute)
+	tm := New()
+
+	// host1 is locked by another job, and becomes available later
+	host1 := []*target.Target{{Name: "host1", ID: "1"}}
+	require.NoError(t, tl.Lock(types.JobID(2), host1))
+	ap, err := tm.ValidateAcquireParameters([]byte(`{"Selector": "sku=T6", "Count": 2, "StreamInterval": "10ms"}`))
+	require.NoError(t, err)
+	targets, err := tm.Acquire(types.JobID(1), make(chan struct{}), ap, tl)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+
+	cancel := make(chan struct{})
+	streamed := make(chan *target.Target)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tm.(target.TargetStreamer).Stream(types.JobID(1), cancel, ap, tl, streamed)
+	}()
+	require.NoError(t, tl.Unlock(types.JobID(2), host1))
+	select {
+	case joined := <-streamed:
+		require.Equal(t, "host1", joined.Name)
+		allLocked, _, _ := tl.CheckLocks(types.JobID(1), []*target.Target{joined})
+		require.True(t, allLocked)
+	case <-time.After(5 * time.Second):
+		t.Fatal("host1 was not streamed")
+	}
+	close(cancel)
+	require.NoError(t, <-errCh)
+}
+
+func TestReleaseUpdatesAttributes(t *testing.T) {
+	setupInventory(t)
+	tl := inmemory.New(time.Minute)
+	tm := New()
+	ap, err := tm.ValidateAcquireParameters([]byte(`{"Selector": "sku=T6"}`))
+	require.NoError(t, err)
+	targets, err := tm.Acquire(types.JobID(1), make(chan struct{}), ap, tl)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(targets))
+
+	rp, err := tm.ValidateReleaseParameters([]byte(`{"PassedAttributes": {"state": "ready"}, "FailedAttributes": {"state": "repair"}}`))
+	require.NoError(t, err)
+	// host3 did not complete the test
+	results := map[*target.Target]error{targets[0]: nil, targets[1]: errors.New("test failed")}
+	require.NoError(t, tm.Release(types.JobID(1), make(chan struct{}), rp, results))
+
+	inventory := storage.NewTargetInventory()
+	for sel, name := range map[string]string{"state=ready": "host1", "state=repair": "host2"} {
+		parsed, err := selector.Parse(sel)
+		require.NoError(t, err)
+		selected, err := inventory.Select(parsed)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(selected))
+		require.Equal(t, name, selected[0].Name)
+		require.Equal(t, "T6", selected[0].Attributes["sku"])
+	}
+}
+
+func TestValidateReleaseParameters(t *testing.T) {
+	tm := New()
+	_, err := tm.ValidateReleaseParameters([]byte(`{"FailedAttributes": {"": "repair"}}`))
+	require.Error(t, err)
+}
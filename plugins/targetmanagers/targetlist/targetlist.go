@@ -16,9 +16,13 @@
 //             "Name": "hostname2.example.com",
 //             "ID": "id2"
 //         }
-// ]
+// ],
+//     "LockWaitTimeout": "5m"
 // }
 //
+// If some of the targets are locked by other jobs, Acquire waits up to
+// LockWaitTimeout for them to be unlocked.
+//
 // hostname1.example.com,1.2.3.4
 // hostname2,2001:db8::1
 //
@@ -32,10 +36,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/facebookincubator/contest/pkg/logging"
 	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/insomniacslk/xjson"
 )
 
 // Name defined the name of the plugin
@@ -47,7 +53,8 @@ var log = logging.GetLogger("targetmanagers/" + strings.ToLower(Name))
 
 // AcquireParameters contains the parameters necessary to acquire targets.
 type AcquireParameters struct {
-	Targets []*target.Target
+	Targets         []*target.Target
+	LockWaitTimeout xjson.Duration
 }
 
 // ReleaseParameters contains the parameters necessary to release targets.
@@ -71,6 +78,9 @@ func (t TargetList) ValidateAcquireParameters(params []byte) (interface{}, error
 			return nil, errors.New("invalid target with empty name")
 		}
 	}
+	if ap.LockWaitTimeout < 0 {
+		return nil, errors.New("LockWaitTimeout cannot be negative")
+	}
 	return ap, nil
 }
 
@@ -92,7 +102,15 @@ func (t *TargetList) Acquire(jobID types.JobID, cancel <-chan struct{}, paramete
 		return nil, fmt.Errorf("Acquire expects %T object, got %T", acquireParameters, parameters)
 	}
 
-	if err := tl.Lock(jobID, acquireParameters.Targets); err != nil {
+	var deadline time.Time
+	if acquireParameters.LockWaitTimeout > 0 {
+		deadline = time.Now().Add(time.Duration(acquireParameters.LockWaitTimeout))
+	}
+	_, err := target.LockWait(tl, jobID, cancel, target.LockRequest{
+		Targets:  acquireParameters.Targets,
+		Deadline: deadline,
+	})
+	if err != nil {
 		log.Warningf("Failed to lock %d targets: %v", len(acquireParameters.Targets), err)
 		return nil, err
 	}
@@ -102,8 +120,14 @@ func (t *TargetList) Acquire(jobID types.JobID, cancel <-chan struct{}, paramete
 }
 
 // Release releases the acquired resources.
-func (t *TargetList) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}) error {
-	log.Infof("Released %d targets", len(t.targets))
+func (t *TargetList) Release(jobID types.JobID, cancel <-chan struct{}, params interface{}, results map[*target.Target]error) error {
+	var failed int
+	for _, err := range results {
+		if err != nil {
+			failed++
+		}
+	}
+	log.Infof("Released %d targets, %d of which failed", len(t.targets), failed)
 	return nil
 }
 
@@ -110,7 +110,7 @@ processing:
 				case <-pause:
 					log.Debug("Returning because pause is requested")
 					return
-				case <-time.After(sleep * time.Second):
+				case <-time.After(sleep):
 				}
 				log.Infof("target %s: %s", t, params.GetOne("text"))
 				select {
@@ -12,7 +12,9 @@ import (
 	"github.com/facebookincubator/contest/plugins/storage/rdbms"
 )
 
+// DBURI is the URI of the database of the integration tests.
+const DBURI = "contest:contest@tcp(mysql:3306)/contest_integ?parseTime=true"
+
 func NewStorage(opts ...rdbms.Opt) storage.Storage {
-	dbURI := "contest:contest@tcp(mysql:3306)/contest_integ?parseTime=true"
-	return rdbms.New(dbURI, opts...)
+	return rdbms.New(DBURI, opts...)
 }
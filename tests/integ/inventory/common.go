@@ -0,0 +1,89 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build integration integration_storage
+
+package test
+
+import (
+	"github.com/facebookincubator/contest/pkg/lib/selector"
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type InventorySuite struct {
+	suite.Suite
+	storage storage.Storage
+}
+
+func (suite *InventorySuite) TearDownTest() {
+	suite.storage.Reset()
+}
+
+func (suite *InventorySuite) selectIDs(expr string) []string {
+	sel, err := selector.Parse(expr)
+	require.NoError(suite.T(), err)
+	targets, err := storage.NewTargetInventory().Select(sel)
+	require.NoError(suite.T(), err)
+	var ids []string
+	for _, t := range targets {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func (suite *InventorySuite) TestStoreAndSelectTargets() {
+	inventory := storage.NewTargetInventory()
+	err := inventory.Store([]*target.Target{
+		{Name: "host1", ID: "1", FQDN: "host1.example.com", Attributes: map[string]string{"sku": "T6", "rack": "A1"}},
+		{Name: "host2", ID: "2", Attributes: map[string]string{"sku": "T6", "rack": "B1"}},
+		{Name: "host3", ID: "3"},
+	})
+	require.NoError(suite.T(), err)
+
+	require.Equal(suite.T(), []string{"1", "2", "3"}, suite.selectIDs(""))
+	require.Equal(suite.T(), []string{"1"}, suite.selectIDs("sku=T6 && rack in (A1,A2)"))
+	require.Equal(suite.T(), []string{"3"}, suite.selectIDs("!sku"))
+
+	targets, err := inventory.Select(selector.Selector{})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &target.Target{Name: "host1", ID: "1", FQDN: "host1.example.com", Attributes: map[string]string{"sku": "T6", "rack": "A1"}}, targets[0])
+}
+
+func (suite *InventorySuite) TestReplaceTargets() {
+	inventory := storage.NewTargetInventory()
+	err := inventory.Store([]*target.Target{
+		{Name: "host1", ID: "1", Attributes: map[string]string{"sku": "T6", "rack": "A1"}},
+	})
+	require.NoError(suite.T(), err)
+	// the attributes of the target are replaced, not merged
+	err = inventory.Store([]*target.Target{
+		{Name: "host1", ID: "1", Attributes: map[string]string{"sku": "T7"}},
+	})
+	require.NoError(suite.T(), err)
+
+	require.Equal(suite.T(), []string{"1"}, suite.selectIDs("sku=T7 && !rack"))
+	require.Empty(suite.T(), suite.selectIDs("sku=T6"))
+}
+
+func (suite *InventorySuite) TestDeleteTargets() {
+	inventory := storage.NewTargetInventory()
+	err := inventory.Store([]*target.Target{
+		{Name: "host1", ID: "1", Attributes: map[string]string{"sku": "T6"}},
+		{Name: "host2", ID: "2", Attributes: map[string]string{"sku": "T6"}},
+	})
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), inventory.Delete([]string{"1"}))
+
+	require.Equal(suite.T(), []string{"2"}, suite.selectIDs("sku=T6"))
+}
+
+func (suite *InventorySuite) TestStoreRequiresTargetID() {
+	err := storage.NewTargetInventory().Store([]*target.Target{{Name: "host1"}})
+	require.Error(suite.T(), err)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build integration_storage
+
+package test
+
+import (
+	"testing"
+
+	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/tests/integ/common"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestInventorySuiteRdbmsStorage(t *testing.T) {
+	testSuite := InventorySuite{}
+
+	storageLayer := common.NewStorage()
+	storage.SetStorage(storageLayer)
+	testSuite.storage = storageLayer
+
+	suite.Run(t, &testSuite)
+}
@@ -10,6 +10,8 @@ package test
 import (
 	"time"
 
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/storage"
 	"github.com/facebookincubator/contest/pkg/types"
@@ -121,3 +123,110 @@ func (suite *JobSuite) TestGetJobRequest() {
 	require.True(suite.T(), request.RequestTime.Before(time.Now().Add(2*time.Second)))
 
 }
+
+var stateEvents = []event.Name{"JobStateStarted", "JobStateCompleted", "JobStateFailed"}
+
+// populateJobList stores four jobs, with different names, tags, requestors,
+// request times and states.
+func populateJobList(backend storage.Storage, now time.Time) error {
+	jobs := []struct {
+		name      string
+		tags      []string
+		requestor string
+		age       time.Duration
+		states    []event.Name
+	}{
+		{"nightly-a", []string{"x86", "nightly"}, "alice", 4 * time.Hour, []event.Name{"JobStateStarted", "JobStateCompleted"}},
+		{"nightly-b", []string{"nightly", "arm", "nightly"}, "bob", 3 * time.Hour, []event.Name{"JobStateStarted", "JobStateFailed"}},
+		{"weekly", []string{"x86"}, "alice", 2 * time.Hour, []event.Name{"JobStateStarted"}},
+		{"adhoc", nil, "bob", time.Hour, nil},
+	}
+	for _, j := range jobs {
+		request := job.Request{
+			JobName:       j.name,
+			Requestor:     j.requestor,
+			RequestTime:   now.Add(-j.age),
+			JobDescriptor: `{"JobName": "` + j.name + `"}`,
+			Tags:          j.tags,
+		}
+		jobID, err := backend.StoreJobRequest(&request)
+		if err != nil {
+			return err
+		}
+		for _, state := range j.states {
+			ev := frameworkevent.Event{JobID: jobID, EventName: state, EmitTime: now}
+			if err := backend.StoreFrameworkEvent(ev); err != nil {
+				return err
+			}
+		}
+		// events which are not state events are ignored
+		if err := backend.StoreFrameworkEvent(frameworkevent.Event{JobID: jobID, EventName: "SomeEvent", EmitTime: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (suite *JobSuite) listJobNames(query job.ListQuery) []string {
+	jobs, err := suite.storage.ListJobs(&query, stateEvents)
+	require.NoError(suite.T(), err)
+	names := []string{}
+	for _, j := range jobs {
+		names = append(names, j.JobName)
+	}
+	return names
+}
+
+func (suite *JobSuite) TestListJobs() {
+	now := time.Now().Truncate(time.Second)
+	require.NoError(suite.T(), populateJobList(suite.storage, now))
+
+	jobs, err := suite.storage.ListJobs(&job.ListQuery{}, stateEvents)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 4, len(jobs))
+	require.Equal(suite.T(), types.JobID(4), jobs[0].JobID)
+	require.Equal(suite.T(), "adhoc", jobs[0].JobName)
+	require.Equal(suite.T(), event.Name(""), jobs[0].State)
+	require.Equal(suite.T(), "nightly-b", jobs[2].JobName)
+	require.Equal(suite.T(), "bob", jobs[2].Requestor)
+	require.Equal(suite.T(), []string{"arm", "nightly"}, jobs[2].Tags)
+	require.Equal(suite.T(), 0, len(jobs[0].Tags))
+	require.Equal(suite.T(), event.Name("JobStateFailed"), jobs[2].State)
+	require.True(suite.T(), now.Add(-3*time.Hour).Equal(jobs[2].RequestTime))
+
+	require.Equal(suite.T(), []string{"weekly", "nightly-b"}, suite.listJobNames(job.ListQuery{States: []event.Name{"JobStateStarted", "JobStateFailed"}}))
+	require.Equal(suite.T(), []string{"weekly", "nightly-b", "nightly-a"}, suite.listJobNames(job.ListQuery{Tags: []string{"x86", "nightly"}}))
+	require.Equal(suite.T(), []string{"nightly-a"}, suite.listJobNames(job.ListQuery{Tags: []string{"x86", "nightly"}, MatchAllTags: true}))
+	require.Equal(suite.T(), []string{"weekly", "nightly-a"}, suite.listJobNames(job.ListQuery{Requestor: "alice"}))
+	require.Equal(suite.T(), []string{"nightly-b", "nightly-a"}, suite.listJobNames(job.ListQuery{NamePattern: "nightly-?"}))
+	require.Equal(suite.T(), []string{"adhoc", "weekly", "nightly-b", "nightly-a"}, suite.listJobNames(job.ListQuery{NamePattern: "*"}))
+	require.Equal(suite.T(), []string{}, suite.listJobNames(job.ListQuery{NamePattern: "nightly_a"}))
+	require.Equal(suite.T(), []string{"weekly", "nightly-b"}, suite.listJobNames(job.ListQuery{
+		RequestedAfter:  now.Add(-3 * time.Hour),
+		RequestedBefore: now.Add(-2 * time.Hour),
+	}))
+	require.Equal(suite.T(), []string{"weekly", "nightly-b"}, suite.listJobNames(job.ListQuery{Offset: 1, Limit: 2}))
+	require.Equal(suite.T(), []string{"nightly-a"}, suite.listJobNames(job.ListQuery{Offset: 3}))
+	require.Equal(suite.T(), []string{"nightly-b"}, suite.listJobNames(job.ListQuery{Tags: []string{"nightly"}, Requestor: "bob", Limit: 1}))
+}
+
+func (suite *JobSuite) TestGetJobRequestsByTag() {
+	require.NoError(suite.T(), populateJobList(suite.storage, time.Now()))
+
+	request, err := suite.storage.GetJobRequest(types.JobID(2))
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"arm", "nightly"}, request.Tags)
+
+	requests, err := suite.storage.GetJobRequestsByTag("x86")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(requests))
+	require.Equal(suite.T(), types.JobID(1), requests[0].JobID)
+	require.Equal(suite.T(), "nightly-a", requests[0].JobName)
+	require.Equal(suite.T(), []string{"nightly", "x86"}, requests[0].Tags)
+	require.Equal(suite.T(), types.JobID(3), requests[1].JobID)
+	require.Equal(suite.T(), []string{"x86"}, requests[1].Tags)
+
+	requests, err = suite.storage.GetJobRequestsByTag("unknown")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0, len(requests))
+}
@@ -8,8 +8,12 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -17,13 +21,18 @@ import (
 	"github.com/facebookincubator/contest/pkg/config"
 	"github.com/facebookincubator/contest/pkg/event"
 	"github.com/facebookincubator/contest/pkg/event/frameworkevent"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/job"
 	"github.com/facebookincubator/contest/pkg/jobmanager"
 	"github.com/facebookincubator/contest/pkg/logging"
 	"github.com/facebookincubator/contest/pkg/pluginregistry"
+	"github.com/facebookincubator/contest/pkg/runner"
 	"github.com/facebookincubator/contest/pkg/storage"
+	"github.com/facebookincubator/contest/pkg/target"
 	"github.com/facebookincubator/contest/pkg/types"
 	"github.com/facebookincubator/contest/plugins/reporters/targetsuccess"
+	"github.com/facebookincubator/contest/plugins/targetlocker/inmemory"
+	"github.com/facebookincubator/contest/plugins/targetmanagers/csvtargetmanager"
 	"github.com/facebookincubator/contest/plugins/targetmanagers/targetlist"
 	"github.com/facebookincubator/contest/plugins/testfetchers/literal"
 	"github.com/facebookincubator/contest/plugins/teststeps/slowecho"
@@ -31,7 +40,9 @@ import (
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/fail"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/noop"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/noreturn"
+	"github.com/facebookincubator/contest/tests/plugins/teststeps/resumable"
 
+	"github.com/insomniacslk/xjson"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -43,14 +54,35 @@ import (
 type CommandType string
 
 var (
-	StartJob CommandType = "start"
-	StopJob  CommandType = "stop"
+	StartJob  CommandType = "start"
+	StopJob   CommandType = "stop"
+	RetryJob  CommandType = "retry"
+	ListJobs  CommandType = "list"
+	WatchJob  CommandType = "watch"
+	StatusJob CommandType = "status"
+
+	ListQuarantine    CommandType = "list_quarantine"
+	ReleaseQuarantine CommandType = "release_quarantine"
+	DetachTarget      CommandType = "detach_target"
+
+	CreateSchedule CommandType = "create_schedule"
+	ListSchedules  CommandType = "list_schedules"
+	PauseSchedule  CommandType = "pause_schedule"
+	ResumeSchedule CommandType = "resume_schedule"
+	DeleteSchedule CommandType = "delete_schedule"
 )
 
 type command struct {
-	commandType   CommandType
-	jobID         types.JobID
-	jobDescriptor string
+	commandType       CommandType
+	requestor         api.EventRequestor
+	jobID             types.JobID
+	jobDescriptor     string
+	failedTargetsOnly bool
+	listQuery         job.ListQuery
+	targetID          string
+	schedule          job.ScheduleDescriptor
+	scheduleID        types.ScheduleID
+	afterID           uint64
 }
 
 // TestListener implements a dummy api.Listener interface for testing purposes
@@ -73,14 +105,90 @@ func (tl *TestListener) Serve(cancel <-chan struct{}, contestApi *api.API) error
 	for {
 		select {
 		case command := <-tl.commandCh:
+			requestor := command.requestor
+			if requestor == "" {
+				requestor = "IntegrationTest"
+			}
 			if command.commandType == StartJob {
-				resp, err := contestApi.Start("IntegrationTest", command.jobDescriptor)
+				resp, err := contestApi.Start(requestor, command.jobDescriptor)
 				if err != nil {
 					tl.errorCh <- err
 				}
 				tl.responseCh <- resp
 			} else if command.commandType == StopJob {
-				resp, err := contestApi.Stop("IntegrationTest", command.jobID)
+				resp, err := contestApi.Stop(requestor, command.jobID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == RetryJob {
+				resp, err := contestApi.Retry(requestor, command.jobID, command.failedTargetsOnly)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == WatchJob {
+				resp, err := contestApi.Watch(requestor, command.jobID, command.afterID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == StatusJob {
+				resp, err := contestApi.Status(requestor, command.jobID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == ListJobs {
+				resp, err := contestApi.List(requestor, command.listQuery)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == ListQuarantine {
+				resp, err := contestApi.ListQuarantine(requestor)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == ReleaseQuarantine {
+				resp, err := contestApi.ReleaseQuarantine(requestor, command.targetID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == DetachTarget {
+				resp, err := contestApi.DetachTarget(requestor, command.jobID, command.targetID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == CreateSchedule {
+				resp, err := contestApi.CreateSchedule(requestor, command.schedule)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == ListSchedules {
+				resp, err := contestApi.ListSchedules(requestor)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == PauseSchedule {
+				resp, err := contestApi.PauseSchedule(requestor, command.scheduleID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == ResumeSchedule {
+				resp, err := contestApi.ResumeSchedule(requestor, command.scheduleID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			} else if command.commandType == DeleteSchedule {
+				resp, err := contestApi.DeleteSchedule(requestor, command.scheduleID)
 				if err != nil {
 					tl.errorCh <- err
 				}
@@ -124,11 +232,14 @@ type TestJobManagerSuite struct {
 
 	storage storage.Storage
 
-	jm *jobmanager.JobManager
+	jm             *jobmanager.JobManager
+	pluginRegistry *pluginregistry.PluginRegistry
+	testListener   *TestListener
 
 	jobRequestManager job.RequestEmitterFetcher
 	jobReportManager  job.ReportEmitterFetcher
 	eventManager      frameworkevent.EmitterFetcher
+	testEventManager  testevent.Fetcher
 
 	// commandCh is the counterpart of the commandCh in the Listener
 	commandCh chan command
@@ -159,9 +270,44 @@ func (suite *TestJobManagerSuite) startJob(jobDescriptor string) (types.JobID, e
 	return jobID, nil
 }
 
+// startJobAs starts a job on behalf of the given requestor.
+func (suite *TestJobManagerSuite) startJobAs(requestor api.EventRequestor, jobDescriptor string) (types.JobID, error) {
+	var resp api.Response
+	start := command{commandType: StartJob, requestor: requestor, jobDescriptor: jobDescriptor}
+	suite.commandCh <- start
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return types.JobID(0), resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return types.JobID(0), fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataStart).JobID, nil
+}
+
+func (suite *TestJobManagerSuite) jobStatus(jobID types.JobID) (*job.Status, error) {
+	var resp api.Response
+	suite.commandCh <- command{commandType: StatusJob, jobID: jobID}
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataStatus).Status, nil
+}
+
 func (suite *TestJobManagerSuite) stopJob(jobID types.JobID) error {
+	return suite.stopJobAs("", jobID)
+}
+
+// stopJobAs stops a job on behalf of the given requestor.
+func (suite *TestJobManagerSuite) stopJobAs(requestor api.EventRequestor, jobID types.JobID) error {
 	var resp api.Response
-	stop := command{commandType: StopJob, jobID: jobID}
+	stop := command{commandType: StopJob, requestor: requestor, jobID: jobID}
 	suite.commandCh <- stop
 	select {
 	case resp = <-suite.responseCh:
@@ -174,6 +320,183 @@ func (suite *TestJobManagerSuite) stopJob(jobID types.JobID) error {
 	return nil
 }
 
+func (suite *TestJobManagerSuite) retryJob(jobID types.JobID, failedTargetsOnly bool) (types.JobID, error) {
+	return suite.retryJobAs("", jobID, failedTargetsOnly)
+}
+
+// retryJobAs retries a job on behalf of the given requestor.
+func (suite *TestJobManagerSuite) retryJobAs(requestor api.EventRequestor, jobID types.JobID, failedTargetsOnly bool) (types.JobID, error) {
+	var resp api.Response
+	retry := command{commandType: RetryJob, requestor: requestor, jobID: jobID, failedTargetsOnly: failedTargetsOnly}
+	suite.commandCh <- retry
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return types.JobID(0), resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return types.JobID(0), fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataRetry).NewJobID, nil
+}
+
+func (suite *TestJobManagerSuite) listJobs(query job.ListQuery) ([]job.Summary, error) {
+	var resp api.Response
+	list := command{commandType: ListJobs, listQuery: query}
+	suite.commandCh <- list
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataList).Jobs, nil
+}
+
+func (suite *TestJobManagerSuite) watchJob(jobID types.JobID) (*storage.Subscription, error) {
+	return suite.watchJobAfter(jobID, 0)
+}
+
+// watchJobAfter watches the events of a job which follow the event whose ID
+// is afterID
+func (suite *TestJobManagerSuite) watchJobAfter(jobID types.JobID, afterID uint64) (*storage.Subscription, error) {
+	var resp api.Response
+	watch := command{commandType: WatchJob, jobID: jobID, afterID: afterID}
+	suite.commandCh <- watch
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataWatch).Subscription, nil
+}
+
+// quarantined returns the sorted IDs of the quarantined targets
+func (suite *TestJobManagerSuite) quarantined() ([]string, error) {
+	var resp api.Response
+	suite.commandCh <- command{commandType: ListQuarantine}
+	select {
+	case resp = <-suite.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	var ids []string
+	for _, qt := range resp.Data.(api.ResponseDataListQuarantine).Targets {
+		ids = append(ids, qt.ID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (suite *TestJobManagerSuite) releaseQuarantine(targetID string) error {
+	var resp api.Response
+	suite.commandCh <- command{commandType: ReleaseQuarantine, targetID: targetID}
+	select {
+	case resp = <-suite.responseCh:
+		return resp.Err
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("Listener response should come within the timeout")
+	}
+}
+
+func (suite *TestJobManagerSuite) detachTarget(jobID types.JobID, targetID string) error {
+	var resp api.Response
+	suite.commandCh <- command{commandType: DetachTarget, jobID: jobID, targetID: targetID}
+	select {
+	case resp = <-suite.responseCh:
+		return resp.Err
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("Listener response should come within the timeout")
+	}
+}
+
+// scheduleCommand sends a command about schedules, and returns the response
+func (suite *TestJobManagerSuite) scheduleCommand(c command) (api.Response, error) {
+	suite.commandCh <- c
+	select {
+	case resp := <-suite.responseCh:
+		return resp, resp.Err
+	case <-time.After(2 * time.Second):
+		return api.Response{}, fmt.Errorf("Listener response should come within the timeout")
+	}
+}
+
+func (suite *TestJobManagerSuite) createSchedule(jobDescriptor string, interval time.Duration, overlapPolicy job.OverlapPolicy) (*job.Schedule, error) {
+	resp, err := suite.scheduleCommand(command{
+		commandType: CreateSchedule,
+		schedule: job.ScheduleDescriptor{
+			JobDescriptor: jobDescriptor,
+			Interval:      xjson.Duration(interval),
+			OverlapPolicy: overlapPolicy,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data.(api.ResponseDataCreateSchedule).Schedule, nil
+}
+
+// scheduledJobs returns the IDs of the jobs started by a schedule, in order
+func (suite *TestJobManagerSuite) scheduledJobs(scheduleID types.ScheduleID) []types.JobID {
+	events, err := suite.eventManager.Fetch([]frameworkevent.QueryField{
+		frameworkevent.QueryEventName(jobmanager.EventScheduleFired),
+	})
+	require.NoError(suite.T(), err)
+	var jobIDs []types.JobID
+	for _, ev := range events {
+		var payload struct{ ScheduleID types.ScheduleID }
+		require.NoError(suite.T(), json.Unmarshal(*ev.Payload, &payload))
+		if payload.ScheduleID == scheduleID {
+			jobIDs = append(jobIDs, ev.JobID)
+		}
+	}
+	return jobIDs
+}
+
+// waitScheduledJobs waits for a schedule to start at least n jobs
+func (suite *TestJobManagerSuite) waitScheduledJobs(scheduleID types.ScheduleID, n int) []types.JobID {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jobIDs := suite.scheduledJobs(scheduleID)
+		if len(jobIDs) >= n || time.Now().After(deadline) {
+			return jobIDs
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (suite *TestJobManagerSuite) listSchedules() []*job.Schedule {
+	resp, err := suite.scheduleCommand(command{commandType: ListSchedules})
+	require.NoError(suite.T(), err)
+	return resp.Data.(api.ResponseDataListSchedules).Schedules
+}
+
+// targetsIn returns the sorted IDs of the targets which entered a step of the
+// given job
+func (suite *TestJobManagerSuite) targetsIn(jobID types.JobID) []string {
+	events, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(target.EventTargetIn),
+		},
+	)
+	require.NoError(suite.T(), err)
+	var ids []string
+	for _, ev := range events {
+		ids = append(ids, ev.Data.Target.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (suite *TestJobManagerSuite) SetupTest() {
 
 	jobRequestManager := storage.NewJobRequestEmitterFetcher()
@@ -183,6 +506,9 @@ func (suite *TestJobManagerSuite) SetupTest() {
 	suite.jobRequestManager = jobRequestManager
 	suite.jobReportManager = jobReportManager
 	suite.eventManager = eventManager
+	suite.testEventManager = storage.NewTestEventFetcher()
+	// job IDs are reused after resetting storage, so streams must be reset too
+	storage.SetEventBroker(storage.NewEventBroker(storage.DefaultStreamHistorySize, storage.DefaultMaxStreams))
 
 	commandCh := make(chan command)
 	suite.commandCh = commandCh
@@ -206,11 +532,14 @@ func (suite *TestJobManagerSuite) SetupTest() {
 	pluginRegistry.RegisterTestStep(crash.Name, crash.New, crash.Events)
 	pluginRegistry.RegisterTestStep(noreturn.Name, noreturn.New, noreturn.Events)
 	pluginRegistry.RegisterTestStep(slowecho.Name, slowecho.New, slowecho.Events)
+	pluginRegistry.RegisterTestStep(resumable.Name, resumable.New, resumable.Events)
 
 	jm, err := jobmanager.New(&testListener, pluginRegistry)
 	require.NoError(suite.T(), err)
 
 	suite.jm = jm
+	suite.pluginRegistry = pluginRegistry
+	suite.testListener = &testListener
 	sigs := make(chan os.Signal)
 	suite.sigs = sigs
 }
@@ -248,8 +577,14 @@ func (suite *TestJobManagerSuite) TestJobManagerJobStartSingle() {
 	require.NoError(suite.T(), err)
 	require.Equal(suite.T(), types.JobID(1), jobID)
 
-	_, err = suite.jobRequestManager.Fetch(types.JobID(jobID))
+	request, err := suite.jobRequestManager.Fetch(types.JobID(jobID))
 	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"integration_testing"}, request.Tags)
+
+	requests, err := suite.jobRequestManager.FetchByTag("integration_testing")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(requests))
+	require.Equal(suite.T(), jobID, requests[0].JobID)
 
 	r, err := suite.jobRequestManager.Fetch(types.JobID(jobID + 1))
 	require.Error(suite.T(), err)
@@ -313,6 +648,11 @@ func (suite *TestJobManagerSuite) TestJobManagerJobCancellation() {
 	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, types.JobID(jobID))
 	require.NoError(suite.T(), err)
 	require.Equal(suite.T(), 1, len(ev))
+
+	// the targets were released despite the cancellation
+	ev, err = pollForEvent(suite.eventManager, runner.EventTargetsReleased, types.JobID(jobID))
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
 }
 
 func (suite *TestJobManagerSuite) TestJobManagerJobNotSuccessful() {
@@ -382,8 +722,12 @@ func (suite *TestJobManagerSuite) TestJobManagerJobCrash() {
 
 	jobReport, err := suite.jobReportManager.Fetch(types.JobID(1))
 	require.NoError(suite.T(), err)
-	// no reports are expected if the job crashes
-	require.Equal(suite.T(), &job.JobReport{JobID: jobID}, jobReport)
+	// the failed run is reported as unsuccessful, and there is no final
+	// report if the job crashes
+	require.Equal(suite.T(), 1, len(jobReport.RunReports))
+	require.Equal(suite.T(), 1, len(jobReport.RunReports[0]))
+	require.False(suite.T(), jobReport.RunReports[0][0].Success)
+	require.Equal(suite.T(), 0, len(jobReport.FinalReports))
 }
 
 func (suite *TestJobManagerSuite) TestJobManagerJobCancellationFailure() {
@@ -424,3 +768,1015 @@ func (suite *TestJobManagerSuite) TestJobManagerJobCancellationFailure() {
 	require.Equal(suite.T(), 1, len(ev))
 
 }
+
+func (suite *TestJobManagerSuite) TestJobManagerJobRetry() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), types.JobID(1), jobID)
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	newJobID, err := suite.retryJob(jobID, false)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), types.JobID(2), newJobID)
+
+	// The new job request must be linked to the original one
+	request, err := suite.jobRequestManager.Fetch(newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), jobID, request.RetryOf)
+	require.Equal(suite.T(), []string{"integration_testing"}, request.Tags)
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// A job without failed targets cannot be retried on failed targets only
+	_, err = suite.retryJob(jobID, true)
+	require.Error(suite.T(), err)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobRetryFailedTargetsOnly() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// only the target with ID "id2" fails
+	jobID, err := suite.startJob(jobDescriptorPartialFailure)
+	require.NoError(suite.T(), err)
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	newJobID, err := suite.retryJob(jobID, true)
+	require.NoError(suite.T(), err)
+	require.NotEqual(suite.T(), jobID, newJobID)
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	request, err := suite.jobRequestManager.Fetch(newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), jobID, request.RetryOf)
+
+	// The original job ran on both targets, the retry only on the failed one
+	require.Equal(suite.T(), []string{"id1", "id2"}, suite.targetsIn(jobID))
+	require.Equal(suite.T(), []string{"id2"}, suite.targetsIn(newJobID))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobRetryRunning() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobStarted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// A job which is still running cannot be retried
+	_, err = suite.retryJob(jobID, false)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+
+	err = suite.stopJob(jobID)
+	require.NoError(suite.T(), err)
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// A job which is not running anymore cannot be stopped
+	err = suite.stopJob(jobID)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobAuthorization() {
+
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry,
+		jobmanager.WithAuthorizer(api.NewOwnerAuthorizer([]api.EventRequestor{"Admin"})))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobStarted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// Only the requestor of the job and the admins can stop or retry it
+	err = suite.stopJobAs("Other", jobID)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindPermissionDenied, api.ErrorKindOf(err))
+	_, err = suite.retryJobAs("Other", jobID, false)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindPermissionDenied, api.ErrorKindOf(err))
+
+	err = suite.stopJobAs("Admin", jobID)
+	require.NoError(suite.T(), err)
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobPauseResume() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// the resumable step holds the targets until it is paused
+	jobID, err := suite.startJob(jobDescriptorResumable)
+	require.NoError(suite.T(), err)
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobStarted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// Shut down the JobManager, which pauses the running job
+	suite.sigs <- syscall.SIGINT
+	select {
+	case <-suite.jobManagerCh:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("JobManager should return within the timeout")
+	}
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobPaused, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0, len(ev))
+
+	// A new JobManager resumes the paused job at startup
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry)
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	suite.jobManagerCh = make(chan struct{})
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobResumed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// The step is resumed, and lets the targets through
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	resumed, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(resumable.ResumedEvent),
+		},
+	)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(resumed))
+
+	jobReport, err := suite.jobReportManager.Fetch(jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(jobReport.RunReports))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobWaitForTargets() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	firstJobID, err := suite.startJob(jobDescriptorWaitForTargets)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobStarted, firstJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the second job waits for the first one to unlock the target
+	secondJobID, err := suite.startJob(jobDescriptorWaitForTargets)
+	require.NoError(suite.T(), err)
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobWaitingForTargets, secondJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, firstJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobTargetsAcquired, secondJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, secondJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the first job never waited
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobWaitingForTargets, firstJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobList() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	completedJobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, completedJobID)
+	require.NoError(suite.T(), err)
+
+	runningJobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobStarted, runningJobID)
+	require.NoError(suite.T(), err)
+
+	jobs, err := suite.listJobs(job.ListQuery{})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(jobs))
+	require.Equal(suite.T(), runningJobID, jobs[0].JobID)
+	require.Equal(suite.T(), jobmanager.EventJobStarted, jobs[0].State)
+	require.Equal(suite.T(), completedJobID, jobs[1].JobID)
+	require.Equal(suite.T(), jobmanager.EventJobCompleted, jobs[1].State)
+	require.Equal(suite.T(), "test job", jobs[1].JobName)
+	require.Equal(suite.T(), "IntegrationTest", jobs[1].Requestor)
+	require.Equal(suite.T(), []string{"integration_testing"}, jobs[1].Tags)
+
+	jobs, err = suite.listJobs(job.ListQuery{States: []event.Name{jobmanager.EventJobCompleted}})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(jobs))
+	require.Equal(suite.T(), completedJobID, jobs[0].JobID)
+
+	jobs, err = suite.listJobs(job.ListQuery{Tags: []string{"integration_testing", "other"}, MatchAllTags: true})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0, len(jobs))
+
+	_, err = suite.listJobs(job.ListQuery{States: []event.Name{"NotAState"}})
+	require.Error(suite.T(), err)
+
+	require.NoError(suite.T(), suite.stopJob(runningJobID))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, runningJobID)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobWatch() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+
+	sub, err := suite.watchJob(jobID)
+	require.NoError(suite.T(), err)
+	defer sub.Cancel()
+
+	// The stream is closed when the job terminates
+	var (
+		frameworkEvents []event.Name
+		testEvents      int
+		ids             []uint64
+	)
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				done = true
+				break
+			}
+			if len(ids) > 0 {
+				require.Greater(suite.T(), ev.ID, ids[len(ids)-1])
+			}
+			ids = append(ids, ev.ID)
+			if ev.FrameworkEvent != nil {
+				frameworkEvents = append(frameworkEvents, ev.FrameworkEvent.EventName)
+			} else {
+				require.Equal(suite.T(), jobID, ev.TestEvent.Header.JobID)
+				testEvents++
+			}
+		case <-timeout:
+			suite.T().Fatalf("job events should be streamed within the timeout")
+		}
+	}
+	require.False(suite.T(), sub.Dropped())
+	require.Equal(suite.T(), []event.Name{jobmanager.EventJobStarted, runner.EventTargetsReleased, jobmanager.EventJobCompleted}, frameworkEvents)
+	require.NotZero(suite.T(), testEvents)
+
+	// Watching a terminated job delivers the past events only
+	sub, err = suite.watchJob(jobID)
+	require.NoError(suite.T(), err)
+	var count int
+	for range sub.C {
+		count++
+	}
+	require.Equal(suite.T(), len(ids), count)
+
+	// Events IDs outlive the broker, e.g. across restarts, as the past
+	// events are fetched from storage
+	storage.SetEventBroker(storage.NewEventBroker(storage.DefaultStreamHistorySize, storage.DefaultMaxStreams))
+	sub, err = suite.watchJobAfter(jobID, ids[0])
+	require.NoError(suite.T(), err)
+	require.False(suite.T(), sub.Gap)
+	var resumed []uint64
+	for ev := range sub.C {
+		resumed = append(resumed, ev.ID)
+	}
+	// the order of the events fetched from storage only follows their emit
+	// time, which might not be precise enough to interleave test and
+	// framework events as they were streamed
+	require.Equal(suite.T(), len(ids)-1, len(resumed))
+	require.Equal(suite.T(), ids[len(ids)-1], resumed[len(resumed)-1])
+
+	_, err = suite.watchJob(jobID + 1)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindNotFound, api.ErrorKindOf(err))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerQuarantine() {
+
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry,
+		jobmanager.WithQuarantinePolicy(jobmanager.QuarantinePolicy{Failures: 2, Runs: 3}))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// the target with ID "id2" fails twice, and is quarantined
+	for i := 0; i < 2; i++ {
+		quarantined, err := suite.quarantined()
+		require.NoError(suite.T(), err)
+		require.Empty(suite.T(), quarantined)
+
+		jobID, err := suite.startJob(jobDescriptorPartialFailure)
+		require.NoError(suite.T(), err)
+		_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+		require.NoError(suite.T(), err)
+	}
+	quarantined, err := suite.quarantined()
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"id2"}, quarantined)
+
+	// the TargetList target manager requires all its targets
+	jobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	require.NoError(suite.T(), suite.releaseQuarantine("id2"))
+	err = suite.releaseQuarantine("id2")
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindNotFound, api.ErrorKindOf(err))
+
+	// the failures before the release do not count anymore
+	jobID, err = suite.startJob(jobDescriptorPartialFailure)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	quarantined, err = suite.quarantined()
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), quarantined)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerHealthCheck() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// command health checks are rejected unless the server enables them
+	_, err := suite.startJob(jobDescriptorHealthCheck)
+	require.Error(suite.T(), err)
+	config.HealthCheckCmdEnabled = true
+	defer func() { config.HealthCheckCmdEnabled = false }()
+
+	jobID, err := suite.startJob(jobDescriptorHealthCheck)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the unhealthy target was not handed to the test
+	require.Equal(suite.T(), []string{"id1"}, suite.targetsIn(jobID))
+	unhealthy, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(target.EventTargetUnhealthy),
+		},
+	)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(unhealthy))
+	require.Equal(suite.T(), "id2", unhealthy[0].Data.Target.ID)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerDetachTarget() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+
+	// wait for the targets to be in the slowecho step
+	for i := 0; i < 10 && len(suite.targetsIn(jobID)) < 2; i++ {
+		time.Sleep(500 * time.Millisecond)
+	}
+	require.Equal(suite.T(), []string{"id1", "id2"}, suite.targetsIn(jobID))
+	require.NoError(suite.T(), suite.detachTarget(jobID, "id2"))
+	// the target is no longer in the job
+	err = suite.detachTarget(jobID, "id2")
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindNotFound, api.ErrorKindOf(err))
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	detached, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(target.EventTargetDetached),
+		},
+	)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(detached))
+	require.Equal(suite.T(), "id2", detached[0].Data.Target.ID)
+
+	// targets cannot be detached from completed jobs
+	err = suite.detachTarget(jobID, "id1")
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerParallelTests() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorParallel)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the tests ran at the same time, each on its own target
+	entered, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(target.EventTargetIn),
+		},
+	)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(entered))
+	require.NotEqual(suite.T(), entered[0].Header.TestName, entered[1].Header.TestName)
+	delta := entered[1].EmitTime.Sub(entered[0].EmitTime)
+	require.True(suite.T(), delta < 2*time.Second && delta > -2*time.Second, "tests did not run in parallel: %s apart", delta)
+	require.Equal(suite.T(), []string{"id1", "id2"}, suite.targetsIn(jobID))
+
+	// both tests released their targets
+	ev, err = pollForEvent(suite.eventManager, runner.EventTargetsReleased, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerParallelTestsSharedTargets() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// tests running in parallel cannot share targets
+	jobID, err := suite.startJob(jobDescriptorParallelSharedTargets)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerParallelTestsCrash() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorParallelCrash)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	ev, err = pollForEvent(suite.eventManager, runner.EventRunFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the run is reported as failed, even though the second test, which
+	// reports after the first one, succeeded
+	require.Contains(suite.T(), suite.targetsIn(jobID), "id2")
+	jobReport, err := suite.jobReportManager.Fetch(jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(jobReport.RunReports))
+	require.Equal(suite.T(), 1, len(jobReport.RunReports[0]))
+	require.False(suite.T(), jobReport.RunReports[0][0].Success)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerParallelTestsSharedCSVTargets() {
+
+	fd, err := ioutil.TempFile("", "hosts*.csv")
+	require.NoError(suite.T(), err)
+	defer os.Remove(fd.Name())
+	_, err = fd.WriteString("host1.example.com,id1\n")
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), fd.Close())
+
+	tl := inmemory.New(time.Minute)
+	suite.pluginRegistry.RegisterTargetManager(csvtargetmanager.Name, csvtargetmanager.New)
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry, jobmanager.WithTargetLocker(tl))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	// both tests try to acquire the only target of the file, the one which
+	// does not get it fails right away
+	jobID, err := suite.startJob(parallelCSVDescriptor(fd.Name()))
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, runner.EventTargetsReleased, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// releasing its target manager does not unlock the target of the other
+	// test, which is still running
+	allLocked, _, _ := tl.CheckLocks(jobID, []*target.Target{{ID: "id1", Name: "host1.example.com"}})
+	require.True(suite.T(), allLocked)
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	require.Equal(suite.T(), []string{"id1"}, suite.targetsIn(jobID))
+	allLocked, _, _ = tl.CheckLocks(jobID, []*target.Target{{ID: "id1", Name: "host1.example.com"}})
+	require.False(suite.T(), allLocked)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerParallelTestsPauseResume() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorParallelResumable)
+	require.NoError(suite.T(), err)
+	// wait for both tests to hold their targets
+	for i := 0; i < 10 && len(suite.targetsIn(jobID)) < 2; i++ {
+		time.Sleep(500 * time.Millisecond)
+	}
+	require.Equal(suite.T(), []string{"id1", "id2"}, suite.targetsIn(jobID))
+
+	// Shut down the JobManager, which pauses both tests
+	suite.sigs <- syscall.SIGINT
+	select {
+	case <-suite.jobManagerCh:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("JobManager should return within the timeout")
+	}
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobPaused, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// A new JobManager resumes both tests at startup
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry)
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	suite.jobManagerCh = make(chan struct{})
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	ev, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	resumed, err := suite.testEventManager.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryEventName(resumable.ResumedEvent),
+		},
+	)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(resumed))
+	require.NotEqual(suite.T(), resumed[0].Header.TestName, resumed[1].Header.TestName)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerFailurePolicyContinue() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorCrashContinue)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// every run took place, and failed
+	ev, err = pollForEvent(suite.eventManager, runner.EventRunFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 3, len(ev))
+	jobReport, err := suite.jobReportManager.Fetch(jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 3, len(jobReport.RunReports))
+	for _, runReports := range jobReport.RunReports {
+		require.Equal(suite.T(), 1, len(runReports))
+		require.False(suite.T(), runReports[0].Success)
+	}
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerFailurePolicyAbort() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorCrashAbort)
+	require.NoError(suite.T(), err)
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	// the job gave up after two failed runs
+	ev, err = pollForEvent(suite.eventManager, runner.EventRunFailed, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerRunReportsStoredPerRun() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobID, err := suite.startJob(jobDescriptorNoopRuns)
+	require.NoError(suite.T(), err)
+
+	// the report of the first run is available while the job is still running
+	var jobReport *job.JobReport
+	for i := 0; i < 20; i++ {
+		jobReport, err = suite.jobReportManager.Fetch(jobID)
+		require.NoError(suite.T(), err)
+		if len(jobReport.RunReports) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NotEqual(suite.T(), 0, len(jobReport.RunReports))
+	require.Less(suite.T(), len(jobReport.RunReports), 3)
+	ev, err := suite.eventManager.Fetch([]frameworkevent.QueryField{
+		frameworkevent.QueryJobID(jobID),
+		frameworkevent.QueryEventName(jobmanager.EventJobCompleted),
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0, len(ev))
+
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	jobReport, err = suite.jobReportManager.Fetch(jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 3, len(jobReport.RunReports))
+	for _, runReports := range jobReport.RunReports {
+		require.Equal(suite.T(), 1, len(runReports))
+		require.True(suite.T(), runReports[0].Success)
+	}
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerSchedule() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	_, err := suite.createSchedule(jobDescriptorNoop, 0, "")
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindInvalid, api.ErrorKindOf(err))
+
+	s, err := suite.createSchedule(jobDescriptorNoop, time.Second, "")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), job.OverlapPolicySkip, s.OverlapPolicy)
+	require.False(suite.T(), s.NextFireTime.IsZero())
+
+	// every firing starts a new job
+	jobIDs := suite.waitScheduledJobs(s.ID, 2)
+	require.Equal(suite.T(), 2, len(jobIDs))
+	require.NotEqual(suite.T(), jobIDs[0], jobIDs[1])
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobIDs[0])
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	schedules := suite.listSchedules()
+	require.Equal(suite.T(), 1, len(schedules))
+	require.Equal(suite.T(), s.ID, schedules[0].ID)
+	require.NotEqual(suite.T(), types.JobID(0), schedules[0].LastJobID)
+
+	// paused schedules do not fire
+	resp, err := suite.scheduleCommand(command{commandType: PauseSchedule, scheduleID: s.ID})
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), resp.Data.(api.ResponseDataPauseSchedule).Schedule.Paused)
+	_, err = suite.scheduleCommand(command{commandType: PauseSchedule, scheduleID: s.ID})
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+	fired := len(suite.scheduledJobs(s.ID))
+	time.Sleep(2 * time.Second)
+	require.Equal(suite.T(), fired, len(suite.scheduledJobs(s.ID)))
+
+	resp, err = suite.scheduleCommand(command{commandType: ResumeSchedule, scheduleID: s.ID})
+	require.NoError(suite.T(), err)
+	require.False(suite.T(), resp.Data.(api.ResponseDataResumeSchedule).Schedule.Paused)
+	require.True(suite.T(), len(suite.waitScheduledJobs(s.ID, fired+1)) > fired)
+
+	_, err = suite.scheduleCommand(command{commandType: DeleteSchedule, scheduleID: s.ID})
+	require.NoError(suite.T(), err)
+	_, err = suite.scheduleCommand(command{commandType: DeleteSchedule, scheduleID: s.ID})
+	require.Equal(suite.T(), api.ErrorKindNotFound, api.ErrorKindOf(err))
+	require.Equal(suite.T(), 0, len(suite.listSchedules()))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerScheduleOverlapSkip() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	s, err := suite.createSchedule(jobDescriptorSlowecho, time.Second, job.OverlapPolicySkip)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(suite.waitScheduledJobs(s.ID, 1)))
+
+	// the first job is still running, the next firings are skipped
+	time.Sleep(2 * time.Second)
+	require.Equal(suite.T(), 1, len(suite.scheduledJobs(s.ID)))
+	require.False(suite.T(), suite.listSchedules()[0].Queued)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerScheduleOverlapQueue() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	s, err := suite.createSchedule(jobDescriptorSlowecho, 3*time.Second, job.OverlapPolicyQueue)
+	require.NoError(suite.T(), err)
+	jobIDs := suite.waitScheduledJobs(s.ID, 1)
+	require.Equal(suite.T(), 1, len(jobIDs))
+
+	// the second firing waits for the first job
+	deadline := time.Now().Add(5 * time.Second)
+	for !suite.listSchedules()[0].Queued {
+		require.True(suite.T(), time.Now().Before(deadline), "the second firing should be queued")
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Equal(suite.T(), 1, len(suite.scheduledJobs(s.ID)))
+
+	// and starts as soon as it terminates, before the third firing
+	require.NoError(suite.T(), suite.stopJob(jobIDs[0]))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, jobIDs[0])
+	require.NoError(suite.T(), err)
+	time.Sleep(1500 * time.Millisecond)
+	require.Equal(suite.T(), 2, len(suite.scheduledJobs(s.ID)))
+	require.False(suite.T(), suite.listSchedules()[0].Queued)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerScheduleOverlapCancelPrevious() {
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	s, err := suite.createSchedule(jobDescriptorSlowecho, time.Second, job.OverlapPolicyCancelPrevious)
+	require.NoError(suite.T(), err)
+
+	jobIDs := suite.waitScheduledJobs(s.ID, 2)
+	require.Equal(suite.T(), 2, len(jobIDs))
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCancelling, jobIDs[0])
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerScheduleLoaded() {
+
+	// the schedule was created by a previous instance
+	scheduleManager := storage.NewScheduleEmitterFetcher()
+	scheduleID, err := scheduleManager.Emit(&job.Schedule{
+		JobName:       "test job",
+		Requestor:     "IntegrationTest",
+		CreateTime:    time.Now(),
+		JobDescriptor: jobDescriptorNoop,
+		Interval:      xjson.Duration(time.Second),
+		OverlapPolicy: job.OverlapPolicySkip,
+	})
+	require.NoError(suite.T(), err)
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	jobIDs := suite.waitScheduledJobs(scheduleID, 1)
+	require.Equal(suite.T(), 1, len(jobIDs))
+	s, err := scheduleManager.Fetch(scheduleID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), jobIDs[0], s.LastJobID)
+	require.False(suite.T(), s.LastFireTime.IsZero())
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerQueue() {
+
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry,
+		jobmanager.WithQueuePolicy(jobmanager.QueuePolicy{MaxRunningJobs: 1}))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	runningJobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobStarted, runningJobID)
+	require.NoError(suite.T(), err)
+
+	// the next jobs are queued, the high priority one first
+	normalJobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	highJobID, err := suite.startJob(jobDescriptorNoopHighPriority)
+	require.NoError(suite.T(), err)
+	for jobID, position := range map[types.JobID]uint{highJobID: 1, normalJobID: 2} {
+		status, err := suite.jobStatus(jobID)
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), string(jobmanager.EventJobQueued), status.State)
+		require.Equal(suite.T(), position, status.QueuePosition)
+		require.True(suite.T(), status.StartTime.IsZero())
+	}
+
+	// once the running job terminates, the queued ones start one at a time
+	require.NoError(suite.T(), suite.stopJob(runningJobID))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, runningJobID)
+	require.NoError(suite.T(), err)
+	highCompleted, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, highJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(highCompleted))
+	normalStarted, err := pollForEvent(suite.eventManager, jobmanager.EventJobStarted, normalJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(normalStarted))
+	require.False(suite.T(), normalStarted[0].EmitTime.Before(highCompleted[0].EmitTime))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, normalJobID)
+	require.NoError(suite.T(), err)
+
+	status, err := suite.jobStatus(normalJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), uint(0), status.QueuePosition)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerQueueStop() {
+
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry,
+		jobmanager.WithQueuePolicy(jobmanager.QueuePolicy{MaxRunningJobs: 1}))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	runningJobID, err := suite.startJob(jobDescriptorSlowecho)
+	require.NoError(suite.T(), err)
+	queuedJobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobQueued, queuedJobID)
+	require.NoError(suite.T(), err)
+
+	// queued jobs cannot be retried, as they did not terminate
+	_, err = suite.retryJob(queuedJobID, false)
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+
+	// queued jobs are cancelled right away
+	require.NoError(suite.T(), suite.stopJob(queuedJobID))
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, queuedJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+	err = suite.stopJob(queuedJobID)
+	require.Equal(suite.T(), api.ErrorKindConflict, api.ErrorKindOf(err))
+
+	// and never start
+	require.NoError(suite.T(), suite.stopJob(runningJobID))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobCancelled, runningJobID)
+	require.NoError(suite.T(), err)
+	time.Sleep(500 * time.Millisecond)
+	ev, err = suite.eventManager.Fetch([]frameworkevent.QueryField{
+		frameworkevent.QueryJobID(queuedJobID),
+		frameworkevent.QueryEventName(jobmanager.EventJobStarted),
+	})
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), ev)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerQueueRequestorQuota() {
+
+	jm, err := jobmanager.New(suite.testListener, suite.pluginRegistry,
+		jobmanager.WithQueuePolicy(jobmanager.QueuePolicy{
+			MaxRunningJobsPerRequestor: 1,
+			RequestorQuotas:            map[string]int{"nightly": 2},
+		}))
+	require.NoError(suite.T(), err)
+	suite.jm = jm
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	var jobIDs []types.JobID
+	for i, requestor := range []api.EventRequestor{"IntegrationTest", "IntegrationTest", "nightly", "nightly", "nightly"} {
+		jobID, err := suite.startJobAs(requestor, slowechoOwnTargets(strconv.Itoa(i)))
+		require.NoError(suite.T(), err)
+		jobIDs = append(jobIDs, jobID)
+	}
+	for i, queued := range []bool{false, true, false, false, true} {
+		status, err := suite.jobStatus(jobIDs[i])
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), queued, status.State == string(jobmanager.EventJobQueued), "job %d", jobIDs[i])
+	}
+
+	// a queued job of a requestor does not hold back the jobs of others
+	require.NoError(suite.T(), suite.stopJob(jobIDs[2]))
+	_, err = pollForEvent(suite.eventManager, jobmanager.EventJobStarted, jobIDs[4])
+	require.NoError(suite.T(), err)
+	status, err := suite.jobStatus(jobIDs[1])
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), string(jobmanager.EventJobQueued), status.State)
+	require.Equal(suite.T(), uint(1), status.QueuePosition)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerQueueRequeue() {
+
+	// the job was queued by a previous instance
+	jobID, err := suite.jobRequestManager.Emit(&job.Request{
+		JobName:       "test job",
+		Requestor:     "IntegrationTest",
+		RequestTime:   time.Now(),
+		JobDescriptor: jobDescriptorNoop,
+	})
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.eventManager.Emit(frameworkevent.Event{
+		JobID:     jobID,
+		EventName: jobmanager.EventJobQueued,
+		EmitTime:  time.Now(),
+	}))
+
+	go func() {
+		suite.jm.Start(suite.sigs)
+		close(suite.jobManagerCh)
+	}()
+
+	ev, err := pollForEvent(suite.eventManager, jobmanager.EventJobCompleted, jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+}
@@ -9,6 +9,8 @@ package test
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"text/template"
 )
 
@@ -66,6 +68,15 @@ func descriptorMust(data string) string {
 	return buf.String()
 }
 
+// slowechoOwnTargets returns jobDescriptorSlowecho on targets of its own, so
+// that it does not wait for the targets of other jobs
+func slowechoOwnTargets(suffix string) string {
+	return strings.NewReplacer(`"id1"`, `"id1-`+suffix+`"`, `"id2"`, `"id2-`+suffix+`"`).Replace(jobDescriptorSlowecho)
+}
+
+// jobDescriptorNoopHighPriority is jobDescriptorNoop with a high priority
+var jobDescriptorNoopHighPriority = strings.Replace(jobDescriptorNoop, `"JobName": "test job",`, `"JobName": "test job", "Priority": "high",`, 1)
+
 var jobDescriptorNoop = descriptorMust(`
     "TestFetcherFetchParameters": {
         "Steps": [
@@ -91,6 +102,57 @@ var jobDescriptorSlowecho = descriptorMust(`
        "TestName": "IntegrationTest: slow echo"
    }`)
 
+// jobDescriptorWaitForTargets waits for its targets if they are locked by
+// another job
+var jobDescriptorWaitForTargets = `
+{
+    "JobName": "test job waiting for targets",
+    "Runs": 1,
+    "RunInterval": "5s",
+    "Tags": [
+        "integration_testing"
+    ],
+    "TestDescriptors": [
+        {
+            "TargetManagerName": "TargetList",
+            "TargetManagerAcquireParameters": {
+                "Targets": [
+                    {
+                        "ID": "id1",
+                        "Name": "hostname1.example.com"
+                    }
+                ],
+                "LockWaitTimeout": "1m"
+            },
+            "TargetManagerReleaseParameters": {},
+            "TestFetcherName": "literal",
+            "TestFetcherFetchParameters": {
+                "Steps": [
+                    {
+                        "name": "slowecho",
+                        "parameters": {
+                          "sleep": ["2"],
+                          "text": ["Hello world"]
+                        }
+                    }
+                ],
+                "TestName": "IntegrationTest: wait for targets"
+            }
+        }
+    ],
+    "Reporting": {
+        "RunReporters": [
+            {
+                "Name": "TargetSuccess",
+                "Parameters": {
+                    "SuccessExpression": ">0%"
+                }
+            }
+        ]
+    }
+}
+`
+
 var jobDescriptorFailure = descriptorMust(`
    "TestFetcherFetchParameters": {
        "Steps": [
@@ -102,6 +164,30 @@ var jobDescriptorFailure = descriptorMust(`
        "TestName": "IntegrationTest: fail"
    }`)
 
+var jobDescriptorPartialFailure = descriptorMust(`
+   "TestFetcherFetchParameters": {
+       "Steps": [
+           {
+               "name": "fail",
+               "parameters": {
+                 "targets": ["id2"]
+               }
+           }
+       ],
+       "TestName": "IntegrationTest: partial failure"
+   }`)
+
+var jobDescriptorResumable = descriptorMust(`
+   "TestFetcherFetchParameters": {
+       "Steps": [
+           {
+               "name": "resumable",
+               "parameters": {}
+           }
+       ],
+       "TestName": "IntegrationTest: resumable"
+   }`)
+
 var jobDescriptorCrash = descriptorMust(`
    "TestFetcherFetchParameters": {
        "Steps": [
@@ -123,3 +209,157 @@ var jobDescriptorHang = descriptorMust(`
        ],
        "TestName": "IntegrationTest: noreturn"
    }`)
+
+// withRuns returns a job descriptor built with descriptorMust running the
+// given number of times, one second apart, with the given failure policy
+func withRuns(descriptor string, runs int, failurePolicy string) string {
+	descriptor = strings.Replace(descriptor, `"Runs": 1,`, fmt.Sprintf(`"Runs": %d, %s,`, runs, failurePolicy), 1)
+	return strings.Replace(descriptor, `"RunInterval": "5s",`, `"RunInterval": "1s",`, 1)
+}
+
+// jobDescriptorCrashContinue crashes in each of its runs, and goes on with
+// the next ones
+var jobDescriptorCrashContinue = withRuns(jobDescriptorCrash, 3, `"FailurePolicy": "continue"`)
+
+// jobDescriptorCrashAbort crashes in each of its runs, and stops after two
+// consecutive failed runs
+var jobDescriptorCrashAbort = withRuns(jobDescriptorCrash, 5, `"FailurePolicy": "abort-after-consecutive-failures", "MaxConsecutiveFailures": 2`)
+
+// jobDescriptorNoopRuns succeeds in each of its three runs, which are one
+// second apart
+var jobDescriptorNoopRuns = withRuns(jobDescriptorNoop, 3, `"FailurePolicy": "stop-on-first-failure"`)
+
+// only the target with ID "id1" passes the health check
+var jobDescriptorHealthCheck = descriptorMust(`
+   "HealthCheck": {
+       "Cmd": "test",
+       "Args": ["{{ .ID }}", "=", "id1"]
+   },
+   "TestFetcherFetchParameters": {
+       "Steps": [
+           {
+               "name": "noop",
+               "parameters": {}
+           }
+       ],
+       "TestName": "IntegrationTest: health check"
+   }`)
+
+var jobDescriptorParallelTemplate = template.Must(template.New("jobDescriptorParallel").Parse(`
+{
+    "JobName": "test job with parallel tests",
+    "Runs": 1,
+    "RunInterval": "5s",
+    "ParallelTests": true,
+    "MaxParallelTests": 2,
+    "Tags": [
+        "integration_testing"
+    ],
+    "TestDescriptors": [
+    {{- range $idx, $test := .Tests }}
+        {{- if $idx }},{{ end }}
+        {
+            {{- if $test.TargetManager }}
+            {{ $test.TargetManager }},
+            {{- else }}
+            "TargetManagerName": "TargetList",
+            "TargetManagerAcquireParameters": {
+                "Targets": [
+                    {
+                        "ID": "{{ $test.TargetID }}",
+                        "Name": "{{ $test.TargetID }}.example.com"
+                    }
+                ]
+            },
+            "TargetManagerReleaseParameters": {},
+            {{- end }}
+            "TestFetcherName": "literal",
+            "TestFetcherFetchParameters": {
+                "Steps": [
+                    {{ $test.Step }}
+                ],
+                "TestName": "IntegrationTest: parallel {{ $idx }}"
+            }
+        }
+    {{- end }}
+    ],
+    "Reporting": {
+        "RunReporters": [
+            {
+                "Name": "TargetSuccess",
+                "Parameters": {
+                    "SuccessExpression": ">0%"
+                }
+            }
+        ]
+    }
+}
+`))
+
+// parallelTest is a test of a job running its tests in parallel, made of a
+// single step running on a single target. TargetManager optionally replaces
+// the target manager fields of the test descriptor.
+type parallelTest struct {
+	TargetID      string
+	TargetManager string
+	Step          string
+}
+
+// parallelTestsDescriptorMust returns the descriptor of a job running the
+// given tests in parallel
+func parallelTestsDescriptorMust(tests ...parallelTest) string {
+	var buf bytes.Buffer
+	data := struct {
+		Tests []parallelTest
+	}{Tests: tests}
+	if err := jobDescriptorParallelTemplate.Execute(&buf, data); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// parallelDescriptorMust returns the descriptor of a job running in parallel
+// one test per given target, made of the given step
+func parallelDescriptorMust(step string, targetIDs ...string) string {
+	tests := make([]parallelTest, 0, len(targetIDs))
+	for _, targetID := range targetIDs {
+		tests = append(tests, parallelTest{TargetID: targetID, Step: step})
+	}
+	return parallelTestsDescriptorMust(tests...)
+}
+
+const slowechoStep = `{"name": "slowecho", "parameters": {"sleep": ["3"], "text": ["Hello world"]}}`
+
+// jobDescriptorParallel runs two tests in parallel on disjoint targets
+var jobDescriptorParallel = parallelDescriptorMust(slowechoStep, "id1", "id2")
+
+// jobDescriptorParallelSharedTargets runs two tests in parallel on the same
+// target, which is not allowed
+var jobDescriptorParallelSharedTargets = parallelDescriptorMust(slowechoStep, "id1", "id1")
+
+// jobDescriptorParallelCrash runs in parallel a test which crashes, and a
+// test which succeeds
+var jobDescriptorParallelCrash = parallelTestsDescriptorMust(
+	parallelTest{TargetID: "id1", Step: `{"name": "crash", "parameters": {}}`},
+	parallelTest{TargetID: "id2", Step: `{"name": "noop", "parameters": {}}`},
+)
+
+// parallelCSVDescriptor returns the descriptor of a job running in parallel
+// two tests acquiring one target each from the same CSV file
+func parallelCSVDescriptor(csvPath string) string {
+	tm := fmt.Sprintf(`"TargetManagerName": "CSVFileTargetManager",
+            "TargetManagerAcquireParameters": {
+                "FileURI": %q,
+                "MinNumberDevices": 1,
+                "MaxNumberDevices": 1
+            },
+            "TargetManagerReleaseParameters": {}`, csvPath)
+	return parallelTestsDescriptorMust(
+		parallelTest{TargetManager: tm, Step: slowechoStep},
+		parallelTest{TargetManager: tm, Step: slowechoStep},
+	)
+}
+
+// jobDescriptorParallelResumable runs in parallel two tests holding their
+// targets until they are paused
+var jobDescriptorParallelResumable = parallelDescriptorMust(`{"name": "resumable", "parameters": {}}`, "id1", "id2")
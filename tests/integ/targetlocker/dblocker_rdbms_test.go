@@ -0,0 +1,43 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build integration_storage
+
+package test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/facebookincubator/contest/pkg/target"
+	"github.com/facebookincubator/contest/pkg/target/lockertest"
+	"github.com/facebookincubator/contest/pkg/types"
+	"github.com/facebookincubator/contest/plugins/targetlocker/dblocker"
+	"github.com/facebookincubator/contest/tests/integ/common"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDBLockerSuiteRdbms(t *testing.T) {
+	db, err := sql.Open("mysql", common.DBURI)
+	require.NoError(t, err)
+	defer db.Close()
+
+	newLocker := func(timeout time.Duration) target.Locker {
+		_, err := db.Exec("truncate target_locks")
+		require.NoError(t, err)
+		return dblocker.New(common.DBURI, timeout)
+	}
+	suite.Run(t, &lockertest.LockerSuite{NewLocker: newLocker})
+}
+
+func TestDBLockerRequiresTargetIDRdbms(t *testing.T) {
+	tl := dblocker.New(common.DBURI, time.Minute)
+	require.Error(t, tl.Lock(types.JobID(123), []*target.Target{{Name: "noID"}}))
+	allLocked, _, _ := tl.CheckLocks(types.JobID(123), []*target.Target{{Name: "noID"}})
+	require.False(t, allLocked)
+}
@@ -11,11 +11,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/facebookincubator/contest/pkg/cerrors"
 	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
 	"github.com/facebookincubator/contest/pkg/logging"
 	"github.com/facebookincubator/contest/pkg/pluginregistry"
 	"github.com/facebookincubator/contest/pkg/runner"
@@ -30,8 +32,10 @@ import (
 	"github.com/facebookincubator/contest/plugins/teststeps/example"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/channels"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/hanging"
+	"github.com/facebookincubator/contest/tests/plugins/teststeps/noop"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/noreturn"
 	"github.com/facebookincubator/contest/tests/plugins/teststeps/panicstep"
+	"github.com/facebookincubator/contest/tests/plugins/teststeps/resumable"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -50,6 +54,8 @@ var testSteps = map[string]test.TestStepFactory{
 	hanging.Name:   hanging.New,
 	channels.Name:  channels.New,
 	cmd.Name:       cmd.New,
+	noop.Name:      noop.New,
+	resumable.Name: resumable.New,
 }
 
 var testStepsEvents = map[string][]event.Name{
@@ -60,6 +66,8 @@ var testStepsEvents = map[string][]event.Name{
 	hanging.Name:   hanging.Events,
 	channels.Name:  channels.Events,
 	cmd.Name:       cmd.Events,
+	noop.Name:      noop.Events,
+	resumable.Name: resumable.Events,
 }
 
 func TestMain(m *testing.M) {
@@ -343,3 +351,151 @@ func TestCmdPlugin(t *testing.T) {
 		t.Errorf("test should return within timeout: %+v", successTimeout)
 	}
 }
+
+// targetsWithEvent returns the IDs of the targets for which the given event
+// was emitted by a test step
+func targetsWithEvent(t *testing.T, jobID types.JobID, testStepLabel string, eventName event.Name) []string {
+	ev := storage.NewTestEventFetcher()
+	events, err := ev.Fetch(
+		[]testevent.QueryField{
+			testevent.QueryJobID(jobID),
+			testevent.QueryTestStepLabel(testStepLabel),
+			testevent.QueryEventName(eventName),
+		},
+	)
+	require.NoError(t, err)
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.Data.Target.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestResume(t *testing.T) {
+
+	jobID := types.JobID(2)
+
+	ts1, err := pluginRegistry.NewTestStep("Noop")
+	require.NoError(t, err)
+	ts2, err := pluginRegistry.NewTestStep("Noop")
+	require.NoError(t, err)
+	ts3, err := pluginRegistry.NewTestStep("Resumable")
+	require.NoError(t, err)
+
+	params := make(test.TestStepParameters)
+	testSteps := []test.TestStepBundle{
+		test.TestStepBundle{TestStep: ts1, TestStepLabel: "FirstStage", TestStepIndex: 1, Parameters: params},
+		test.TestStepBundle{TestStep: ts2, TestStepLabel: "SecondStage", TestStepIndex: 2, Parameters: params},
+		test.TestStepBundle{TestStep: ts3, TestStepLabel: "ThirdStage", TestStepIndex: 3, Parameters: params},
+	}
+
+	failure := "target failed"
+	states := []runner.TargetState{
+		// completed before pausing, successfully or not
+		runner.TargetState{Target: targets[0], Completed: true},
+		runner.TargetState{Target: targets[1], Completed: true, Error: &failure},
+		// paused in the last step, which supports resume
+		runner.TargetState{Target: targets[2], TestStepLabel: "ThirdStage"},
+		// not entered in the pipeline yet
+		runner.TargetState{Target: targets[3]},
+		// paused in the second step, which does not support resume
+		runner.TargetState{Target: targets[4], TestStepLabel: "SecondStage"},
+	}
+
+	cancel := make(chan struct{})
+	pause := make(chan struct{})
+
+	type result struct {
+		res *test.TestResult
+		err error
+	}
+	resCh := make(chan result)
+	go func() {
+		tr := runner.NewTestRunner()
+		res, err := tr.Resume(cancel, pause, &test.Test{Name: "Resume", TestStepsBundles: testSteps}, states, jobID)
+		resCh <- result{res: res, err: err}
+	}()
+	var r result
+	select {
+	case r = <-resCh:
+		require.NoError(t, r.err)
+	case <-time.After(successTimeout):
+		t.Fatalf("test should return within timeout (%s)", successTimeout.String())
+	}
+
+	// all targets are part of the result, including the ones that completed
+	// before pausing
+	results := r.res.Targets()
+	require.Equal(t, len(targets), len(results))
+	for idx, tgt := range targets {
+		err, ok := results[tgt]
+		require.True(t, ok)
+		if idx == 1 {
+			require.EqualError(t, err, failure)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	// targets are routed straight to the step they were paused in
+	require.Equal(t, []string{"004"}, targetsWithEvent(t, jobID, "FirstStage", target.EventTargetIn))
+	require.Equal(t, []string{"004", "005"}, targetsWithEvent(t, jobID, "SecondStage", target.EventTargetIn))
+	require.Equal(t, []string{"003", "004", "005"}, targetsWithEvent(t, jobID, "ThirdStage", target.EventTargetIn))
+	// the last step supports resume, and it is resumed
+	require.Equal(t, []string{"003", "004", "005"}, targetsWithEvent(t, jobID, "ThirdStage", resumable.ResumedEvent))
+}
+
+func TestJoinDetach(t *testing.T) {
+
+	jobID := types.JobID(3)
+
+	ts1, err := pluginRegistry.NewTestStep("Noop")
+	require.NoError(t, err)
+	ts2, err := pluginRegistry.NewTestStep("Noop")
+	require.NoError(t, err)
+
+	params := make(test.TestStepParameters)
+	testSteps := []test.TestStepBundle{
+		test.TestStepBundle{TestStep: ts1, TestStepLabel: "FirstStage", TestStepIndex: 1, Parameters: params},
+		test.TestStepBundle{TestStep: ts2, TestStepLabel: "SecondStage", TestStepIndex: 2, Parameters: params},
+	}
+
+	cancel := make(chan struct{})
+	pause := make(chan struct{})
+	joined := make(chan *target.Target)
+
+	type result struct {
+		res *test.TestResult
+		err error
+	}
+	resCh := make(chan result)
+	tr := runner.NewTestRunner()
+	tr.Join(joined)
+	// the second target is detached before entering the pipeline
+	tr.Detach(targets[1])
+	go func() {
+		res, err := tr.Run(cancel, pause, &test.Test{Name: "JoinDetach", TestStepsBundles: testSteps}, targets[:2], jobID)
+		resCh <- result{res: res, err: err}
+	}()
+	joined <- targets[2]
+	close(joined)
+	var r result
+	select {
+	case r = <-resCh:
+		require.NoError(t, r.err)
+	case <-time.After(successTimeout):
+		t.Fatalf("test should return within timeout (%s)", successTimeout.String())
+	}
+
+	// the detached target has no result, the joined one completed the test
+	results := r.res.Targets()
+	require.Equal(t, 2, len(results))
+	for _, tgt := range []*target.Target{targets[0], targets[2]} {
+		err, ok := results[tgt]
+		require.True(t, ok)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"001", "003"}, targetsWithEvent(t, jobID, "SecondStage", target.EventTargetIn))
+	require.Equal(t, []string{"002"}, targetsWithEvent(t, jobID, "FirstStage", target.EventTargetDetached))
+}
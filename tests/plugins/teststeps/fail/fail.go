@@ -28,14 +28,23 @@ func (ts *fail) Name() string {
 	return Name
 }
 
-// Run executes a step which does never return.
+// Run fails the targets whose ID is listed in the optional "targets" parameter,
+// and lets the other ones through. If no target ID is listed, all targets fail.
 func (ts *fail) Run(cancel, pause <-chan struct{}, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter) error {
+	failing := make(map[string]bool)
+	for _, p := range params.Get("targets") {
+		failing[p.String()] = true
+	}
 	for {
 		select {
 		case target := <-ch.In:
 			if target == nil {
 				return nil
 			}
+			if len(failing) > 0 && !failing[target.ID] {
+				ch.Out <- target
+				continue
+			}
 			ch.Err <- cerrors.TargetError{Target: target, Err: fmt.Errorf("Integration test failure for %v", target)}
 		case <-cancel:
 			return nil
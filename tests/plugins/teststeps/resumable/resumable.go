@@ -0,0 +1,84 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package resumable
+
+import (
+	"github.com/facebookincubator/contest/pkg/event"
+	"github.com/facebookincubator/contest/pkg/event/testevent"
+	"github.com/facebookincubator/contest/pkg/test"
+)
+
+// Name is the name used to look this plugin up.
+var Name = "Resumable"
+
+// ResumedEvent is emitted for every target processed by a resumed step
+var ResumedEvent = event.Name("TargetResumed")
+
+// Events defines the events that a TestStep is allow to emit
+var Events = []event.Name{ResumedEvent}
+
+type resumable struct {
+}
+
+// Name returns the name of the Step
+func (ts *resumable) Name() string {
+	return Name
+}
+
+// Run holds all the targets until the step is paused or cancelled, so that
+// tests can pause a job while its targets are in this step.
+func (ts *resumable) Run(cancel, pause <-chan struct{}, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter) error {
+	in := ch.In
+	for {
+		select {
+		case target := <-in:
+			if target == nil {
+				// no more targets, keep waiting for pause or cancellation
+				in = nil
+			}
+		case <-cancel:
+			return nil
+		case <-pause:
+			return nil
+		}
+	}
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *resumable) ValidateParameters(params test.TestStepParameters) error {
+	return nil
+}
+
+// Resume lets all the targets through, emitting a ResumedEvent for each of
+// them.
+func (ts *resumable) Resume(cancel, pause <-chan struct{}, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.EmitterFetcher) error {
+	for {
+		select {
+		case target := <-ch.In:
+			if target == nil {
+				return nil
+			}
+			if err := ev.Emit(testevent.Data{EventName: ResumedEvent, Target: target}); err != nil {
+				return err
+			}
+			ch.Out <- target
+		case <-cancel:
+			return nil
+		case <-pause:
+			return nil
+		}
+	}
+}
+
+// CanResume tells whether this step is able to resume.
+func (ts *resumable) CanResume() bool {
+	return true
+}
+
+// New creates a new resumable step
+func New() test.TestStep {
+	return &resumable{}
+}